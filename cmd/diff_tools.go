@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/client"
+	"github.com/ceyewan/mcp-proxy/internal/config"
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 差异报告使用的终端颜色
+const (
+	colorGreen  = "\033[32m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// toolChange 工具输入 schema 的变化
+type toolChange struct {
+	Name      string          `json:"name"`
+	OldSchema json.RawMessage `json:"oldSchema"`
+	NewSchema json.RawMessage `json:"newSchema"`
+}
+
+// toolDiff 两份工具列表的差异
+type toolDiff struct {
+	Added   []string     `json:"added"`
+	Removed []string     `json:"removed"`
+	Changed []toolChange `json:"changed"`
+}
+
+// runDiffTools 比较两份配置（或缓存的工具列表）中的工具，输出新增、移除和 schema 变化的工具
+func runDiffTools(args []string) error {
+	flags := flag.NewFlagSet("diff-tools", flag.ExitOnError)
+	before := flags.String("before", "", "config file, config dir or url, or a JSON tool list saved by list-tools --json")
+	after := flags.String("after", "", "config file, config dir or url, or a JSON tool list saved by list-tools --json")
+	jsonOutput := flags.Bool("json", false, "print the diff as JSON")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout for connecting to each server and listing its tools")
+	_ = flags.Parse(args)
+
+	if *before == "" || *after == "" {
+		flags.Usage()
+		return fmt.Errorf("--before and --after are required")
+	}
+
+	beforeTools, err := loadToolSets(*before, *timeout)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", *before, err)
+	}
+	afterTools, err := loadToolSets(*after, *timeout)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", *after, err)
+	}
+
+	// 两边都只有一个服务器时直接按工具名比较，否则按 server/tool 比较
+	qualify := len(beforeTools) > 1 || len(afterTools) > 1
+	diff, err := diffTools(flattenToolSets(beforeTools, qualify), flattenToolSets(afterTools, qualify))
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	}
+	return printToolDiff(os.Stdout, diff, isTerminal(os.Stdout))
+}
+
+// loadToolSets 加载工具列表，返回服务器名称到工具列表的映射；JSON 数组文件视为单个服务器的缓存工具列表
+func loadToolSets(source string, timeout time.Duration) (map[string][]mcp.Tool, error) {
+	isURL := strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+	if !isURL && !config.IsDir(source) {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+			var tools []mcp.Tool
+			if err := json.Unmarshal(trimmed, &tools); err != nil {
+				return nil, fmt.Errorf("failed to parse tool list: %w", err)
+			}
+			return map[string][]mcp.Tool{"": tools}, nil
+		}
+	}
+
+	provider := config.NewProvider()
+	cfg, err := provider.Load(source)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	factory := client.NewFactory()
+	toolSets := make(map[string][]mcp.Tool, len(cfg.Servers))
+	for name, serverConfig := range cfg.Servers {
+		mcpClient, err := factory.CreateClient(name, serverConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client %s: %w", name, err)
+		}
+		tools, err := listServerTools(mcpClient, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools of %s: %w", name, err)
+		}
+		toolSets[name] = tools
+	}
+	return toolSets, nil
+}
+
+// listServerTools 连接服务器并列出其全部工具，完成后断开连接
+func listServerTools(mcpClient interfaces.MCPClient, timeout time.Duration) ([]mcp.Tool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := mcpClient.Connect(ctx, mcp.Implementation{Name: "mcp-proxy", Version: BuildVersion}); err != nil {
+		return nil, err
+	}
+	defer mcpClient.Disconnect()
+
+	return listAllTools(ctx, mcpClient)
+}
+
+// flattenToolSets 将各服务器的工具合并为以工具名（qualify 时为 server/tool）为键的映射
+func flattenToolSets(toolSets map[string][]mcp.Tool, qualify bool) map[string]mcp.Tool {
+	flat := make(map[string]mcp.Tool)
+	for server, tools := range toolSets {
+		for _, tool := range tools {
+			key := tool.Name
+			if qualify && server != "" {
+				key = server + "/" + tool.Name
+			}
+			flat[key] = tool
+		}
+	}
+	return flat
+}
+
+// diffTools 计算两组工具的差异，结果按名称排序
+func diffTools(before, after map[string]mcp.Tool) (toolDiff, error) {
+	diff := toolDiff{Added: []string{}, Removed: []string{}, Changed: []toolChange{}}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+	for name, oldTool := range before {
+		newTool, ok := after[name]
+		if !ok {
+			diff.Removed = append(diff.Removed, name)
+			continue
+		}
+		oldSchema, err := toolInputSchema(oldTool)
+		if err != nil {
+			return diff, err
+		}
+		newSchema, err := toolInputSchema(newTool)
+		if err != nil {
+			return diff, err
+		}
+		if !bytes.Equal(oldSchema, newSchema) {
+			diff.Changed = append(diff.Changed, toolChange{Name: name, OldSchema: oldSchema, NewSchema: newSchema})
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Name < diff.Changed[j].Name })
+	return diff, nil
+}
+
+// toolInputSchema 返回工具输入 schema 的规范化 JSON（键按字母排序），便于逐字节比较
+func toolInputSchema(tool mcp.Tool) (json.RawMessage, error) {
+	data, err := json.Marshal(tool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tool %s: %w", tool.Name, err)
+	}
+	var fields struct {
+		InputSchema any `json:"inputSchema"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse schema of tool %s: %w", tool.Name, err)
+	}
+	return json.Marshal(fields.InputSchema)
+}
+
+// printToolDiff 输出可读的差异报告，color 为 true 时使用终端颜色
+func printToolDiff(w io.Writer, diff toolDiff, color bool) error {
+	paint := func(code, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + colorReset
+	}
+
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0 {
+		_, err := fmt.Fprintln(w, "No tool changes")
+		return err
+	}
+
+	for _, name := range diff.Added {
+		fmt.Fprintln(w, paint(colorGreen, "+ "+name))
+	}
+	for _, name := range diff.Removed {
+		fmt.Fprintln(w, paint(colorRed, "- "+name))
+	}
+	for _, change := range diff.Changed {
+		fmt.Fprintln(w, paint(colorYellow, "~ "+change.Name))
+		fmt.Fprintln(w, paint(colorYellow, "    old: "+string(change.OldSchema)))
+		fmt.Fprintln(w, paint(colorYellow, "    new: "+string(change.NewSchema)))
+	}
+	_, err := fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	return err
+}
+
+// isTerminal 判断文件是否为终端，输出被重定向时不使用颜色
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/client"
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// listToolsDescriptionWidth 表格中描述的最大字符数
+const listToolsDescriptionWidth = 80
+
+// runListTools 直接连接单个 MCP 服务器并列出其工具，不启动代理
+func runListTools(args []string) error {
+	flags := flag.NewFlagSet("list-tools", flag.ExitOnError)
+	serverFlag := flags.String("server", "", "http(s) url of the MCP server, or the command line of a stdio server")
+	transport := flags.String("transport", "", "sse, streamable-http or stdio (default: stdio for commands, sse for urls ending in /sse, otherwise streamable-http)")
+	jsonOutput := flags.Bool("json", false, "print the tools as JSON instead of a table")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout for connecting and listing tools")
+	_ = flags.Parse(args)
+
+	if *serverFlag == "" {
+		flags.Usage()
+		return fmt.Errorf("--server is required")
+	}
+
+	serverConfig := listToolsServerConfig(*serverFlag, *transport)
+	mcpClient, err := client.NewFactory().CreateClient("list-tools", serverConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := mcpClient.Connect(ctx, mcp.Implementation{Name: "mcp-proxy", Version: BuildVersion}); err != nil {
+		return err
+	}
+	defer mcpClient.Disconnect()
+
+	tools, err := listAllTools(ctx, mcpClient)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(tools)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tDESCRIPTION")
+	for _, tool := range tools {
+		fmt.Fprintf(writer, "%s\t%s\n", tool.Name, truncateDescription(tool.Description, listToolsDescriptionWidth))
+	}
+	return writer.Flush()
+}
+
+// listAllTools 按分页游标列出客户端的全部工具
+func listAllTools(ctx context.Context, mcpClient interfaces.MCPClient) ([]mcp.Tool, error) {
+	var tools []mcp.Tool
+	request := mcp.ListToolsRequest{}
+	for {
+		result, err := mcpClient.ListTools(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		request.Params.Cursor = result.NextCursor
+	}
+}
+
+// listToolsServerConfig 根据命令行参数构造服务器配置
+func listToolsServerConfig(target, transport string) interfaces.ServerConfig {
+	isURL := strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+	if transport == "" {
+		switch {
+		case !isURL:
+			transport = interfaces.ClientTypeStdio
+		case strings.HasSuffix(strings.TrimSuffix(target, "/"), "/sse"):
+			transport = interfaces.ClientTypeSSE
+		default:
+			transport = interfaces.ClientTypeStreamable
+		}
+	}
+
+	serverConfig := interfaces.ServerConfig{Transport: transport}
+	if transport == interfaces.ClientTypeStdio {
+		fields := strings.Fields(target)
+		serverConfig.Command = fields[0]
+		serverConfig.Args = fields[1:]
+	} else {
+		serverConfig.URL = target
+	}
+	return serverConfig
+}
+
+// truncateDescription 将描述压缩为单行并截断到最多 width 个字符
+func truncateDescription(description string, width int) string {
+	description = strings.Join(strings.Fields(description), " ")
+	runes := []rune(description)
+	if len(runes) <= width {
+		return description
+	}
+	return string(runes[:width-3]) + "..."
+}
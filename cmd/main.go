@@ -4,18 +4,52 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/ceyewan/mcp-proxy/internal/app"
+	"github.com/ceyewan/mcp-proxy/internal/config"
 )
 
 var BuildVersion = "dev"
 
 func main() {
-	conf := flag.String("config", "config.json", "path to config file or a http(s) url")
+	// 子命令
+	if len(os.Args) > 1 && os.Args[1] == "list-tools" {
+		if err := runListTools(os.Args[2:]); err != nil {
+			log.Fatalf("Failed to list tools: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff-tools" {
+		if err := runDiffTools(os.Args[2:]); err != nil {
+			log.Fatalf("Failed to diff tools: %v", err)
+		}
+		return
+	}
+
+	conf := flag.String("config", "config.json", "path to config file or a http(s) url; defaults to $MCP_PROXY_CONFIG or $MCP_PROXY_CONFIG_URL when not given")
+	confDir := flag.String("config-dir", "", "path to a config directory (proxy.json plus one <server>.json per server), watched for changes; overrides -config")
 	version := flag.Bool("version", false, "print version and exit")
 	help := flag.Bool("help", false, "print help and exit")
+	schema := flag.Bool("schema", false, "print the embedded config JSON schema and exit")
+	generateSchema := flag.Bool("generate-schema", false, "generate the config JSON schema from source and exit")
+	migrateConfig := flag.String("migrate-config", "", "read an old or partial config, print the complete current config and exit")
+	validate := flag.Bool("validate", false, "validate the config in strict mode (unknown fields are errors) and exit")
 	flag.Parse()
 
+	if *confDir != "" {
+		*conf = *confDir
+	} else if !flagPassed("config") {
+		path, err := configFromEnv()
+		if err != nil {
+			log.Fatalf("Invalid config environment: %v", err)
+		}
+		if path != "" {
+			*conf = path
+		}
+	}
+
 	if *help {
 		flag.Usage()
 		return
@@ -26,8 +60,39 @@ func main() {
 		return
 	}
 
+	if *schema {
+		os.Stdout.Write(config.Schema())
+		return
+	}
+
+	if *generateSchema {
+		data, err := config.GenerateSchema()
+		if err != nil {
+			log.Fatalf("Failed to generate schema: %v", err)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+
+	if *migrateConfig != "" {
+		data, err := config.Migrate(*migrateConfig)
+		if err != nil {
+			log.Fatalf("Failed to migrate config: %v", err)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+
+	if *validate {
+		if err := config.ValidateFile(*conf); err != nil {
+			log.Fatalf("Invalid config: %v", err)
+		}
+		fmt.Printf("Config %s is valid\n", *conf)
+		return
+	}
+
 	// 创建应用实例
-	application, err := app.New()
+	application, err := app.New(BuildVersion)
 	if err != nil {
 		log.Fatalf("Failed to create application: %v", err)
 	}
@@ -37,3 +102,28 @@ func main() {
 		log.Fatalf("Application failed: %v", err)
 	}
 }
+
+// flagPassed 判断命令行是否显式传入了指定参数
+func flagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
+
+// configFromEnv 从环境变量获取配置路径，MCP_PROXY_CONFIG 优先于 MCP_PROXY_CONFIG_URL，均未设置时返回空字符串
+func configFromEnv() (string, error) {
+	if path := os.Getenv("MCP_PROXY_CONFIG"); path != "" {
+		return path, nil
+	}
+	if url := os.Getenv("MCP_PROXY_CONFIG_URL"); url != "" {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return "", fmt.Errorf("MCP_PROXY_CONFIG_URL must be a http(s) url: %s", url)
+		}
+		return url, nil
+	}
+	return "", nil
+}
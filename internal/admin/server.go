@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// Server 管理 API 服务器，监听独立于代理的地址
+type Server struct {
+	mux        *http.ServeMux
+	httpServer *http.Server
+}
+
+// New 创建新的管理 API 服务器，middlewares 按顺序包裹所有路由
+func New(addr string, middlewares ...interfaces.Middleware) *Server {
+	mux := http.NewServeMux()
+
+	// 从后往前包裹中间件
+	var handler http.Handler = mux
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i].Handle(handler)
+	}
+
+	return &Server{
+		mux: mux,
+		httpServer: &http.Server{
+			Addr:    addr,
+			Handler: handler,
+		},
+	}
+}
+
+// Handle 注册路由
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandleFunc 注册路由处理函数
+func (s *Server) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// Start 在后台启动管理 API 服务
+func (s *Server) Start() {
+	go func() {
+		slog.Info("Starting admin server", "addr", s.httpServer.Addr)
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Admin server failed", "error", err)
+		}
+	}()
+}
+
+// Shutdown 关闭管理 API 服务
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// WriteJSON 以 JSON 格式写出响应
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write admin response", "error", err)
+	}
+}
@@ -2,39 +2,97 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
-	"path"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/ceyewan/mcp-proxy/internal/admin"
 	"github.com/ceyewan/mcp-proxy/internal/client"
 	"github.com/ceyewan/mcp-proxy/internal/config"
+	"github.com/ceyewan/mcp-proxy/internal/cost"
+	"github.com/ceyewan/mcp-proxy/internal/events"
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/logging"
 	"github.com/ceyewan/mcp-proxy/internal/middleware/auth"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/bodylimit"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/connlimit"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/cors"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/jwtauth"
 	"github.com/ceyewan/mcp-proxy/internal/middleware/logger"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/metrics"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/ratelimit"
 	"github.com/ceyewan/mcp-proxy/internal/middleware/recovery"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/requestid"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/responseheaders"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/servertiming"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/sseage"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/tracing"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/version"
 	"github.com/ceyewan/mcp-proxy/internal/server"
 	"github.com/mark3labs/mcp-go/mcp"
-	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// connectRetryMinBackoff 连接超时后后台重试的初始间隔
+	connectRetryMinBackoff = time.Second
+	// connectRetryMaxBackoff 连接超时后后台重试的最大间隔
+	connectRetryMaxBackoff = time.Minute
+)
+
+var (
+	// errConnectTimeout 客户端未能在 connectTimeout 内完成连接
+	errConnectTimeout = errors.New("connect timeout")
+	// errFatalStartup 服务器启动失败且配置要求终止代理
+	errFatalStartup = errors.New("fatal server startup error")
 )
 
 // Application 应用程序主体
 type Application struct {
-	configProvider interfaces.ConfigProvider
-	clientFactory  interfaces.ClientFactory
-	clientManager  interfaces.ClientManager
-	serverManager  interfaces.ServerManager
+	configProvider  interfaces.ConfigProvider
+	clientFactory   interfaces.ClientFactory
+	clientManager   interfaces.ClientManager
+	serverManager   *server.Manager
+	metrics         *metrics.Metrics
+	costTracker     *cost.Tracker
+	eventBus        *events.Bus
+	buildVersion    string
+	startTime       time.Time
+	configSource    string
+	listeners       []*listener
+	adminServer     *admin.Server
+	pprofServer     *admin.Server
+	cancel          context.CancelFunc
+	readyServers    atomic.Int32
+	optionalServers sync.Map
+	logLevel        slog.LevelVar
+	logFormat       string
+	logCloser       io.Closer
+	globalLimiter   *rate.Limiter
+	runCtx          context.Context
+	runningConfig   *interfaces.Config
+	clientInfo      mcp.Implementation
+	reloadMutex     sync.Mutex
+	fatal           chan error
 }
 
-// New 创建新的应用实例
-func New() (*Application, error) {
+// New 创建新的应用实例，buildVersion 为构建时注入的版本号
+func New(buildVersion string) (*Application, error) {
 	// 创建配置提供者
 	configProvider := config.NewProvider()
 
@@ -52,11 +110,71 @@ func New() (*Application, error) {
 		clientFactory:  clientFactory,
 		clientManager:  clientManager,
 		serverManager:  serverManager,
+		buildVersion:   buildVersion,
+		eventBus:       events.NewBus(0),
+		fatal:          make(chan error, 1),
 	}, nil
 }
 
-// Run 运行应用程序
+// Run 运行应用程序，直到收到退出信号
 func (app *Application) Run(configPath string) error {
+	// 加载配置之前先输出到标准错误，配置了 logOutput 时在 Start 中切换
+	app.configSource = configPath
+	app.installLogger(os.Stderr)
+
+	if err := app.Start(configPath); err != nil {
+		return err
+	}
+
+	// SIGHUP 重新加载配置，不影响 HTTP 监听和已有连接
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	defer func() {
+		signal.Stop(reloadChan)
+		close(reloadChan)
+	}()
+	go func() {
+		for range reloadChan {
+			slog.Info("Reload signal received")
+			if err := app.Reload(); err != nil {
+				slog.Warn("Config reload failed, keeping current config", "error", err)
+			}
+		}
+	}()
+
+	// 监听系统信号
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	var runErr error
+	select {
+	case <-sigChan:
+		slog.Info("Shutdown signal received")
+	case runErr = <-app.fatal:
+		slog.Error("Fatal error, shutting down", "error", runErr)
+	}
+
+	// 优雅关闭
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		// 客户端停止失败不影响退出，单独记录以便与 HTTP 服务关闭错误区分
+		slog.Warn("Shutdown completed with client stop errors", "error", err)
+	}
+	return runErr
+}
+
+// installLogger 按 logFormat 将所有日志（包括 log.Printf）输出到 writer，并带上配置来源，便于区分多份配置的实例
+func (app *Application) installLogger(writer io.Writer) {
+	handler := logging.NewHandler(app.logFormat, writer, &slog.HandlerOptions{Level: &app.logLevel}).WithAttrs([]slog.Attr{
+		slog.String("config_source", app.configSource),
+	})
+	slog.SetDefault(slog.New(requestid.NewLogHandler(handler)))
+}
+
+// Start 加载配置、启动所有客户端并在后台提供 HTTP 服务
+func (app *Application) Start(configPath string) error {
 	// 加载配置
 	config, err := app.configProvider.Load(configPath)
 	if err != nil {
@@ -68,9 +186,71 @@ func (app *Application) Run(configPath string) error {
 		return err
 	}
 
+	app.configSource = configPath
+	app.logFormat = config.Proxy.LogFormat
+
+	// 切换日志输出目标和格式
+	if options := config.Proxy.Options; options != nil && options.LogOutput != "" && options.LogOutput != logging.OutputStderr {
+		writer, closer, err := logging.Open(logging.Options{
+			Output:         options.LogOutput,
+			RotateSizeMB:   options.LogRotateSizeMB,
+			SyslogFacility: options.SyslogFacility,
+			SyslogSeverity: options.SyslogSeverity,
+			SyslogTag:      config.Proxy.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to open log output: %w", err)
+		}
+		app.logCloser = closer
+		app.installLogger(writer)
+	} else if app.logFormat == logging.FormatJSON {
+		app.installLogger(os.Stderr)
+	}
+
+	// 调试模式开启详细日志
+	if debugMode(config.Proxy.Options) {
+		app.logLevel.Set(slog.LevelDebug)
+		slog.Info("Debug mode enabled: verbose logging, pprof and panic stack traces are on")
+	}
+
+	// 每个 SSE 连接、stdio 子进程和上游连接都占用文件描述符
+	applyFDLimit(config.Proxy.MaxOpenFDs)
+
+	// 在连接任何上游之前检查端口冲突
+	if err := checkPortConflicts(config); err != nil {
+		return err
+	}
+
+	app.startTime = time.Now()
+
+	// 创建指标与成本统计
+	app.metrics = metrics.New(config.Proxy.MetricsPrefix)
+	app.costTracker = cost.NewTracker(app.metrics)
+
+	// 所有服务器共享的全局限流器，服务器自身的 rateLimit 不继承该配置
+	if rateLimit := config.Proxy.Options.RateLimit; rateLimit != nil {
+		app.globalLimiter = ratelimit.NewLimiter(rateLimit.Rate, rateLimit.Burst)
+	}
+
+	// 连接耗时指标依赖配置中的指标前缀，因此在加载配置后创建客户端工厂
+	app.clientFactory = client.NewFactory(
+		client.WithConnectRecorder(app.metrics),
+		client.WithBreakerRecorder(app.metrics),
+		client.WithStateChangeHandler(app.eventBus.PublishStateChange),
+	)
+
+	// 内置的工具调用事件订阅者
+	events.StartMetricsCollector(app.eventBus, app.metrics)
+
+	// 客户端连接状态指标
+	events.StartClientStateCollector(app.eventBus, app.metrics)
+	if config.Proxy.Options != nil && config.Proxy.Options.LogEnabled != nil && *config.Proxy.Options.LogEnabled {
+		events.StartAuditLogger(app.eventBus)
+	}
+
 	// 创建上下文
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	app.cancel = cancel
 
 	// 创建所有客户端
 	for name, serverConfig := range config.Servers {
@@ -81,139 +261,786 @@ func (app *Application) Run(configPath string) error {
 		if err := app.clientManager.AddClient(client); err != nil {
 			return fmt.Errorf("failed to add client %s: %w", name, err)
 		}
+		app.clientManager.SetStopTimeout(name, serverStopTimeout(serverConfig))
+		app.setOptional(name, serverConfig)
 	}
 
 	// 启动所有客户端
 	clientInfo := mcp.Implementation{
-		Name: config.Proxy.Name,
+		Name:    config.Proxy.Name,
+		Version: app.buildVersion,
 	}
-	if err := app.clientManager.StartAll(ctx, clientInfo); err != nil {
-		return err
+	if config.Proxy.ClientInfoName != "" {
+		clientInfo.Name = config.Proxy.ClientInfoName
+	}
+	if config.Proxy.ClientInfoVersion != "" {
+		clientInfo.Version = config.Proxy.ClientInfoVersion
 	}
 
-	// 创建并启动 HTTP 服务器
-	httpServer, err := app.createHTTPServer(config)
-	if err != nil {
+	// 为主代理和 proxies 中的每个代理创建 HTTP 服务器，上游连接成功后再动态注册对应路由
+	proxies := []*interfaces.ProxyConfig{&config.Proxy}
+	for i := range config.Proxies {
+		proxies = append(proxies, &config.Proxies[i])
+	}
+	for i, proxy := range proxies {
+		servedBy := ""
+		if i > 0 {
+			servedBy = proxy.Name
+		}
+		l, err := app.createHTTPServer(config, proxy, servedBy)
+		if err != nil {
+			return err
+		}
+
+		// 先监听端口，便于获取实际地址并尽早暴露端口冲突
+		if err := l.listen(app.fail); err != nil {
+			return err
+		}
+		app.listeners = append(app.listeners, l)
+	}
+
+	// 启动管理 API 服务
+	if config.Proxy.AdminAddr != "" {
+		app.adminServer = app.createAdminServer(config)
+		app.adminServer.Start()
+	}
+
+	// 启动独立的 pprof 服务，pprof 从不注册在代理端口上
+	if pprofEnabled(&config.Proxy) && config.Proxy.AdminAddr == "" && config.Proxy.PprofAddr == "" {
+		slog.Warn("Debug mode pprof requires adminAddr or pprofAddr, not serving pprof")
+	}
+	if pprofEnabled(&config.Proxy) && config.Proxy.PprofAddr != "" {
+		app.pprofServer = app.createPprofServer(config)
+		app.pprofServer.Start()
+	}
+
+	// 记录运行状态，供重新加载配置时增量应用变化
+	app.runCtx = ctx
+	app.runningConfig = config
+	app.clientInfo = clientInfo
+
+	// 后台连接所有客户端，每个客户端就绪后立即提供服务
+	app.startClients(ctx, config, clientInfo)
+
+	// 使用配置目录时监视目录变化，增量添加和移除服务器
+	app.watchConfigDir(ctx, configPath)
+
+	slog.Info("Proxy started", "proxy", config.Proxy.Name, "servers", len(config.Servers), "config", configPath)
+	return nil
+}
+
+// startClients 并发连接所有客户端，连接成功后注册代理服务器和路由
+//
+// 启用 panicIfInvalid 的服务器连接失败时报告致命错误，由 Run 关闭应用后退出，否则只记录错误并跳过该服务器。
+// 连接超过 connectTimeout 时按 connectTimeoutBehavior 跳过、报告致命错误或在后台重试。
+func (app *Application) startClients(ctx context.Context, config *interfaces.Config, clientInfo mcp.Implementation) {
+	for name, mcpClient := range app.clientManager.GetClients() {
+		serverConfig := config.Servers[name]
+
+		go func() {
+			err := app.startClient(ctx, config, name, serverConfig, mcpClient, clientInfo)
+			if err != nil && ctx.Err() != nil {
+				// 应用正在关闭，连接被取消
+				return
+			}
+			// connectTimeoutBehavior 为 skip 时视为未连接，不受 panicIfInvalid 影响
+			panicIfInvalid := serverConfig.Options != nil && serverConfig.Options.PanicIfInvalid != nil && *serverConfig.Options.PanicIfInvalid
+			if err != nil && !errors.Is(err, errConnectTimeout) && panicIfInvalid {
+				err = fmt.Errorf("%w: %w", errFatalStartup, err)
+			}
+			if errors.Is(err, errFatalStartup) {
+				slog.Error("Failed to start server", "server", interfaces.ServerLogTag(name, serverConfig), "error", err)
+				app.fail(fmt.Errorf("failed to start server %s: %w", name, err))
+				return
+			}
+			if err != nil {
+				slog.Error("Failed to start server, skipping", "server", interfaces.ServerLogTag(name, serverConfig), "error", err)
+				return
+			}
+
+			if ready := app.readyServers.Add(1); int(ready) == len(config.Servers) {
+				slog.Info("All servers are ready")
+			}
+		}()
+	}
+}
+
+// fail 报告导致代理无法继续运行的错误，Run 收到后关闭应用并返回该错误；只保留第一个错误
+func (app *Application) fail(err error) {
+	select {
+	case app.fatal <- err:
+	default:
+	}
+}
+
+// Fatal 返回接收致命错误的通道，通过 Start 启动应用的调用方收到错误后应调用 Shutdown
+func (app *Application) Fatal() <-chan error {
+	return app.fatal
+}
+
+// startClient 连接单个客户端并注册对应的代理服务器和路由
+func (app *Application) startClient(ctx context.Context, config *interfaces.Config, name string, serverConfig interfaces.ServerConfig, mcpClient interfaces.MCPClient, clientInfo mcp.Implementation) error {
+	slog.Info("Starting client", "server", interfaces.ServerLogTag(name, serverConfig), "client", name)
+	start := time.Now()
+	if err := app.connectClient(ctx, name, serverConfig, mcpClient, clientInfo); err != nil {
 		return err
 	}
+	slog.Info("Successfully started client", "server", interfaces.ServerLogTag(name, serverConfig), "client", name, "duration", time.Since(start))
+	return app.registerServer(config, name, serverConfig, mcpClient)
+}
 
-	// 启动 HTTP 服务
-	go func() {
-		log.Printf("Starting HTTP server on %s", config.Proxy.Addr)
-		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("Failed to start HTTP server: %v", err)
+// connectClient 连接客户端，配置了 connectTimeout 时按 connectTimeoutBehavior 处理超时
+func (app *Application) connectClient(ctx context.Context, name string, serverConfig interfaces.ServerConfig, mcpClient interfaces.MCPClient, clientInfo mcp.Implementation) error {
+	// 配置已在加载时校验
+	timeout, _ := time.ParseDuration(serverConfig.ConnectTimeout)
+	if timeout <= 0 {
+		return mcpClient.Connect(ctx, clientInfo)
+	}
+
+	backoff := connectRetryMinBackoff
+	for {
+		err := connectWithTimeout(ctx, mcpClient, clientInfo, timeout)
+		if !errors.Is(err, errConnectTimeout) {
+			return err
 		}
-	}()
 
-	// 监听系统信号
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		switch serverConfig.ConnectTimeoutBehavior {
+		case interfaces.ConnectTimeoutBehaviorFatal:
+			return fmt.Errorf("%w: %w", errFatalStartup, err)
+		case interfaces.ConnectTimeoutBehaviorRetry:
+			slog.Warn("Failed to connect within timeout, retrying", "server", interfaces.ServerLogTag(name, serverConfig), "timeout", timeout, "backoff", backoff)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, connectRetryMaxBackoff)
+		default:
+			return err
+		}
+	}
+}
 
-	<-sigChan
-	log.Println("Shutdown signal received")
+// connectWithTimeout 在 timeout 内完成连接，超时返回 errConnectTimeout
+//
+// 连接上下文仅在超时时取消：SSE 流和保活任务在连接成功后仍沿用该上下文。
+func connectWithTimeout(ctx context.Context, mcpClient interfaces.MCPClient, clientInfo mcp.Implementation, timeout time.Duration) error {
+	connectCtx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(timeout, cancel)
 
-	// 优雅关闭
-	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
-	defer shutdownCancel()
+	err := mcpClient.Connect(connectCtx, clientInfo)
+	if timer.Stop() {
+		return err
+	}
+	if err == nil {
+		// 连接恰好在超时时刻完成，上下文已被取消，断开后按超时处理
+		_ = mcpClient.Disconnect()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("%w after %s", errConnectTimeout, timeout)
+}
+
+// Addr 返回主代理 HTTP 服务实际监听的地址，仅在 Start 成功后有效
+func (app *Application) Addr() string {
+	return app.primary().addr
+}
+
+// EventBus 返回工具调用生命周期和客户端连接状态事件总线，外部代码可通过 Subscribe 和 SubscribeStateChanges 订阅
+func (app *Application) EventBus() events.EventBus {
+	return app.eventBus
+}
+
+// Shutdown 关闭 HTTP 服务、管理 API 服务并停止所有客户端
+func (app *Application) Shutdown(ctx context.Context) error {
+	// 取消仍在进行的客户端连接
+	if app.cancel != nil {
+		app.cancel()
+	}
 
 	// 关闭 HTTP 服务器
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Error shutting down HTTP server: %v", err)
+	for _, l := range app.listeners {
+		if err := l.httpServer.Shutdown(ctx); err != nil {
+			slog.Error("Error shutting down HTTP server", "proxy", l.proxy.Name, "error", err)
+		}
 	}
 
-	// 停止所有客户端
-	if err := app.clientManager.StopAll(); err != nil {
-		log.Printf("Error stopping clients: %v", err)
+	// 关闭管理 API 服务
+	if app.adminServer != nil {
+		if err := app.adminServer.Shutdown(ctx); err != nil {
+			slog.Error("Error shutting down admin server", "error", err)
+		}
 	}
+	if app.pprofServer != nil {
+		if err := app.pprofServer.Shutdown(ctx); err != nil {
+			slog.Error("Error shutting down pprof server", "error", err)
+		}
+	}
+
+	// 停止所有客户端，错误在清理完成后返回
+	stopErr := app.clientManager.StopAll()
+
+	// 关闭事件总线，结束所有订阅者
+	app.eventBus.Close()
+
+	slog.Info("Application shutdown complete")
 
-	log.Println("Application shutdown complete")
+	// 最后关闭日志输出，之后的日志回到标准错误
+	if app.logCloser != nil {
+		app.installLogger(os.Stderr)
+		_ = app.logCloser.Close()
+		app.logCloser = nil
+	}
+	if stopErr != nil {
+		return fmt.Errorf("failed to stop clients: %w", stopErr)
+	}
 	return nil
 }
 
-// createHTTPServer 创建 HTTP 服务器
-func (app *Application) createHTTPServer(config *interfaces.Config) (*http.Server, error) {
+// serverStopTimeout 返回服务器配置的客户端停止超时时间，未配置时返回 0
+func serverStopTimeout(serverConfig interfaces.ServerConfig) time.Duration {
+	if serverConfig.Options == nil || serverConfig.Options.StopTimeout == "" {
+		return 0
+	}
+	// 配置加载时已校验
+	timeout, _ := time.ParseDuration(serverConfig.Options.StopTimeout)
+	return timeout
+}
+
+// createHTTPServer 为代理创建监听器和 HTTP 服务器，初始只包含探针路由
+func (app *Application) createHTTPServer(config *interfaces.Config, proxy *interfaces.ProxyConfig, servedBy string) (*listener, error) {
 	// 解析基础 URL
-	baseURL, err := url.Parse(config.Proxy.BaseURL)
+	baseURL, err := url.Parse(proxy.BaseURL)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建 HTTP 多路复用器
-	mux := http.NewServeMux()
+	// 创建可动态注册的路由表
+	l := &listener{proxy: proxy, servedBy: servedBy, routes: newRouteTable(), basePath: baseURL.Path}
 
-	// 创建错误组用于并发初始化
-	var errorGroup errgroup.Group
+	// 存活探针
+	healthPath := strings.TrimSuffix(proxy.HealthPath, "/")
+	l.routes.HandleFunc("GET "+healthPath+"/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
 
-	// 为每个客户端创建代理服务器和路由
-	clients := app.clientManager.GetClients()
-	for name, mcpClient := range clients {
-		serverConfig := config.Servers[name]
+	// 就绪探针：任一必需的服务器不健康时返回 503，响应中列出不健康的服务器
+	pingUpstreams := proxy.ReadyzPingUpstreams != nil && *proxy.ReadyzPingUpstreams
+	pingTimeout := defaultReadyzPingTimeout
+	// 配置加载时已校验
+	if timeout, _ := time.ParseDuration(proxy.ReadyzPingTimeout); timeout > 0 {
+		pingTimeout = timeout
+	}
+	l.routes.HandleFunc("GET "+healthPath+"/readyz", func(w http.ResponseWriter, r *http.Request) {
+		// 开启 readyzPingUpstreams 时实际 ping 每个上游，ping 失败的上游同样视为不健康
+		var pings map[string]upstreamPing
+		if pingUpstreams {
+			pings = app.pingUpstreams(r.Context(), pingTimeout)
+		}
+		status, report := app.readiness(pings)
+		admin.WriteJSON(w, status, report)
+	})
 
-		errorGroup.Go(func() error {
-			// 创建代理服务器
-			proxyServer, err := server.NewProxyServer(name, &config.Proxy, serverConfig)
-			if err != nil {
-				return err
-			}
+	// Prometheus 指标
+	if proxy.MetricsPath != "" {
+		l.routes.Handle("GET "+proxy.MetricsPath, app.metrics.Handler())
+	}
 
-			// 注册客户端到代理服务器
-			if err := proxyServer.RegisterClient(mcpClient); err != nil {
-				return err
-			}
+	// 合并所有服务器的工具发现路由和 OpenAPI 文档
+	if proxy.Options != nil && proxy.Options.ToolsDiscoveryEnabled != nil && *proxy.Options.ToolsDiscoveryEnabled {
+		if err := app.registerAggregatedTools(l); err != nil {
+			return nil, fmt.Errorf("failed to register aggregated tools: %w", err)
+		}
+	}
+
+	// 扇出工具
+	if len(proxy.FanOutGroups) > 0 {
+		if err := app.registerFanOutServer(l); err != nil {
+			return nil, fmt.Errorf("failed to register fan-out tools: %w", err)
+		}
+	}
 
-			// 创建中间件链
-			middlewares := app.createMiddlewares(name, &serverConfig)
+	var handler http.Handler = l.routes
 
-			// 构造路由前缀
-			mcpRoute := path.Join(baseURL.Path, name)
-			if !strings.HasPrefix(mcpRoute, "/") {
-				mcpRoute = "/" + mcpRoute
+	// CONNECT 隧道，仅允许连接到该代理提供的上游
+	if proxy.CONNECTProxy != nil && *proxy.CONNECTProxy {
+		var tunnel http.Handler = server.NewTunnelHandler(upstreamHosts(config, servedBy))
+		if proxy.Options != nil && len(proxy.Options.AuthTokens) > 0 {
+			tunnel = auth.New(proxy.Options.AuthTokens).Handle(tunnel)
+		}
+		routes := l.routes
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodConnect {
+				tunnel.ServeHTTP(w, r)
+				return
 			}
-			if !strings.HasSuffix(mcpRoute, "/") {
-				mcpRoute += "/"
+			routes.ServeHTTP(w, r)
+		})
+		slog.Info("CONNECT tunneling enabled", "proxy", proxy.Name)
+	}
+
+	// SSE 连接最长存活时间，配置加载时已校验
+	sseMaxAge, _ := time.ParseDuration(proxy.SSEMaxConnectionAge)
+	handler = sseage.New(sseMaxAge).Handle(handler)
+
+	// SSE 连接数限制
+	l.sseLimiter = connlimit.New(proxy.MaxSSEConnections, app.metrics.IncConnectionsRejected)
+	handler = l.sseLimiter.Handle(handler)
+
+	// 创建 HTTP 服务器
+	l.httpServer = &http.Server{
+		Addr:    proxy.Addr,
+		Handler: clearStreamDeadlines(version.New(app.buildVersion).Handle(responseheaders.New(responseHeaders(proxy)).Handle(handler))),
+	}
+	applyServerTimeouts(l.httpServer, proxy.ServerTimeouts)
+
+	// 证书在启动时加载，便于尽早暴露证书错误
+	if proxy.TLS != nil {
+		tlsConfig, err := newTLSConfig(proxy.TLS)
+		if err != nil {
+			return nil, err
+		}
+		l.httpServer.TLSConfig = tlsConfig
+	}
+
+	return l, nil
+}
+
+// debugMode 是否开启调试模式
+func debugMode(options *interfaces.OptionsConfig) bool {
+	return options != nil && options.DebugMode != nil && *options.DebugMode
+}
+
+// responseHeaders 返回代理级配置的自定义响应头
+func responseHeaders(proxy *interfaces.ProxyConfig) map[string]string {
+	if proxy.Options == nil {
+		return nil
+	}
+	return proxy.Options.ResponseHeaders
+}
+
+// upstreamHosts 返回 servedBy 代理提供的所有 HTTP 类上游的 host:port
+func upstreamHosts(config *interfaces.Config, servedBy string) []string {
+	var hosts []string
+	for _, serverConfig := range config.Servers {
+		if serverConfig.ServedBy != servedBy {
+			continue
+		}
+		for _, rawURL := range append([]string{serverConfig.URL}, serverConfig.URLs...) {
+			if host := upstreamHost(rawURL); host != "" {
+				hosts = append(hosts, host)
 			}
+		}
+	}
+	return hosts
+}
 
-			// 注册路由
-			handler := app.chainMiddleware(proxyServer.GetHandler(), middlewares...)
-			mux.Handle(mcpRoute, handler)
+// upstreamHost 返回上游地址的 host:port，未指定端口时按协议补全
+func upstreamHost(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	upstreamURL, err := url.Parse(rawURL)
+	if err != nil || upstreamURL.Host == "" {
+		return ""
+	}
+	if upstreamURL.Port() != "" {
+		return upstreamURL.Host
+	}
+	port := "80"
+	if upstreamURL.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(upstreamURL.Hostname(), port)
+}
 
-			log.Printf("<%s> Registered route: %s", name, mcpRoute)
-			return nil
-		})
+// registerServer 为已连接的客户端创建代理服务器并注册到 servedBy 对应代理的路由
+func (app *Application) registerServer(config *interfaces.Config, name string, serverConfig interfaces.ServerConfig, mcpClient interfaces.MCPClient) error {
+	// 代理列表变化需要重启才能生效
+	l := app.listenerFor(serverConfig)
+	if l == nil {
+		return fmt.Errorf("proxy %s is not running, restart to apply", serverConfig.ServedBy)
 	}
 
-	// 等待所有代理服务器初始化完成
-	if err := errorGroup.Wait(); err != nil {
-		return nil, err
+	// 创建代理服务器
+	proxyServer, err := server.NewProxyServer(name, l.proxy, serverConfig, server.WithCostTracker(app.costTracker), server.WithCacheRecorder(app.metrics), server.WithEventBus(app.eventBus))
+	if err != nil {
+		return err
 	}
 
-	// 创建 HTTP 服务器
-	httpServer := &http.Server{
-		Addr:    config.Proxy.Addr,
-		Handler: mux,
+	// 注册客户端到代理服务器
+	if err := proxyServer.RegisterClient(mcpClient); err != nil {
+		return err
+	}
+	if err := app.serverManager.AddServer(name, proxyServer); err != nil {
+		return err
+	}
+
+	// 创建中间件链
+	middlewares, err := app.createMiddlewares(interfaces.ServerLogTag(name, serverConfig), &serverConfig)
+	if err != nil {
+		return err
+	}
+
+	// 构造路由前缀
+	mcpRoute := l.serverRoute(name)
+
+	// 注册工具发现路由
+	if serverConfig.Options != nil && serverConfig.Options.ToolsDiscoveryEnabled != nil && *serverConfig.Options.ToolsDiscoveryEnabled {
+		l.routes.Handle("GET "+mcpRoute+"tools", app.chainMiddleware(proxyServer.ToolsHandler(), middlewares...))
+		l.routes.Handle("POST "+mcpRoute+"tools/{tool}", app.chainMiddleware(proxyServer.CallToolHandler(), middlewares...))
+		slog.Info("Registered tools discovery route", "server", interfaces.ServerLogTag(name, serverConfig), "route", mcpRoute+"tools")
+	}
+
+	// 注册路由
+	handler := app.chainMiddleware(proxyServer.GetHandler(), middlewares...)
+	l.routes.Handle(mcpRoute, handler)
+
+	slog.Info("Registered route", "server", interfaces.ServerLogTag(name, serverConfig), "route", mcpRoute)
+	return nil
+}
+
+// registerFanOutServer 注册扇出工具的虚拟服务器路由，使用代理级中间件
+func (app *Application) registerFanOutServer(l *listener) error {
+	fanOutServer, err := server.NewFanOutServer(l.proxy, app.serverManager)
+	if err != nil {
+		return err
 	}
 
-	return httpServer, nil
+	middlewares, err := app.createMiddlewares(interfaces.FanOutServerName, &interfaces.ServerConfig{Options: l.proxy.Options})
+	if err != nil {
+		return err
+	}
+
+	route := l.serverRoute(interfaces.FanOutServerName)
+	l.routes.Handle(route, app.chainMiddleware(fanOutServer.GetHandler(), middlewares...))
+	slog.Info("Registered route", "server", interfaces.FanOutServerName, "route", route)
+	return nil
 }
 
-// createMiddlewares 创建中间件链
-func (app *Application) createMiddlewares(clientName string, config *interfaces.ServerConfig) []interfaces.Middleware {
+// registerAggregatedTools 注册合并代理下所有服务器工具的发现路由和 OpenAPI 文档路由，使用代理级中间件
+func (app *Application) registerAggregatedTools(l *listener) error {
+	middlewares, err := app.createMiddlewares("tools", &interfaces.ServerConfig{Options: l.proxy.Options})
+	if err != nil {
+		return err
+	}
+
+	route := strings.TrimSuffix(l.serverRoute("tools"), "/")
+	l.routes.Handle("GET "+route, app.chainMiddleware(server.AggregatedToolsHandler(app.serverManager, l.servedBy), middlewares...))
+	slog.Info("Registered aggregated tools discovery route", "route", route)
+
+	route = strings.TrimSuffix(l.serverRoute("openapi.json"), "/")
+	l.routes.Handle("GET "+route, app.chainMiddleware(server.OpenAPIHandler(l.proxy, app.serverManager, l.servedBy), middlewares...))
+	slog.Info("Registered OpenAPI route", "route", route)
+	return nil
+}
+
+// createAdminServer 创建管理 API 服务器
+func (app *Application) createAdminServer(config *interfaces.Config) *admin.Server {
+	if len(config.Proxy.AdminAuthTokens) == 0 {
+		slog.Warn("Admin API has no authentication configured")
+	}
+
+	// 管理操作始终记录日志，不受采样影响
+	adminServer := admin.New(
+		config.Proxy.AdminAddr,
+		version.New(app.buildVersion),
+		responseheaders.New(responseHeaders(&config.Proxy)),
+		recovery.New("admin", debugMode(config.Proxy.Options)),
+		logger.New("admin"),
+		auth.New(config.Proxy.AdminAuthTokens),
+		bodylimit.New("admin", 0),
+	)
+
+	// 所有管理路由注册在 adminBasePath 下
+	basePath := strings.TrimSuffix(config.Proxy.AdminBasePath, "/")
+
+	// 运行状态
+	adminServer.HandleFunc("GET "+basePath+"/status", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"version":      app.buildVersion,
+			"startTime":    app.startTime.Format(time.RFC3339),
+			"uptime":       time.Since(app.startTime).Round(time.Second).String(),
+			"goVersion":    runtime.Version(),
+			"configSource": app.configSource,
+		})
+	})
+
+	// 已配置的服务器按连接状态分组，并附带已注册服务器的统计信息
+	adminServer.HandleFunc("GET "+basePath+"/servers", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"connected":    sortedNames(app.clientManager.GetConnectedClients()),
+			"disconnected": sortedNames(app.clientManager.GetDisconnectedClients()),
+			"stats":        app.serverManager.GetServerStats(),
+		})
+	})
+
+	// 运行时添加上游服务器，请求体为带 name 字段的服务器配置
+	adminServer.HandleFunc("POST "+basePath+"/servers", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Name string `json:"name"`
+			interfaces.ServerConfig
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			admin.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server config: " + err.Error()})
+			return
+		}
+		route, err := app.addServer(request.Name, request.ServerConfig)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var addErr *addServerError
+			if errors.As(err, &addErr) {
+				status = addErr.status
+			}
+			admin.WriteJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		admin.WriteJSON(w, http.StatusCreated, map[string]string{"status": "added", "route": route})
+	})
+
+	// 断开并移除上游服务器及其路由
+	adminServer.HandleFunc("DELETE "+basePath+"/servers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := app.deleteServer(name); err != nil {
+			admin.WriteJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, map[string]string{"status": "removed"})
+	})
+
+	// 上游服务器信息与连接状态
+	adminServer.HandleFunc("GET "+basePath+"/servers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		proxyServer := app.serverManager.GetServer(name)
+		if proxyServer == nil || proxyServer.GetClient() == nil {
+			admin.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "server not found: " + name})
+			return
+		}
+		client := proxyServer.GetClient()
+		admin.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"name":       name,
+			"type":       client.GetType(),
+			"connected":  client.IsConnected(),
+			"state":      client.GetState(),
+			"serverInfo": client.GetServerInfo(),
+		})
+	})
+
+	// 重新获取上游的工具、提示词和资源
+	adminServer.HandleFunc("POST "+basePath+"/servers/{name}/refresh", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		proxyServer := app.serverManager.GetServer(name)
+		if proxyServer == nil {
+			admin.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "server not found: " + name})
+			return
+		}
+		if err := proxyServer.RefreshResources(r.Context()); err != nil {
+			admin.WriteJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, map[string]string{"status": "refreshed"})
+	})
+
+	// 客户端类型、连接状态和是否定期 ping
+	adminServer.HandleFunc("GET "+basePath+"/clients", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, app.clientManager.GetClientStats())
+	})
+
+	// 断开并按当前配置重新连接上游
+	adminServer.HandleFunc("POST "+basePath+"/clients/{name}/reconnect", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := app.reconnectServer(name); err != nil {
+			status := http.StatusBadGateway
+			if errors.Is(err, errServerNotFound) {
+				status = http.StatusNotFound
+			}
+			admin.WriteJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, map[string]string{"status": "reconnected"})
+	})
+
+	// 断开并移除上游，直到下次重新加载配置
+	adminServer.HandleFunc("DELETE "+basePath+"/clients/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := app.deleteServer(name); err != nil {
+			admin.WriteJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		admin.WriteJSON(w, http.StatusOK, map[string]string{"status": "removed"})
+	})
+
+	// 上游服务器当前注册的工具
+	adminServer.HandleFunc("GET "+basePath+"/tools/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		proxyServer := app.serverManager.GetServer(name)
+		if proxyServer == nil {
+			admin.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "server not found: " + name})
+			return
+		}
+		proxyServer.ToolsHandler().ServeHTTP(w, r)
+	})
+
+	// 当前 SSE 连接数与上限
+	adminServer.HandleFunc("GET "+basePath+"/connections", func(w http.ResponseWriter, r *http.Request) {
+		primary := app.primary().sseLimiter
+		connections := map[string]interface{}{
+			"active": primary.Active(),
+			"max":    primary.Max(),
+		}
+		// proxies 中的代理按名称分别列出
+		if len(app.listeners) > 1 {
+			proxies := make(map[string]map[string]int64, len(app.listeners)-1)
+			for _, l := range app.listeners[1:] {
+				proxies[l.proxy.Name] = map[string]int64{"active": l.sseLimiter.Active(), "max": l.sseLimiter.Max()}
+			}
+			connections["proxies"] = proxies
+		}
+		admin.WriteJSON(w, http.StatusOK, connections)
+	})
+
+	// 工具调用成本汇总
+	adminServer.HandleFunc("GET "+basePath+"/cost", func(w http.ResponseWriter, r *http.Request) {
+		admin.WriteJSON(w, http.StatusOK, app.costTracker.Summary())
+	})
+
+	// 未配置独立的 pprofAddr 时，pprof 注册在管理端口上，受管理 API 认证保护
+	if pprofEnabled(&config.Proxy) && config.Proxy.PprofAddr == "" {
+		registerPprof(adminServer.HandleFunc)
+		slog.Warn("pprof enabled on the admin listener, do not expose it publicly", "addr", config.Proxy.AdminAddr)
+	}
+
+	return adminServer
+}
+
+// sortedNames 返回按名称排序的客户端名称列表
+func sortedNames(clients map[string]interfaces.MCPClient) []string {
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// createMiddlewares 创建中间件链，配置了 middlewares 列表时按列表顺序构建
+//
+// 配置的列表只替代默认的外层中间件，请求体大小限制、认证和限流等安全相关的中间件始终追加在列表之后，
+// 避免配置列表（包括从代理级继承的列表）意外关闭认证、限流或请求体上限。
+func (app *Application) createMiddlewares(clientName string, config *interfaces.ServerConfig) ([]interfaces.Middleware, error) {
 	var middlewares []interfaces.Middleware
+	debug := debugMode(config.Options)
 
-	// 恢复中间件（最外层）
-	middlewares = append(middlewares, recovery.New(clientName))
+	if config.Options != nil && len(config.Options.Middlewares) > 0 {
+		for _, instance := range config.Options.Middlewares {
+			middleware, err := app.createMiddlewareInstance(clientName, instance, debug)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create middleware %s: %w", instance.ID, err)
+			}
+			middlewares = append(middlewares, middleware)
+		}
+		return app.appendSecurityMiddlewares(middlewares, clientName, config)
+	}
+
+	// 请求 ID 中间件（最外层），之后的中间件和处理器均可读取请求 ID
+	middlewares = append(middlewares, requestid.New())
 
-	// 日志中间件
-	if config.Options != nil && config.Options.LogEnabled != nil && *config.Options.LogEnabled {
+	// 恢复中间件
+	middlewares = append(middlewares, recovery.New(clientName, debug))
+
+	// Server-Timing 中间件，位于认证等步骤之外以便统计它们的耗时
+	if config.Options != nil && config.Options.ServerTimingEnabled != nil && *config.Options.ServerTimingEnabled {
+		middlewares = append(middlewares, servertiming.New())
+	}
+
+	// 日志中间件，调试模式下始终开启
+	if debug || (config.Options != nil && config.Options.LogEnabled != nil && *config.Options.LogEnabled) {
 		middlewares = append(middlewares, logger.New(clientName))
 	}
 
+	// CORS 中间件，位于认证之前，未认证的预检请求同样能得到 CORS 响应
+	if config.Options != nil && config.Options.CORS != nil {
+		middlewares = append(middlewares, cors.New(*config.Options.CORS))
+	}
+
+	// 请求体大小限制、认证、限流和 JWT 中间件，与配置 middlewares 列表时相同
+	middlewares, err := app.appendSecurityMiddlewares(middlewares, clientName, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 追踪上下文中间件
+	if config.TracingEnabled != nil && *config.TracingEnabled {
+		middlewares = append(middlewares, tracing.New())
+	}
+
+	return middlewares, nil
+}
+
+// appendSecurityMiddlewares 追加请求体大小限制、authTokens 和 jwt 对应的认证中间件以及限流中间件，无论是否配置了 middlewares 列表
+//
+// 请求体大小限制始终生效，未配置 maxBodyBytes 时使用默认上限；全局限流器由所有服务器共享，
+// 即使服务器没有配置 options.rateLimit 也会应用。
+func (app *Application) appendSecurityMiddlewares(middlewares []interfaces.Middleware, clientName string, config *interfaces.ServerConfig) ([]interfaces.Middleware, error) {
+	// 请求体大小限制中间件，未配置时使用默认上限
+	var maxBodyBytes int64
+	if config.Options != nil {
+		maxBodyBytes = config.Options.MaxBodyBytes
+	}
+	middlewares = append(middlewares, bodylimit.New(clientName, maxBodyBytes))
+
 	// 认证中间件
 	if config.Options != nil && len(config.Options.AuthTokens) > 0 {
 		middlewares = append(middlewares, auth.New(config.Options.AuthTokens))
 	}
 
-	return middlewares
+	// 限流中间件，同时受服务器限流和全局限流约束
+	var serverLimiter *rate.Limiter
+	if config.Options != nil && config.Options.RateLimit != nil {
+		serverLimiter = ratelimit.NewLimiter(config.Options.RateLimit.Rate, config.Options.RateLimit.Burst)
+	}
+	if serverLimiter != nil || app.globalLimiter != nil {
+		middlewares = append(middlewares, ratelimit.New(app.metrics.IncRateLimited, serverLimiter, app.globalLimiter))
+	}
+
+	// JWT 认证中间件，注入参数所需的声明同样存入上下文
+	if config.Options != nil && config.Options.JWT != nil {
+		jwtMiddleware, err := jwtauth.New(*config.Options.JWT, config.Options.InjectClaimsAsArgs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create jwt middleware: %w", err)
+		}
+		middlewares = append(middlewares, jwtMiddleware)
+	}
+
+	return middlewares, nil
+}
+
+// createMiddlewareInstance 通过中间件注册表创建实例，日志前缀为 "<服务器名>/<实例 ID>"
+func (app *Application) createMiddlewareInstance(clientName string, instance interfaces.MiddlewareInstanceConfig, debug bool) (interfaces.Middleware, error) {
+	factory, ok := registry.Lookup(instance.Type)
+	if !ok {
+		return nil, fmt.Errorf("unsupported middleware type: %s, registered: %s", instance.Type, strings.Join(registry.Names(), ", "))
+	}
+
+	// 注入代理提供的选项，不覆盖配置中的同名选项
+	options := make(map[string]interface{}, len(instance.Options)+2)
+	for key, value := range instance.Options {
+		options[key] = value
+	}
+	if _, ok := options[registry.OptionName]; !ok {
+		options[registry.OptionName] = clientName + "/" + instance.ID
+	}
+	if _, ok := options[registry.OptionDebug]; !ok {
+		options[registry.OptionDebug] = debug
+	}
+
+	middleware := factory(options)
+	if middleware == nil {
+		return nil, fmt.Errorf("invalid options for middleware type %s", instance.Type)
+	}
+	return middleware, nil
 }
 
 // chainMiddleware 链式组合多个中间件
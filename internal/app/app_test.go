@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// writeConfig 将配置写入临时文件并返回路径
+func writeConfig(t *testing.T, config *interfaces.Config) string {
+	t.Helper()
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPanicIfInvalidReportsFatalError(t *testing.T) {
+	panicIfInvalid := true
+	path := writeConfig(t, &interfaces.Config{
+		Proxy: interfaces.ProxyConfig{
+			Name:    "test-proxy",
+			Version: "1.0.0",
+			Type:    interfaces.TransportTypeSSE,
+			Addr:    "127.0.0.1:0",
+			BaseURL: "http://127.0.0.1",
+		},
+		Servers: map[string]interfaces.ServerConfig{
+			"broken": {
+				Transport: interfaces.ClientTypeStreamable,
+				URL:       "http://127.0.0.1:1/mcp",
+				Options:   &interfaces.OptionsConfig{PanicIfInvalid: &panicIfInvalid},
+			},
+		},
+	})
+
+	application, err := New("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := application.Start(path); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// 连接失败不再直接退出进程，而是交给调用方关闭应用
+	select {
+	case err := <-application.Fatal():
+		if !errors.Is(err, errFatalStartup) {
+			t.Errorf("fatal error = %v, want errFatalStartup", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no fatal error reported for a panicIfInvalid server that failed to start")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := application.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
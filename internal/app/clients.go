@@ -0,0 +1,124 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// 管理 API 操作服务器时的错误
+var (
+	errServerNotFound = errors.New("server not found")
+	errServerExists   = errors.New("server already exists")
+)
+
+// addServerError 运行时添加服务器失败的原因，用于管理 API 区分客户端错误和上游错误
+type addServerError struct {
+	status int
+	err    error
+}
+
+// Error 返回原始错误信息
+func (e *addServerError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *addServerError) Unwrap() error {
+	return e.err
+}
+
+// addClient 创建客户端并加入客户端管理器，同时记录停止超时和是否可选
+func (app *Application) addClient(name string, serverConfig interfaces.ServerConfig) (interfaces.MCPClient, error) {
+	mcpClient, err := app.clientFactory.CreateClient(name, serverConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	if err := app.clientManager.AddClient(mcpClient); err != nil {
+		return nil, fmt.Errorf("failed to add client: %w", err)
+	}
+	app.clientManager.SetStopTimeout(name, serverStopTimeout(serverConfig))
+	app.setOptional(name, serverConfig)
+	return mcpClient, nil
+}
+
+// reconnectServer 移除服务器后按当前配置重新创建客户端并连接，连接完成后返回
+func (app *Application) reconnectServer(name string) error {
+	app.reloadMutex.Lock()
+	defer app.reloadMutex.Unlock()
+
+	serverConfig, ok := app.runningConfig.Servers[name]
+	if !ok {
+		return errServerNotFound
+	}
+
+	app.removeServer(name)
+	mcpClient, err := app.addClient(name, serverConfig)
+	if err != nil {
+		return err
+	}
+	// 客户端的后台任务随运行上下文结束，不使用请求的上下文
+	return app.startClient(app.runCtx, app.runningConfig, name, serverConfig, mcpClient, app.clientInfo)
+}
+
+// addServer 在运行时添加服务器：连接上游并注册路由，连接失败时撤销已创建的客户端
+//
+// 只接受 SSE 和 Streamable HTTP 上游，避免通过管理 API 在代理所在主机上启动进程。
+// 添加的服务器不写入配置文件，重新加载配置时会被移除。成功时返回服务器的路由前缀。
+func (app *Application) addServer(name string, serverConfig interfaces.ServerConfig) (string, error) {
+	app.reloadMutex.Lock()
+	defer app.reloadMutex.Unlock()
+
+	if _, ok := app.runningConfig.Servers[name]; ok || app.clientManager.GetClient(name) != nil {
+		return "", &addServerError{status: http.StatusConflict, err: errServerExists}
+	}
+	serverConfig, err := app.configProvider.PrepareServer(app.runningConfig, name, serverConfig)
+	if err != nil {
+		return "", &addServerError{status: http.StatusBadRequest, err: err}
+	}
+	if serverConfig.Transport != interfaces.ClientTypeSSE && serverConfig.Transport != interfaces.ClientTypeStreamable {
+		return "", &addServerError{status: http.StatusBadRequest, err: fmt.Errorf("unsupported transport for runtime registration: %s", serverConfig.Transport)}
+	}
+
+	l := app.listenerFor(serverConfig)
+	if l == nil {
+		return "", &addServerError{status: http.StatusBadRequest, err: fmt.Errorf("proxy %s is not running, restart to apply", serverConfig.ServedBy)}
+	}
+
+	mcpClient, err := app.addClient(name, serverConfig)
+	if err != nil {
+		return "", &addServerError{status: http.StatusBadRequest, err: err}
+	}
+	if err := app.startClient(app.runCtx, app.runningConfig, name, serverConfig, mcpClient, app.clientInfo); err != nil {
+		app.removeServer(name)
+		return "", &addServerError{status: http.StatusBadGateway, err: err}
+	}
+
+	updated := *app.runningConfig
+	updated.Servers = maps.Clone(app.runningConfig.Servers)
+	updated.Servers[name] = serverConfig
+	app.runningConfig = &updated
+	return l.serverRoute(name), nil
+}
+
+// deleteServer 断开并移除服务器，配置文件中仍存在该服务器时下次重新加载配置会重新添加
+func (app *Application) deleteServer(name string) error {
+	app.reloadMutex.Lock()
+	defer app.reloadMutex.Unlock()
+
+	if _, ok := app.runningConfig.Servers[name]; !ok {
+		return errServerNotFound
+	}
+
+	app.removeServer(name)
+
+	// 复制配置，避免修改其他地方持有的服务器配置
+	updated := *app.runningConfig
+	updated.Servers = maps.Clone(app.runningConfig.Servers)
+	delete(updated.Servers, name)
+	app.runningConfig = &updated
+	return nil
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/connlimit"
+)
+
+// listener 一个代理监听地址，拥有独立的路由表和 HTTP 服务器
+//
+// 主代理 proxy 的 servedBy 为空，proxies 中的代理以名称作为 servedBy，只提供 servedBy 与之相同的服务器。
+type listener struct {
+	proxy      *interfaces.ProxyConfig
+	servedBy   string
+	routes     *routeTable
+	basePath   string
+	httpServer *http.Server
+	addr       string
+	sseLimiter *connlimit.Middleware
+}
+
+// serverRoute 返回服务器的路由前缀，形如 /basePath/name/
+func (l *listener) serverRoute(name string) string {
+	route := path.Join(l.basePath, name)
+	if !strings.HasPrefix(route, "/") {
+		route = "/" + route
+	}
+	if !strings.HasSuffix(route, "/") {
+		route += "/"
+	}
+	return route
+}
+
+// listen 监听代理地址并在后台提供 HTTP 服务，监听失败时返回错误，之后的服务错误交给 fail
+func (l *listener) listen(fail func(error)) error {
+	netListener, err := net.Listen("tcp", l.proxy.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", l.proxy.Addr, err)
+	}
+	l.addr = netListener.Addr().String()
+
+	go func() {
+		var err error
+		if l.httpServer.TLSConfig != nil {
+			// 证书已加载到 TLSConfig 中
+			slog.Info("Starting HTTPS server", "proxy", l.proxy.Name, "addr", l.addr)
+			err = l.httpServer.ServeTLS(netListener, "", "")
+		} else {
+			slog.Info("Starting HTTP server", "proxy", l.proxy.Name, "addr", l.addr)
+			err = l.httpServer.Serve(netListener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "proxy", l.proxy.Name, "error", err)
+			fail(fmt.Errorf("http server %s failed: %w", l.proxy.Name, err))
+		}
+	}()
+	return nil
+}
+
+// primary 返回主代理的监听器
+func (app *Application) primary() *listener {
+	return app.listeners[0]
+}
+
+// listenerFor 返回提供该服务器的监听器，servedBy 对应的代理未启动时返回 nil
+func (app *Application) listenerFor(serverConfig interfaces.ServerConfig) *listener {
+	for _, l := range app.listeners {
+		if l.servedBy == serverConfig.ServedBy {
+			return l
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// listenAddr 需要监听的地址及其用途
+type listenAddr struct {
+	name string
+	addr string
+}
+
+// checkPortConflicts 启动前检查监听地址是否重复或已被其他进程占用
+func checkPortConflicts(config *interfaces.Config) error {
+	addrs := []listenAddr{{name: "proxy", addr: config.Proxy.Addr}}
+	for _, proxy := range config.Proxies {
+		addrs = append(addrs, listenAddr{name: "proxy " + proxy.Name, addr: proxy.Addr})
+	}
+	if config.Proxy.AdminAddr != "" {
+		addrs = append(addrs, listenAddr{name: "admin", addr: config.Proxy.AdminAddr})
+	}
+	if pprofEnabled(&config.Proxy) && config.Proxy.PprofAddr != "" {
+		addrs = append(addrs, listenAddr{name: "pprof", addr: config.Proxy.PprofAddr})
+	}
+
+	// 检查配置内部的端口重复
+	for i := range addrs {
+		hostI, portI, err := net.SplitHostPort(addrs[i].addr)
+		if err != nil {
+			return fmt.Errorf("invalid %s address %s: %w", addrs[i].name, addrs[i].addr, err)
+		}
+		for j := i + 1; j < len(addrs); j++ {
+			hostJ, portJ, err := net.SplitHostPort(addrs[j].addr)
+			if err != nil {
+				return fmt.Errorf("invalid %s address %s: %w", addrs[j].name, addrs[j].addr, err)
+			}
+			// 端口 0 由系统随机分配，不会冲突；通配地址监听所有地址，与任意主机冲突
+			if portI == "0" || portI != portJ {
+				continue
+			}
+			if wildcardHost(hostI) || wildcardHost(hostJ) || hostI == hostJ {
+				return fmt.Errorf("port conflict: %s address %s and %s address %s use the same port", addrs[i].name, addrs[i].addr, addrs[j].name, addrs[j].addr)
+			}
+		}
+	}
+
+	// 检查端口是否已被其他进程占用
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr.addr)
+		if err != nil {
+			return fmt.Errorf("%s address %s is not available: %w", addr.name, addr.addr, err)
+		}
+		listener.Close()
+	}
+
+	return nil
+}
+
+// wildcardHost 主机名为空、0.0.0.0 或 :: 时监听所有地址
+func wildcardHost(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+func TestCheckPortConflicts(t *testing.T) {
+	// 获取一个空闲端口，无冲突的用例需要实际监听
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	tests := []struct {
+		name      string
+		proxyAddr string
+		adminAddr string
+		conflict  bool
+	}{
+		{name: "empty host", proxyAddr: ":" + port, adminAddr: "127.0.0.1:" + port, conflict: true},
+		{name: "ipv4 wildcard", proxyAddr: "0.0.0.0:" + port, adminAddr: "127.0.0.1:" + port, conflict: true},
+		{name: "ipv6 wildcard", proxyAddr: "[::]:" + port, adminAddr: "127.0.0.1:" + port, conflict: true},
+		{name: "wildcard on both sides", proxyAddr: "0.0.0.0:" + port, adminAddr: "[::]:" + port, conflict: true},
+		{name: "same host", proxyAddr: "127.0.0.1:" + port, adminAddr: "127.0.0.1:" + port, conflict: true},
+		{name: "different hosts", proxyAddr: "127.0.0.1:" + port, adminAddr: "127.0.0.2:" + port},
+		{name: "random ports", proxyAddr: "0.0.0.0:0", adminAddr: "127.0.0.1:0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPortConflicts(&interfaces.Config{
+				Proxy: interfaces.ProxyConfig{Addr: tt.proxyAddr, AdminAddr: tt.adminAddr},
+			})
+			gotConflict := err != nil && strings.Contains(err.Error(), "port conflict")
+			if gotConflict != tt.conflict {
+				t.Errorf("checkPortConflicts(%s, %s) error = %v, want conflict %v", tt.proxyAddr, tt.adminAddr, err, tt.conflict)
+			}
+		})
+	}
+}
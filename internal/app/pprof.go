@@ -0,0 +1,40 @@
+package app
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/ceyewan/mcp-proxy/internal/admin"
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/auth"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/logger"
+)
+
+// registerPprof 在 /debug/pprof/ 下注册标准的 pprof 处理函数
+//
+// pprof.Index 依据 /debug/pprof/ 前缀解析 profile 名称，因此路径不受 adminBasePath 影响。
+func registerPprof(handleFunc func(pattern string, handler func(http.ResponseWriter, *http.Request))) {
+	handleFunc("/debug/pprof/", pprof.Index)
+	handleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	handleFunc("/debug/pprof/profile", pprof.Profile)
+	handleFunc("/debug/pprof/symbol", pprof.Symbol)
+	handleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// pprofEnabled 判断是否在管理端口或独立端口上提供 pprof，代理级调试模式同样开启 pprof
+func pprofEnabled(config *interfaces.ProxyConfig) bool {
+	return (config.EnablePprof != nil && *config.EnablePprof) || debugMode(config.Options)
+}
+
+// createPprofServer 创建独立监听 pprofAddr 的 pprof 服务器，与管理 API 使用相同的认证令牌
+func (app *Application) createPprofServer(config *interfaces.Config) *admin.Server {
+	pprofServer := admin.New(
+		config.Proxy.PprofAddr,
+		logger.New("pprof"),
+		auth.New(config.Proxy.AdminAuthTokens),
+	)
+	registerPprof(pprofServer.HandleFunc)
+	slog.Warn("pprof enabled on a dedicated listener, do not expose it publicly", "addr", config.Proxy.PprofAddr)
+	return pprofServer
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// defaultReadyzPingTimeout 就绪探针 ping 上游的默认超时时间
+const defaultReadyzPingTimeout = time.Second
+
+// upstreamPing 就绪探针对单个上游的 ping 结果
+type upstreamPing struct {
+	Healthy   bool    `json:"healthy"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// readinessReport 就绪探针的响应
+type readinessReport struct {
+	Ready     int                     `json:"ready"`
+	Total     int                     `json:"total"`
+	Unhealthy []string                `json:"unhealthy"`
+	Servers   map[string]upstreamPing `json:"servers,omitempty"`
+}
+
+// readiness 检查每个服务器是否已连接并注册路由，pings 不为 nil 时还要求 ping 成功；
+// 任一必需（未设置 optional）的服务器不健康时返回 503
+func (app *Application) readiness(pings map[string]upstreamPing) (int, readinessReport) {
+	clients := app.clientManager.GetClients()
+	report := readinessReport{Total: len(clients), Unhealthy: []string{}, Servers: pings}
+	status := http.StatusOK
+	for _, name := range sortedNames(clients) {
+		healthy := clients[name].IsConnected() && app.serverManager.GetServer(name) != nil
+		if pings != nil {
+			healthy = healthy && pings[name].Healthy
+		}
+		if healthy {
+			report.Ready++
+			continue
+		}
+
+		report.Unhealthy = append(report.Unhealthy, name)
+		if _, optional := app.optionalServers.Load(name); !optional {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	return status, report
+}
+
+// setOptional 记录服务器是否为可选服务器，可选服务器不健康时不影响就绪状态
+func (app *Application) setOptional(name string, serverConfig interfaces.ServerConfig) {
+	if serverConfig.Optional != nil && *serverConfig.Optional {
+		app.optionalServers.Store(name, struct{}{})
+		return
+	}
+	app.optionalServers.Delete(name)
+}
+
+// pingUpstreams 并发 ping 所有上游，未连接或超时的上游视为不健康
+func (app *Application) pingUpstreams(ctx context.Context, timeout time.Duration) map[string]upstreamPing {
+	clients := app.clientManager.GetClients()
+	results := make(map[string]upstreamPing, len(clients))
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for name, mcpClient := range clients {
+		wg.Add(1)
+		go func(name string, mcpClient interfaces.MCPClient) {
+			defer wg.Done()
+
+			result := pingUpstream(ctx, mcpClient, timeout)
+			mutex.Lock()
+			results[name] = result
+			mutex.Unlock()
+		}(name, mcpClient)
+	}
+	wg.Wait()
+	return results
+}
+
+// pingUpstream 在超时时间内 ping 单个上游并记录延迟
+func pingUpstream(ctx context.Context, mcpClient interfaces.MCPClient, timeout time.Duration) upstreamPing {
+	if !mcpClient.IsConnected() {
+		return upstreamPing{Error: "not connected"}
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := mcpClient.Ping(pingCtx)
+	result := upstreamPing{LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Healthy = true
+	return result
+}
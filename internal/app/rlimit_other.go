@@ -0,0 +1,12 @@
+//go:build !unix
+
+package app
+
+import "log/slog"
+
+// applyFDLimit 非 Unix 平台不支持调整文件描述符限制
+func applyFDLimit(maxOpenFDs int) {
+	if maxOpenFDs > 0 {
+		slog.Warn("maxOpenFDs is not supported on this platform, ignoring")
+	}
+}
@@ -0,0 +1,40 @@
+//go:build unix
+
+package app
+
+import (
+	"log/slog"
+	"syscall"
+)
+
+// applyFDLimit 记录当前文件描述符限制，并按 maxOpenFDs 提升软限制
+//
+// 软限制最多提升到硬限制，超过硬限制的请求只记录警告。
+func applyFDLimit(maxOpenFDs int) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		slog.Warn("Failed to get file descriptor limit", "error", err)
+		return
+	}
+	slog.Info("File descriptor limit", "soft", limit.Cur, "hard", limit.Max)
+
+	if maxOpenFDs <= 0 || uint64(maxOpenFDs) <= uint64(limit.Cur) {
+		return
+	}
+
+	target := uint64(maxOpenFDs)
+	if target > uint64(limit.Max) {
+		slog.Warn("maxOpenFDs exceeds the hard limit, raising to the hard limit instead", "max_open_fds", maxOpenFDs, "hard", limit.Max)
+		target = uint64(limit.Max)
+	}
+	if target <= uint64(limit.Cur) {
+		return
+	}
+
+	limit.Cur = target
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		slog.Warn("Failed to raise file descriptor limit", "target", target, "error", err)
+		return
+	}
+	slog.Info("Raised file descriptor soft limit", "soft", target)
+}
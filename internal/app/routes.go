@@ -0,0 +1,78 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// route 路由注册信息
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// routeTable 可在服务运行期间动态添加和移除路由的 HTTP 处理器
+//
+// http.ServeMux 不支持并发注册与服务，每次变更路由都会重建一个新的 ServeMux 并原子替换。
+type routeTable struct {
+	routes []route
+	mux    atomic.Pointer[http.ServeMux]
+	mutex  sync.Mutex
+}
+
+// newRouteTable 创建空的路由表
+func newRouteTable() *routeTable {
+	table := &routeTable{}
+	table.mux.Store(http.NewServeMux())
+	return table
+}
+
+// Handle 注册路由
+func (t *routeTable) Handle(pattern string, handler http.Handler) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.routes = append(t.routes, route{pattern: pattern, handler: handler})
+	t.rebuild()
+}
+
+// RemovePrefix 移除路径以 prefix 开头的所有路由
+func (t *routeTable) RemovePrefix(prefix string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	routes := t.routes[:0]
+	for _, r := range t.routes {
+		// 去掉 "GET " 等方法前缀后比较路径
+		pattern := r.pattern
+		if _, path, ok := strings.Cut(pattern, " "); ok {
+			pattern = path
+		}
+		if !strings.HasPrefix(pattern, prefix) {
+			routes = append(routes, r)
+		}
+	}
+	t.routes = routes
+	t.rebuild()
+}
+
+// rebuild 根据当前路由重建 ServeMux 并原子替换，调用方需持有锁
+func (t *routeTable) rebuild() {
+	mux := http.NewServeMux()
+	for _, r := range t.routes {
+		mux.Handle(r.pattern, r.handler)
+	}
+	t.mux.Store(mux)
+}
+
+// HandleFunc 注册路由处理函数
+func (t *routeTable) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	t.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// ServeHTTP 使用当前的 ServeMux 处理请求
+func (t *routeTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.mux.Load().ServeHTTP(w, r)
+}
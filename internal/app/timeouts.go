@@ -0,0 +1,46 @@
+package app
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/config"
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// applyServerTimeouts 设置 HTTP 服务器超时，配置加载时已设置默认值并校验
+func applyServerTimeouts(httpServer *http.Server, timeouts *interfaces.ServerTimeouts) {
+	if timeouts == nil {
+		timeouts = &interfaces.ServerTimeouts{}
+	}
+	httpServer.ReadTimeout, _ = config.GetDuration(timeouts.ReadTimeout)
+	httpServer.WriteTimeout, _ = config.GetDuration(timeouts.WriteTimeout)
+	httpServer.IdleTimeout, _ = config.GetDuration(timeouts.IdleTimeout)
+	httpServer.ReadHeaderTimeout, _ = config.GetDuration(timeouts.ReadHeaderTimeout)
+
+	if httpServer.ReadTimeout == 0 && httpServer.WriteTimeout == 0 && httpServer.IdleTimeout == 0 && httpServer.ReadHeaderTimeout == 0 {
+		slog.Warn("HTTP server timeouts are all disabled, slow clients can hold connections open indefinitely")
+	}
+}
+
+// clearStreamDeadlines 为事件流请求清除连接的读写截止时间
+//
+// http.Server 的 ReadTimeout 和 WriteTimeout 作用于整个请求，
+// 会中断 SSE 长连接和以事件流返回的 Streamable HTTP 工具调用，
+// 这类连接的存活时间由 sseMaxConnectionAge 控制。
+func clearStreamDeadlines(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") || strings.HasSuffix(r.URL.Path, "/sse") {
+			controller := http.NewResponseController(w)
+			if err := controller.SetReadDeadline(time.Time{}); err != nil {
+				slog.DebugContext(r.Context(), "Failed to clear read deadline", "error", err)
+			}
+			if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+				slog.DebugContext(r.Context(), "Failed to clear write deadline", "error", err)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/config"
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// configDirPollInterval 检查配置目录变化的间隔
+const configDirPollInterval = 2 * time.Second
+
+// fileStamp 文件的修改时间和大小，用于判断文件是否变化
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchConfigDir 配置路径为目录时在后台轮询目录变化
+//
+// 新增、修改和删除服务器配置文件会增量添加、重建和移除对应的服务器；
+// proxy 部分的变化需要重启才能生效。
+func (app *Application) watchConfigDir(ctx context.Context, dir string) {
+	if !config.IsDir(dir) {
+		return
+	}
+
+	stamps := snapshotConfigDir(dir)
+	slog.Info("Watching config directory for changes", "dir", dir)
+
+	go func() {
+		ticker := time.NewTicker(configDirPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			next := snapshotConfigDir(dir)
+			if reflect.DeepEqual(stamps, next) {
+				continue
+			}
+			stamps = next
+
+			updated, err := app.loadConfig(dir)
+			if err != nil {
+				slog.Warn("Ignoring config directory change", "error", err)
+				continue
+			}
+			app.applyConfig(updated)
+		}
+	}()
+}
+
+// snapshotConfigDir 记录配置目录中每个文件的状态
+func snapshotConfigDir(dir string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	files, err := config.DirFiles(dir)
+	if err != nil {
+		slog.Warn("Failed to read config directory", "dir", dir, "error", err)
+		return stamps
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps
+}
+
+// loadConfig 加载并验证配置
+func (app *Application) loadConfig(path string) (*interfaces.Config, error) {
+	loaded, err := app.configProvider.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := app.configProvider.Validate(loaded); err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+// Reload 重新加载配置来源并增量应用服务器变化，加载或校验失败时保留当前状态
+func (app *Application) Reload() error {
+	updated, err := app.loadConfig(app.configSource)
+	if err != nil {
+		return err
+	}
+	app.applyConfig(updated)
+	slog.Info("Config reloaded", "source", app.configSource, "servers", len(updated.Servers))
+	return nil
+}
+
+// applyConfig 将新配置应用到运行中的服务器并记录为当前配置
+func (app *Application) applyConfig(updated *interfaces.Config) {
+	app.reloadMutex.Lock()
+	defer app.reloadMutex.Unlock()
+
+	app.applyServerChanges(app.runCtx, app.runningConfig, updated, app.clientInfo)
+	app.runningConfig = updated
+}
+
+// applyServerChanges 对比新旧配置，移除已删除或已修改的服务器并启动新增或已修改的服务器
+//
+// 只有工具过滤配置变化的服务器直接更新过滤规则，不重新连接上游。
+func (app *Application) applyServerChanges(ctx context.Context, current, updated *interfaces.Config, clientInfo mcp.Implementation) {
+	if !reflect.DeepEqual(current.Proxy, updated.Proxy) || !reflect.DeepEqual(current.Proxies, updated.Proxies) {
+		slog.Warn("Proxy config changed, restart to apply")
+	}
+
+	unchanged := make(map[string]struct{})
+	for name, serverConfig := range current.Servers {
+		newConfig, ok := updated.Servers[name]
+		if ok && (reflect.DeepEqual(serverConfig, newConfig) || app.updateToolFilter(ctx, name, serverConfig, newConfig)) {
+			unchanged[name] = struct{}{}
+			continue
+		}
+		app.removeServer(name)
+	}
+
+	for name, serverConfig := range updated.Servers {
+		if _, ok := unchanged[name]; ok {
+			continue
+		}
+
+		mcpClient, err := app.addClient(name, serverConfig)
+		if err != nil {
+			slog.Error("Failed to add client", "server", interfaces.ServerLogTag(name, serverConfig), "error", err)
+			continue
+		}
+
+		go func() {
+			err := app.startClient(ctx, updated, name, serverConfig, mcpClient, clientInfo)
+			if errors.Is(err, errFatalStartup) {
+				slog.Error("Failed to start server", "server", interfaces.ServerLogTag(name, serverConfig), "error", err)
+				app.fail(fmt.Errorf("failed to start server %s: %w", name, err))
+				return
+			}
+			if err != nil {
+				slog.Error("Failed to start server, skipping", "server", interfaces.ServerLogTag(name, serverConfig), "error", err)
+			}
+		}()
+	}
+}
+
+// updateToolFilter 两份服务器配置只有工具过滤不同时直接更新已注册服务器的过滤规则，返回是否已更新
+func (app *Application) updateToolFilter(ctx context.Context, name string, oldConfig, newConfig interfaces.ServerConfig) bool {
+	if !reflect.DeepEqual(withoutToolFilter(oldConfig), withoutToolFilter(newConfig)) {
+		return false
+	}
+	proxyServer := app.serverManager.GetServer(name)
+	if proxyServer == nil || proxyServer.GetClient() == nil {
+		return false
+	}
+
+	var filter *interfaces.ToolFilterConfig
+	if newConfig.Options != nil {
+		filter = newConfig.Options.ToolFilter
+	}
+	if err := proxyServer.UpdateToolFilter(ctx, filter); err != nil {
+		slog.Warn("Failed to refresh tools after tool filter change", "server", interfaces.ServerLogTag(name, newConfig), "error", err)
+	}
+	return true
+}
+
+// withoutToolFilter 返回去掉工具过滤配置的服务器配置副本
+func withoutToolFilter(serverConfig interfaces.ServerConfig) interfaces.ServerConfig {
+	if serverConfig.Options == nil {
+		return serverConfig
+	}
+	options := *serverConfig.Options
+	options.ToolFilter = nil
+	serverConfig.Options = &options
+	return serverConfig
+}
+
+// removeServer 移除服务器的路由、代理服务器和客户端
+func (app *Application) removeServer(name string) {
+	for _, l := range app.listeners {
+		l.routes.RemovePrefix(l.serverRoute(name))
+	}
+	if app.serverManager.GetServer(name) != nil {
+		if err := app.serverManager.RemoveServer(name); err != nil {
+			slog.Error("Failed to remove server", "server", name, "error", err)
+		}
+	}
+	if err := app.clientManager.RemoveClient(name); err != nil {
+		slog.Error("Failed to remove client", "server", name, "error", err)
+	}
+}
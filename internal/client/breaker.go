@@ -0,0 +1,253 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 熔断器状态
+const (
+	BreakerStateClosed   = "closed"
+	BreakerStateOpen     = "open"
+	BreakerStateHalfOpen = "half-open"
+)
+
+// 熔断器默认配置
+const (
+	DefaultBreakerThreshold    = 5
+	DefaultBreakerOpenDuration = 30 * time.Second
+)
+
+// ErrCircuitOpen 熔断器打开时直接返回的错误，调用不会转发给上游
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// probeRetryAfter 半开状态下探测名额已用完时建议的重试等待时长
+const probeRetryAfter = time.Second
+
+// CircuitOpenError 熔断器拒绝调用时返回的错误，errors.Is(err, ErrCircuitOpen) 成立
+type CircuitOpenError struct {
+	Server     string
+	retryAfter time.Duration
+}
+
+// Error 实现 error 接口
+func (e *CircuitOpenError) Error() string {
+	seconds := math.Ceil(e.retryAfter.Seconds())
+	return fmt.Sprintf("%s for server %s, retry in %s", ErrCircuitOpen, e.Server, time.Duration(seconds)*time.Second)
+}
+
+// Unwrap 返回 ErrCircuitOpen
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// RetryAfter 建议调用方等待多久后重试
+func (e *CircuitOpenError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// BreakerRecorder 记录熔断器状态变化的指标接口
+type BreakerRecorder interface {
+	SetCircuitBreakerState(server, state string)
+}
+
+// BreakerStateReporter 由启用了熔断器的客户端实现，供管理 API 查询熔断器状态
+type BreakerStateReporter interface {
+	CircuitBreakerState() string
+}
+
+// circuitBreaker 按连续失败次数熔断的状态机
+//
+// 关闭状态下连续失败达到 threshold 次后打开；打开 openDuration 后进入半开状态，
+// 半开状态下最多放行 halfOpenProbes 个探测调用（相邻探测至少间隔 halfOpenInterval），
+// 全部成功后关闭，任一失败则重新打开并重新计时。
+type circuitBreaker struct {
+	name             string
+	threshold        int
+	openDuration     time.Duration
+	halfOpenProbes   int
+	halfOpenInterval time.Duration
+	onChange         func(state string)
+
+	mutex           sync.Mutex
+	state           string
+	failures        int
+	openedAt        time.Time
+	probesStarted   int
+	probesSucceeded int
+	lastProbe       time.Time
+}
+
+// newCircuitBreaker 按配置创建熔断器，配置已在加载时设置默认值并校验
+func newCircuitBreaker(name string, config *interfaces.CircuitBreakerConfig, onChange func(state string)) *circuitBreaker {
+	b := &circuitBreaker{
+		name:           name,
+		threshold:      config.Threshold,
+		openDuration:   DefaultBreakerOpenDuration,
+		halfOpenProbes: max(config.HalfOpenProbes, 1),
+		onChange:       onChange,
+		state:          BreakerStateClosed,
+	}
+	if b.threshold <= 0 {
+		b.threshold = DefaultBreakerThreshold
+	}
+	if duration, _ := time.ParseDuration(config.OpenDuration); duration > 0 {
+		b.openDuration = duration
+	}
+	b.halfOpenInterval, _ = time.ParseDuration(config.HalfOpenInterval)
+	return b
+}
+
+// State 获取熔断器当前状态
+func (b *circuitBreaker) State() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state
+}
+
+// allow 判断调用能否转发给上游，probe 表示该调用是半开状态下的探测
+func (b *circuitBreaker) allow(now time.Time) (probe bool, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerStateClosed:
+		return false, nil
+	case BreakerStateOpen:
+		if wait := b.openDuration - now.Sub(b.openedAt); wait > 0 {
+			return false, &CircuitOpenError{Server: b.name, retryAfter: wait}
+		}
+		b.probesStarted, b.probesSucceeded = 0, 0
+		b.lastProbe = time.Time{}
+		b.setState(BreakerStateHalfOpen)
+	}
+
+	if b.probesStarted >= b.halfOpenProbes {
+		return false, &CircuitOpenError{Server: b.name, retryAfter: probeRetryAfter}
+	}
+	if wait := b.halfOpenInterval - now.Sub(b.lastProbe); wait > 0 {
+		return false, &CircuitOpenError{Server: b.name, retryAfter: wait}
+	}
+	b.probesStarted++
+	b.lastProbe = now
+	return true, nil
+}
+
+// record 记录一次已放行调用的结果，调用方取消的调用不计入
+func (b *circuitBreaker) record(probe bool, err error, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if errors.Is(err, context.Canceled) {
+		if probe && b.state == BreakerStateHalfOpen {
+			b.probesStarted--
+		}
+		return
+	}
+
+	switch {
+	case probe && b.state == BreakerStateHalfOpen:
+		if err != nil {
+			b.open(now)
+			return
+		}
+		b.probesSucceeded++
+		if b.probesSucceeded >= b.halfOpenProbes {
+			b.failures = 0
+			b.setState(BreakerStateClosed)
+		}
+	case !probe && b.state == BreakerStateClosed:
+		if err == nil {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open(now)
+		}
+	}
+}
+
+// open 打开熔断器并重新计时
+func (b *circuitBreaker) open(now time.Time) {
+	b.openedAt = now
+	b.setState(BreakerStateOpen)
+}
+
+// setState 切换状态并通知回调，调用时需持有锁
+func (b *circuitBreaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	slog.Warn("Circuit breaker state changed", "server", b.name, "from", b.state, "to", state)
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(state)
+	}
+}
+
+// breakerClient 为工具调用和工具列表请求加上熔断器的客户端
+type breakerClient struct {
+	interfaces.MCPClient
+	breaker *circuitBreaker
+}
+
+// newBreakerClient 用熔断器包装客户端
+func newBreakerClient(mcpClient interfaces.MCPClient, config *interfaces.CircuitBreakerConfig, opts ...Option) interfaces.MCPClient {
+	o := newOptions(opts)
+	name := mcpClient.GetName()
+	var onChange func(string)
+	if o.breakerRecorder != nil {
+		o.breakerRecorder.SetCircuitBreakerState(name, BreakerStateClosed)
+		onChange = func(state string) {
+			o.breakerRecorder.SetCircuitBreakerState(name, state)
+		}
+	}
+	return &breakerClient{
+		MCPClient: mcpClient,
+		breaker:   newCircuitBreaker(name, config, onChange),
+	}
+}
+
+// unwrapBreaker 返回熔断器包装的客户端，未包装时原样返回
+func unwrapBreaker(mcpClient interfaces.MCPClient) interfaces.MCPClient {
+	if breaker, ok := mcpClient.(*breakerClient); ok {
+		return breaker.MCPClient
+	}
+	return mcpClient
+}
+
+// CircuitBreakerState 获取熔断器当前状态
+func (c *breakerClient) CircuitBreakerState() string {
+	return c.breaker.State()
+}
+
+// CallTool 熔断器打开时直接返回错误，否则转发给上游并记录结果
+func (c *breakerClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	probe, err := c.breaker.allow(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.MCPClient.CallTool(ctx, request)
+	c.breaker.record(probe, err, time.Now())
+	return result, err
+}
+
+// ListTools 熔断器打开时直接返回错误，否则转发给上游并记录结果
+func (c *breakerClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	probe, err := c.breaker.allow(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.MCPClient.ListTools(ctx, request)
+	c.breaker.record(probe, err, time.Now())
+	return result, err
+}
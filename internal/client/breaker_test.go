@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+var errUpstream = errors.New("upstream failed")
+
+// tripBreaker 连续记录失败直到熔断器打开
+func tripBreaker(t *testing.T, b *circuitBreaker, now time.Time) {
+	t.Helper()
+	for i := 0; i < b.threshold; i++ {
+		probe, err := b.allow(now)
+		if err != nil {
+			t.Fatalf("allow() before threshold error = %v", err)
+		}
+		b.record(probe, errUpstream, now)
+	}
+	if state := b.State(); state != BreakerStateOpen {
+		t.Fatalf("state after %d failures = %s, want open", b.threshold, state)
+	}
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker("test", &interfaces.CircuitBreakerConfig{Threshold: 3, OpenDuration: "10s"}, nil)
+	now := time.Now()
+
+	// 成功调用重置连续失败计数
+	b.record(false, errUpstream, now)
+	b.record(false, errUpstream, now)
+	b.record(false, nil, now)
+	if state := b.State(); state != BreakerStateClosed {
+		t.Fatalf("state = %s, want closed", state)
+	}
+
+	tripBreaker(t, b, now)
+	_, err := b.allow(now.Add(4 * time.Second))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() while open error = %v, want ErrCircuitOpen", err)
+	}
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("allow() while open error = %T, want *CircuitOpenError", err)
+	}
+	if got := openErr.RetryAfter(); got != 6*time.Second {
+		t.Errorf("RetryAfter() = %s, want 6s until half-open", got)
+	}
+}
+
+func TestCircuitBreakerHalfOpenRecovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		probes    int
+		results   []error
+		wantState string
+	}{
+		{name: "single probe succeeds", probes: 1, results: []error{nil}, wantState: BreakerStateClosed},
+		{name: "single probe fails", probes: 1, results: []error{errUpstream}, wantState: BreakerStateOpen},
+		{name: "all probes succeed", probes: 3, results: []error{nil, nil, nil}, wantState: BreakerStateClosed},
+		{name: "partial recovery stays half-open", probes: 3, results: []error{nil, nil}, wantState: BreakerStateHalfOpen},
+		{name: "last probe fails", probes: 3, results: []error{nil, nil, errUpstream}, wantState: BreakerStateOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker("test", &interfaces.CircuitBreakerConfig{Threshold: 1, OpenDuration: "10s", HalfOpenProbes: tt.probes}, nil)
+			now := time.Now()
+			tripBreaker(t, b, now)
+
+			now = now.Add(10 * time.Second)
+			for i, result := range tt.results {
+				probe, err := b.allow(now)
+				if err != nil {
+					t.Fatalf("probe %d: allow() error = %v", i, err)
+				}
+				if !probe {
+					t.Fatalf("probe %d: allow() is not a probe", i)
+				}
+				b.record(probe, result, now)
+			}
+			if state := b.State(); state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenLimitsProbes(t *testing.T) {
+	b := newCircuitBreaker("test", &interfaces.CircuitBreakerConfig{Threshold: 1, OpenDuration: "10s", HalfOpenProbes: 2}, nil)
+	now := time.Now()
+	tripBreaker(t, b, now)
+
+	now = now.Add(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		if _, err := b.allow(now); err != nil {
+			t.Fatalf("probe %d: allow() error = %v", i, err)
+		}
+	}
+	// 探测调用尚未返回时不再放行更多调用
+	if _, err := b.allow(now); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow() beyond halfOpenProbes error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreakerFailedProbeRestartsTimer(t *testing.T) {
+	b := newCircuitBreaker("test", &interfaces.CircuitBreakerConfig{Threshold: 1, OpenDuration: "10s"}, nil)
+	now := time.Now()
+	tripBreaker(t, b, now)
+
+	now = now.Add(10 * time.Second)
+	probe, err := b.allow(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.record(probe, errUpstream, now)
+
+	// 重新打开后重新计时，原计时到期时仍然拒绝
+	if _, err := b.allow(now.Add(5 * time.Second)); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow() 5s after failed probe error = %v, want ErrCircuitOpen", err)
+	}
+	if _, err := b.allow(now.Add(10 * time.Second)); err != nil {
+		t.Errorf("allow() 10s after failed probe error = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpenInterval(t *testing.T) {
+	b := newCircuitBreaker("test", &interfaces.CircuitBreakerConfig{Threshold: 1, OpenDuration: "10s", HalfOpenProbes: 2, HalfOpenInterval: "1s"}, nil)
+	now := time.Now()
+	tripBreaker(t, b, now)
+
+	now = now.Add(10 * time.Second)
+	probe, err := b.allow(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.record(probe, nil, now)
+
+	_, err = b.allow(now.Add(400 * time.Millisecond))
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("allow() within halfOpenInterval error = %v, want *CircuitOpenError", err)
+	}
+	if got := openErr.RetryAfter(); got != 600*time.Millisecond {
+		t.Errorf("RetryAfter() = %s, want 600ms until the next probe", got)
+	}
+	probe, err = b.allow(now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("allow() after halfOpenInterval error = %v", err)
+	}
+	b.record(probe, nil, now.Add(time.Second))
+	if state := b.State(); state != BreakerStateClosed {
+		t.Errorf("state = %s, want closed", state)
+	}
+}
+
+func TestCircuitBreakerIgnoresCancelledProbe(t *testing.T) {
+	b := newCircuitBreaker("test", &interfaces.CircuitBreakerConfig{Threshold: 1, OpenDuration: "10s"}, nil)
+	now := time.Now()
+	tripBreaker(t, b, now)
+
+	now = now.Add(10 * time.Second)
+	probe, err := b.allow(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.record(probe, context.Canceled, now)
+
+	// 调用方取消的探测不计入结果，名额归还
+	if state := b.State(); state != BreakerStateHalfOpen {
+		t.Fatalf("state = %s, want half-open", state)
+	}
+	if _, err := b.allow(now); err != nil {
+		t.Errorf("allow() after cancelled probe error = %v, want nil", err)
+	}
+}
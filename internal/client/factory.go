@@ -2,27 +2,51 @@ package client
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
 )
 
 // Factory 客户端工厂实现
-type Factory struct{}
+type Factory struct {
+	opts []Option
+}
 
-// NewFactory 创建新的客户端工厂
-func NewFactory() interfaces.ClientFactory {
-	return &Factory{}
+// NewFactory 创建新的客户端工厂，opts 应用于所有内置类型的客户端
+func NewFactory(opts ...Option) interfaces.ClientFactory {
+	return &Factory{opts: opts}
 }
 
-// CreateClient 创建客户端实例
+// CreateClient 创建客户端实例，优先使用通过 RegisterClientFactory 注册的构造函数
+//
+// 配置了多个上游地址时创建连接池客户端，配置了熔断器时包装熔断器。
 func (f *Factory) CreateClient(name string, config interfaces.ServerConfig) (interfaces.MCPClient, error) {
+	var mcpClient interfaces.MCPClient
+	var err error
+	if len(config.URLs) > 0 {
+		mcpClient, err = f.createPool(name, config)
+	} else {
+		mcpClient, err = f.createClient(name, config, f.opts)
+	}
+	if err != nil || config.CircuitBreaker == nil {
+		return mcpClient, err
+	}
+	return newBreakerClient(mcpClient, config.CircuitBreaker, f.opts...), nil
+}
+
+// createClient 按传输类型创建客户端实例
+func (f *Factory) createClient(name string, config interfaces.ServerConfig, opts []Option) (interfaces.MCPClient, error) {
+	if factory, ok := lookupClientFactory(config.Transport); ok {
+		return factory(name, config)
+	}
+
 	switch config.Transport {
 	case interfaces.ClientTypeStdio:
-		return NewStdioClient(name, config)
+		return NewStdioClient(name, config, opts...)
 	case interfaces.ClientTypeSSE:
-		return NewSSEClient(name, config)
+		return NewSSEClient(name, config, opts...)
 	case interfaces.ClientTypeStreamable:
-		return NewStreamableClient(name, config)
+		return NewStreamableClient(name, config, opts...)
 	default:
 		return nil, fmt.Errorf("unsupported client type: %s", config.Transport)
 	}
@@ -30,9 +54,15 @@ func (f *Factory) CreateClient(name string, config interfaces.ServerConfig) (int
 
 // SupportedTypes 获取支持的客户端类型
 func (f *Factory) SupportedTypes() []string {
-	return []string{
+	types := []string{
 		interfaces.ClientTypeStdio,
 		interfaces.ClientTypeSSE,
 		interfaces.ClientTypeStreamable,
 	}
+	for _, transport := range RegisteredTransports() {
+		if !slices.Contains(types, transport) {
+			types = append(types, transport)
+		}
+	}
+	return types
 }
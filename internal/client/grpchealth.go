@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// grpcHealthInterval gRPC 健康检查间隔
+	grpcHealthInterval = 10 * time.Second
+	// grpcHealthTimeout 单次 gRPC 健康检查超时时间
+	grpcHealthTimeout = 5 * time.Second
+)
+
+// grpcHealthChecker 基于 grpc.health.v1 协议的上游健康探测器
+type grpcHealthChecker struct {
+	name    string
+	target  string
+	healthy atomic.Bool
+	cancel  context.CancelFunc
+	mutex   sync.Mutex
+}
+
+// newGRPCHealthChecker 创建 gRPC 健康探测器，target 为空时返回 nil
+func newGRPCHealthChecker(name, target string) *grpcHealthChecker {
+	if target == "" {
+		return nil
+	}
+	return &grpcHealthChecker{
+		name:   name,
+		target: target,
+	}
+}
+
+// Start 启动后台探测任务，独立于 MCP 连接运行
+func (h *grpcHealthChecker) Start(ctx context.Context) {
+	if h == nil {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.cancel != nil {
+		return
+	}
+
+	ctx, h.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	go h.run(ctx)
+}
+
+// Stop 停止后台探测任务
+func (h *grpcHealthChecker) Stop() {
+	if h == nil {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+	}
+	h.healthy.Store(false)
+}
+
+// Healthy 返回最近一次探测结果，未配置探测时始终为 true
+func (h *grpcHealthChecker) Healthy() bool {
+	if h == nil {
+		return true
+	}
+	return h.healthy.Load()
+}
+
+// run 定期调用 Health.Check 并更新健康状态
+func (h *grpcHealthChecker) run(ctx context.Context) {
+	conn, err := grpc.NewClient(h.target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		slog.Error("Failed to create gRPC health client", "server", h.name, "target", h.target, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	healthClient := healthpb.NewHealthClient(conn)
+	ticker := time.NewTicker(grpcHealthInterval)
+	defer ticker.Stop()
+
+	for {
+		h.check(ctx, healthClient)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check 执行一次健康检查
+func (h *grpcHealthChecker) check(ctx context.Context, healthClient healthpb.HealthClient) {
+	checkCtx, cancel := context.WithTimeout(ctx, grpcHealthTimeout)
+	defer cancel()
+
+	resp, err := healthClient.Check(checkCtx, &healthpb.HealthCheckRequest{})
+	healthy := err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+	if previous := h.healthy.Swap(healthy); previous != healthy {
+		if healthy {
+			slog.Info("gRPC health check is serving", "server", h.name, "target", h.target)
+		} else {
+			slog.Warn("gRPC health check failed", "server", h.name, "target", h.target, "status", resp.GetStatus().String(), "error", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// defaultAuthScheme 上游认证头的默认方案
+const defaultAuthScheme = "Bearer"
+
+// requestHeaders 返回发往上游的请求头，配置了 authToken 时附加 Authorization 头
+//
+// 返回副本，不修改配置中的 headers。
+func requestHeaders(config interfaces.ServerConfig) map[string]string {
+	if config.AuthToken == "" {
+		return config.Headers
+	}
+
+	headers := make(map[string]string, len(config.Headers)+1)
+	for key, value := range config.Headers {
+		headers[key] = value
+	}
+	scheme := config.AuthScheme
+	if scheme == "" {
+		scheme = defaultAuthScheme
+	}
+	headers["Authorization"] = scheme + " " + config.AuthToken
+	return headers
+}
+
+// newHTTPClient 按连接池和 TLS 配置创建 HTTP 客户端，均未配置时返回 nil 以使用默认客户端
+func newHTTPClient(config interfaces.ServerConfig, tlsConfig *tls.Config) *http.Client {
+	if config.MaxIdleConns == 0 && config.MaxConnsPerHost == 0 && config.IdleConnTimeout == "" && tlsConfig == nil {
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+		transport.MaxIdleConnsPerHost = config.MaxIdleConns
+	}
+	if config.MaxConnsPerHost > 0 {
+		// 超过上限的请求会等待空闲连接，形成自然的背压
+		transport.MaxConnsPerHost = config.MaxConnsPerHost
+	}
+	if config.IdleConnTimeout != "" {
+		// 配置已在加载时校验
+		if timeout, err := time.ParseDuration(config.IdleConnTimeout); err == nil {
+			transport.IdleConnTimeout = timeout
+		}
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// newClientTLSConfig 按上游 TLS 配置加载 CA 和客户端证书，未配置时返回 nil
+func newClientTLSConfig(logTag string, config *interfaces.ClientTLSConfig) (*tls.Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if config.InsecureSkipVerify {
+		slog.Warn("TLS certificate verification is disabled", "server", logTag)
+	}
+	if config.CAFile != "" {
+		data, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// pingInterval 解析 SSE 和 Streamable 客户端的 ping 间隔，未配置时使用默认间隔，为 0 时不 ping
+func pingInterval(config interfaces.ServerConfig) time.Duration {
+	if config.PingInterval == "" {
+		return interfaces.DefaultPingInterval
+	}
+	// 配置已在加载时校验
+	interval, _ := time.ParseDuration(config.PingInterval)
+	return interval
+}
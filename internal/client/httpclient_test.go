@@ -0,0 +1,89 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// newConnCountingServer 启动统计新建 TCP 连接数的模拟服务器，每个请求处理 delay 后返回
+func newConnCountingServer(t *testing.T, delay time.Duration) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var conns atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, &conns
+}
+
+// sendConcurrently 并发发送 n 个请求并等待全部完成
+func sendConcurrently(t *testing.T, httpClient *http.Client, url string, n int) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := httpClient.Get(url)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNewHTTPClientMaxConnsPerHost(t *testing.T) {
+	server, conns := newConnCountingServer(t, 50*time.Millisecond)
+
+	httpClient := newHTTPClient(interfaces.ServerConfig{MaxConnsPerHost: 2}, nil)
+	if httpClient == nil {
+		t.Fatal("newHTTPClient() = nil with maxConnsPerHost set")
+	}
+	sendConcurrently(t, httpClient, server.URL, 10)
+
+	if got := conns.Load(); got > 2 {
+		t.Errorf("opened %d connections, want at most 2", got)
+	}
+}
+
+func TestNewHTTPClientReusesIdleConns(t *testing.T) {
+	server, conns := newConnCountingServer(t, 0)
+
+	httpClient := newHTTPClient(interfaces.ServerConfig{MaxIdleConns: 1, IdleConnTimeout: "30s"}, nil)
+	for i := 0; i < 5; i++ {
+		sendConcurrently(t, httpClient, server.URL, 1)
+	}
+
+	if got := conns.Load(); got != 1 {
+		t.Errorf("opened %d connections for sequential requests, want 1", got)
+	}
+	transport := httpClient.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 1 || transport.IdleConnTimeout != 30*time.Second {
+		t.Errorf("transport = {MaxIdleConnsPerHost: %d, IdleConnTimeout: %s}, want {1, 30s}", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+}
+
+func TestNewHTTPClientDefault(t *testing.T) {
+	if httpClient := newHTTPClient(interfaces.ServerConfig{}, nil); httpClient != nil {
+		t.Error("newHTTPClient() without pool settings should use the default client")
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"log/slog"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// newInitializeRequest 构造发送给上游的初始化请求
+func newInitializeRequest(config interfaces.ServerConfig, clientInfo mcp.Implementation) mcp.InitializeRequest {
+	protocolVersion := mcp.LATEST_PROTOCOL_VERSION
+	if config.ProtocolVersion != "" {
+		protocolVersion = config.ProtocolVersion
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = protocolVersion
+	initRequest.Params.ClientInfo = clientInfo
+	initRequest.Params.Capabilities = mcp.ClientCapabilities{
+		Experimental: make(map[string]interface{}),
+		Roots:        nil,
+		Sampling:     nil,
+	}
+	return initRequest
+}
+
+// logInitializeResult 记录上游返回的协议版本，便于排查版本不匹配
+func logInitializeResult(name string, request mcp.InitializeRequest, result *mcp.InitializeResult) {
+	if result == nil {
+		return
+	}
+
+	requested := request.Params.ProtocolVersion
+	if result.ProtocolVersion != requested {
+		slog.Warn("Upstream responded with a different protocol version", "server", name, "protocol_version", result.ProtocolVersion, "requested", requested)
+		return
+	}
+	slog.Info("Upstream protocol version", "server", name, "protocol_version", result.ProtocolVersion)
+}
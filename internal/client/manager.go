@@ -1,27 +1,29 @@
 package client
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // Manager 客户端管理器实现
 type Manager struct {
-	clients map[string]interfaces.MCPClient
-	mutex   sync.RWMutex
-	factory interfaces.ClientFactory
+	clients      map[string]interfaces.MCPClient
+	stopTimeouts map[string]time.Duration
+	mutex        sync.RWMutex
+	factory      interfaces.ClientFactory
 }
 
 // NewManager 创建新的客户端管理器
 func NewManager(factory interfaces.ClientFactory) interfaces.ClientManager {
 	return &Manager{
-		clients: make(map[string]interfaces.MCPClient),
-		factory: factory,
+		clients:      make(map[string]interfaces.MCPClient),
+		stopTimeouts: make(map[string]time.Duration),
+		factory:      factory,
 	}
 }
 
@@ -36,7 +38,7 @@ func (m *Manager) AddClient(client interfaces.MCPClient) error {
 	}
 
 	m.clients[name] = client
-	log.Printf("Added client: %s (type: %s)", name, client.GetType())
+	slog.Info("Added client", "client", name, "type", client.GetType())
 	return nil
 }
 
@@ -52,11 +54,12 @@ func (m *Manager) RemoveClient(name string) error {
 
 	// 断开连接
 	if err := client.Disconnect(); err != nil {
-		log.Printf("Error disconnecting client %s: %v", name, err)
+		slog.Error("Error disconnecting client", "client", name, "error", err)
 	}
 
 	delete(m.clients, name)
-	log.Printf("Removed client: %s", name)
+	delete(m.stopTimeouts, name)
+	slog.Info("Removed client", "client", name)
 	return nil
 }
 
@@ -81,72 +84,33 @@ func (m *Manager) GetClients() map[string]interfaces.MCPClient {
 	return result
 }
 
-// StartAll 启动所有客户端
-func (m *Manager) StartAll(ctx context.Context, clientInfo mcp.Implementation) error {
-	m.mutex.RLock()
-	clients := make(map[string]interfaces.MCPClient)
-	for name, client := range m.clients {
-		clients[name] = client
-	}
-	m.mutex.RUnlock()
-
-	if len(clients) == 0 {
-		log.Printf("No clients to start")
-		return nil
-	}
-
-	// 并发启动所有客户端
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(clients))
-
-	for name, client := range clients {
-		wg.Add(1)
-		go func(name string, client interfaces.MCPClient) {
-			defer wg.Done()
-
-			log.Printf("Starting client: %s", name)
-			if err := client.Connect(ctx, clientInfo); err != nil {
-				log.Printf("Failed to start client %s: %v", name, err)
-				select {
-				case errChan <- fmt.Errorf("failed to start client %s: %w", name, err):
-				default:
-				}
-				return
-			}
-			log.Printf("Successfully started client: %s", name)
-		}(name, client)
-	}
-
-	// 等待所有客户端启动完成
-	wg.Wait()
-	close(errChan)
-
-	// 收集所有错误
-	var startErrors []error
-	for err := range errChan {
-		startErrors = append(startErrors, err)
-	}
+// SetStopTimeout 设置停止客户端时等待 Disconnect 的最长时间，0 表示一直等待
+func (m *Manager) SetStopTimeout(name string, timeout time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
-	if len(startErrors) > 0 {
-		// 如果有错误，返回第一个错误
-		return startErrors[0]
+	if timeout <= 0 {
+		delete(m.stopTimeouts, name)
+		return
 	}
-
-	log.Printf("All clients started successfully")
-	return nil
+	m.stopTimeouts[name] = timeout
 }
 
-// StopAll 停止所有客户端
+// StopAll 停止所有客户端，即使部分客户端停止失败也会继续停止其余客户端，返回合并后的全部错误
 func (m *Manager) StopAll() error {
 	m.mutex.RLock()
 	clients := make(map[string]interfaces.MCPClient)
 	for name, client := range m.clients {
 		clients[name] = client
 	}
+	stopTimeouts := make(map[string]time.Duration, len(m.stopTimeouts))
+	for name, timeout := range m.stopTimeouts {
+		stopTimeouts[name] = timeout
+	}
 	m.mutex.RUnlock()
 
 	if len(clients) == 0 {
-		log.Printf("No clients to stop")
+		slog.Info("No clients to stop")
 		return nil
 	}
 
@@ -159,16 +123,14 @@ func (m *Manager) StopAll() error {
 		go func(name string, client interfaces.MCPClient) {
 			defer wg.Done()
 
-			log.Printf("Stopping client: %s", name)
-			if err := client.Disconnect(); err != nil {
-				log.Printf("Error stopping client %s: %v", name, err)
-				select {
-				case errChan <- fmt.Errorf("failed to stop client %s: %w", name, err):
-				default:
-				}
+			slog.Info("Stopping client", "client", name)
+			start := time.Now()
+			if err := disconnectWithTimeout(client, stopTimeouts[name]); err != nil {
+				slog.Error("Error stopping client", "client", name, "duration", time.Since(start), "error", err)
+				errChan <- fmt.Errorf("failed to stop client %s: %w", name, err)
 				return
 			}
-			log.Printf("Successfully stopped client: %s", name)
+			slog.Info("Successfully stopped client", "client", name, "duration", time.Since(start))
 		}(name, client)
 	}
 
@@ -183,16 +145,36 @@ func (m *Manager) StopAll() error {
 	}
 
 	if len(stopErrors) > 0 {
-		// 记录所有错误但不返回错误（确保清理工作完成）
-		for _, err := range stopErrors {
-			log.Printf("Stop error: %v", err)
-		}
+		slog.Warn("Stopped all clients with errors", "errors", len(stopErrors))
+		return errors.Join(stopErrors...)
 	}
 
-	log.Printf("All clients stopped")
+	slog.Info("All clients stopped")
 	return nil
 }
 
+// disconnectWithTimeout 断开客户端连接，超过 timeout 仍未返回时放弃等待并返回超时错误
+func disconnectWithTimeout(client interfaces.MCPClient, timeout time.Duration) error {
+	if timeout <= 0 {
+		return client.Disconnect()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Disconnect()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("disconnect did not finish within %s", timeout)
+	}
+}
+
 // CreateAndAddClient 创建并添加客户端
 func (m *Manager) CreateAndAddClient(name string, config interfaces.ServerConfig) error {
 	client, err := m.factory.CreateClient(name, config)
@@ -217,6 +199,20 @@ func (m *Manager) GetConnectedClients() map[string]interfaces.MCPClient {
 	return result
 }
 
+// GetDisconnectedClients 获取已配置但未连接的客户端
+func (m *Manager) GetDisconnectedClients() map[string]interfaces.MCPClient {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make(map[string]interfaces.MCPClient)
+	for name, client := range m.clients {
+		if !client.IsConnected() {
+			result[name] = client
+		}
+	}
+	return result
+}
+
 // GetClientStats 获取客户端统计信息
 func (m *Manager) GetClientStats() map[string]map[string]interface{} {
 	m.mutex.RLock()
@@ -224,11 +220,19 @@ func (m *Manager) GetClientStats() map[string]map[string]interface{} {
 
 	result := make(map[string]map[string]interface{})
 	for name, client := range m.clients {
-		result[name] = map[string]interface{}{
+		stats := map[string]interface{}{
 			"type":      client.GetType(),
 			"connected": client.IsConnected(),
 			"needsPing": client.NeedsPing(),
 		}
+		if reporter, ok := client.(BreakerStateReporter); ok {
+			stats["circuitBreaker"] = reporter.CircuitBreakerState()
+		}
+		// 连接池可能被熔断器包装
+		if reporter, ok := unwrapBreaker(client).(PoolStatsReporter); ok {
+			stats["backends"] = reporter.BackendStats()
+		}
+		result[name] = stats
 	}
 	return result
 }
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// fakeClient 只实现管理器用到的方法的客户端
+type fakeClient struct {
+	interfaces.MCPClient
+	name       string
+	connected  bool
+	disconnect func() error
+}
+
+func (c *fakeClient) GetName() string   { return c.name }
+func (c *fakeClient) GetType() string   { return "fake" }
+func (c *fakeClient) IsConnected() bool { return c.connected }
+func (c *fakeClient) NeedsPing() bool   { return false }
+
+func (c *fakeClient) Disconnect() error {
+	if c.disconnect == nil {
+		return nil
+	}
+	return c.disconnect()
+}
+
+// newTestManager 创建包含给定客户端的管理器
+func newTestManager(t *testing.T, clients ...interfaces.MCPClient) *Manager {
+	t.Helper()
+
+	manager := NewManager(NewFactory()).(*Manager)
+	for _, client := range clients {
+		if err := manager.AddClient(client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return manager
+}
+
+// sortedKeys 返回按名称排序的客户端名称
+func sortedKeys(clients map[string]interfaces.MCPClient) []string {
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestManagerConnectedAndDisconnectedClients(t *testing.T) {
+	manager := newTestManager(t,
+		&fakeClient{name: "a", connected: true},
+		&fakeClient{name: "b", connected: false},
+		&fakeClient{name: "c", connected: true},
+		&fakeClient{name: "d", connected: false},
+	)
+
+	if got, want := sortedKeys(manager.GetConnectedClients()), []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("GetConnectedClients() = %v, want %v", got, want)
+	}
+	if got, want := sortedKeys(manager.GetDisconnectedClients()), []string{"b", "d"}; !slices.Equal(got, want) {
+		t.Errorf("GetDisconnectedClients() = %v, want %v", got, want)
+	}
+
+	// 两个集合互补，覆盖所有客户端
+	if got := len(manager.GetConnectedClients()) + len(manager.GetDisconnectedClients()); got != len(manager.GetClients()) {
+		t.Errorf("connected + disconnected = %d, want %d", got, len(manager.GetClients()))
+	}
+}
+
+func TestManagerClientsEmpty(t *testing.T) {
+	manager := newTestManager(t)
+	if clients := manager.GetConnectedClients(); len(clients) != 0 {
+		t.Errorf("GetConnectedClients() = %v, want empty", clients)
+	}
+	if clients := manager.GetDisconnectedClients(); len(clients) != 0 {
+		t.Errorf("GetDisconnectedClients() = %v, want empty", clients)
+	}
+}
+
+func TestManagerStopAllCollectsErrors(t *testing.T) {
+	errStop := errors.New("stop failed")
+	var stopped atomic.Int32
+	stop := func() error {
+		stopped.Add(1)
+		return nil
+	}
+	manager := newTestManager(t,
+		&fakeClient{name: "a", disconnect: stop},
+		&fakeClient{name: "broken", disconnect: func() error { return errStop }},
+		&fakeClient{name: "c", disconnect: stop},
+	)
+
+	err := manager.StopAll()
+	if !errors.Is(err, errStop) {
+		t.Fatalf("StopAll() error = %v, want %v", err, errStop)
+	}
+	// 一个客户端停止失败不影响其他客户端停止
+	if got := stopped.Load(); got != 2 {
+		t.Errorf("stopped clients = %d, want 2", got)
+	}
+}
+
+func TestManagerStopAllTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var stopped atomic.Bool
+	manager := newTestManager(t,
+		&fakeClient{name: "hang", disconnect: func() error {
+			<-release
+			return nil
+		}},
+		&fakeClient{name: "ok", disconnect: func() error {
+			stopped.Store(true)
+			return nil
+		}},
+	)
+	manager.SetStopTimeout("hang", 50*time.Millisecond)
+
+	start := time.Now()
+	err := manager.StopAll()
+	if err == nil {
+		t.Fatal("StopAll() error = nil, want timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("StopAll() took %s, want it to give up after the stop timeout", elapsed)
+	}
+	if !stopped.Load() {
+		t.Error("client ok was not stopped")
+	}
+}
+
+func TestManagerStopAllEmpty(t *testing.T) {
+	if err := newTestManager(t).StopAll(); err != nil {
+		t.Errorf("StopAll() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+const (
+	// oauth2RefreshRatio token 在有效期过去该比例后刷新
+	oauth2RefreshRatio = 0.8
+	// oauth2DefaultExpiry 令牌端点未返回 expires_in 时假定的有效期
+	oauth2DefaultExpiry = 5 * time.Minute
+	// oauth2RequestTimeout 单次获取 token 的超时时间
+	oauth2RequestTimeout = 10 * time.Second
+	// oauth2MinBackoff 刷新失败后的初始重试间隔
+	oauth2MinBackoff = time.Second
+	// oauth2MaxBackoff 刷新失败后的最大重试间隔
+	oauth2MaxBackoff = time.Minute
+)
+
+// oauth2TokenManager 通过 client credentials 授权获取并缓存上游访问令牌
+//
+// token 缓存到有效期的 80%，后台任务在此之前主动刷新；刷新失败时按指数退避重试，
+// 期间客户端被视为未连接。
+type oauth2TokenManager struct {
+	name         string
+	clientID     string
+	clientSecret string
+	tokenURL     string
+	scopes       []string
+	httpClient   *http.Client
+
+	mutex     sync.Mutex
+	token     string
+	refreshAt time.Time
+	healthy   atomic.Bool
+	cancel    context.CancelFunc
+}
+
+// oauth2TokenResponse 令牌端点响应
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// newOAuth2TokenManager 创建令牌管理器，未配置 oauth2TokenURL 时返回 nil
+func newOAuth2TokenManager(name string, config interfaces.ServerConfig) *oauth2TokenManager {
+	if config.OAuth2TokenURL == "" {
+		return nil
+	}
+
+	httpClient := newHTTPClient(config, nil)
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	httpClient.Timeout = oauth2RequestTimeout
+
+	return &oauth2TokenManager{
+		name:         name,
+		clientID:     config.OAuth2ClientID,
+		clientSecret: config.OAuth2ClientSecret,
+		tokenURL:     config.OAuth2TokenURL,
+		scopes:       config.OAuth2Scopes,
+		httpClient:   httpClient,
+	}
+}
+
+// Start 获取首个 token 并启动后台刷新任务
+func (m *oauth2TokenManager) Start(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+
+	if _, err := m.refresh(ctx); err != nil {
+		return fmt.Errorf("failed to fetch oauth2 token: %w", err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cancel == nil {
+		var refreshCtx context.Context
+		refreshCtx, m.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		go m.run(refreshCtx)
+	}
+	return nil
+}
+
+// Stop 停止后台刷新任务
+func (m *oauth2TokenManager) Stop() {
+	if m == nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.healthy.Store(false)
+}
+
+// Healthy 最近一次获取 token 是否成功，未配置 OAuth2 时始终为 true
+func (m *oauth2TokenManager) Healthy() bool {
+	if m == nil {
+		return true
+	}
+	return m.healthy.Load()
+}
+
+// Headers 返回携带当前 token 的请求头，用作 mcp-go 的 HTTPHeaderFunc
+func (m *oauth2TokenManager) Headers(ctx context.Context) map[string]string {
+	token, err := m.Token(ctx)
+	if err != nil {
+		slog.Error("Failed to get oauth2 token", "server", m.name, "error", err)
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// Token 返回缓存的 token，已到刷新时间时同步刷新
+func (m *oauth2TokenManager) Token(ctx context.Context) (string, error) {
+	m.mutex.Lock()
+	token, refreshAt := m.token, m.refreshAt
+	m.mutex.Unlock()
+
+	if token != "" && time.Now().Before(refreshAt) {
+		return token, nil
+	}
+	return m.refresh(ctx)
+}
+
+// run 在 token 到达刷新时间时主动刷新，失败时按指数退避重试
+func (m *oauth2TokenManager) run(ctx context.Context) {
+	backoff := oauth2MinBackoff
+	for {
+		m.mutex.Lock()
+		wait := time.Until(m.refreshAt)
+		m.mutex.Unlock()
+		if !m.healthy.Load() {
+			wait = backoff
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := m.refresh(ctx); err != nil {
+			slog.Warn("Failed to refresh oauth2 token, retrying", "server", m.name, "backoff", backoff, "error", err)
+			backoff = min(backoff*2, oauth2MaxBackoff)
+			continue
+		}
+		backoff = oauth2MinBackoff
+	}
+}
+
+// refresh 通过 client credentials 授权获取新 token
+func (m *oauth2TokenManager) refresh(ctx context.Context) (string, error) {
+	token, expiresIn, err := m.fetch(ctx)
+	if err != nil {
+		m.healthy.Store(false)
+		return "", err
+	}
+
+	expiry := oauth2DefaultExpiry
+	if expiresIn > 0 {
+		expiry = time.Duration(expiresIn) * time.Second
+	}
+
+	m.mutex.Lock()
+	m.token = token
+	m.refreshAt = time.Now().Add(time.Duration(float64(expiry) * oauth2RefreshRatio))
+	m.mutex.Unlock()
+	m.healthy.Store(true)
+	return token, nil
+}
+
+// fetch 请求令牌端点，客户端凭据按 RFC 6749 使用 HTTP Basic 认证发送
+func (m *oauth2TokenManager) fetch(ctx context.Context) (string, int64, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(m.scopes) > 0 {
+		form.Set("scope", strings.Join(m.scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(m.clientID), url.QueryEscape(m.clientSecret))
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, err
+	}
+
+	var tokenResp oauth2TokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", 0, fmt.Errorf("invalid token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.Error != "" {
+		return "", 0, fmt.Errorf("token endpoint returned status %d: %s %s", resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response has no access_token")
+	}
+	return tokenResp.AccessToken, tokenResp.ExpiresIn, nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// 连接结果
+const (
+	connectResultSuccess = "success"
+	connectResultError   = "error"
+)
+
+// ConnectRecorder 记录客户端从开始连接到完成 Initialize 握手的耗时
+type ConnectRecorder interface {
+	RecordClientConnect(serverName, transport, result string, duration time.Duration)
+}
+
+// Option 内置客户端的构造选项
+type Option func(*options)
+
+// options 内置客户端的可选依赖
+type options struct {
+	recorder        ConnectRecorder
+	onStateChange   func(interfaces.StateChange)
+	breakerRecorder BreakerRecorder
+}
+
+// WithConnectRecorder 设置连接耗时记录器
+func WithConnectRecorder(recorder ConnectRecorder) Option {
+	return func(o *options) {
+		o.recorder = recorder
+	}
+}
+
+// WithStateChangeHandler 设置连接状态变化回调，回调在状态变化后同步调用，不应阻塞
+func WithStateChangeHandler(handler func(interfaces.StateChange)) Option {
+	return func(o *options) {
+		o.onStateChange = handler
+	}
+}
+
+// WithBreakerRecorder 设置熔断器状态记录器
+func WithBreakerRecorder(recorder BreakerRecorder) Option {
+	return func(o *options) {
+		o.breakerRecorder = recorder
+	}
+}
+
+// newOptions 应用构造选项
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// recordConnect 记录一次连接尝试的耗时和结果，未设置记录器时忽略
+func (o options) recordConnect(name, transport string, start time.Time, err error) {
+	if o.recorder == nil {
+		return
+	}
+	result := connectResultSuccess
+	if err != nil {
+		result = connectResultError
+	}
+	o.recorder.RecordClientConnect(name, transport, result, time.Since(start))
+}
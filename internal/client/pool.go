@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// PoolStatsReporter 由连接池客户端实现，供管理 API 查询每个后端的状态
+type PoolStatsReporter interface {
+	BackendStats() []map[string]interface{}
+}
+
+// poolMember 连接池中的一个后端
+type poolMember struct {
+	interfaces.MCPClient
+	url      string
+	healthy  atomic.Bool
+	inFlight atomic.Int64
+}
+
+// usable 后端已连接且最近一次探测成功
+func (m *poolMember) usable() bool {
+	return m.IsConnected() && m.healthy.Load()
+}
+
+// Pool 连接池客户端，将同一服务器的多个上游地址组合为一个客户端
+//
+// 调用按负载均衡策略分发到可用的后端；可用后端少于 minHealthy 时整个客户端视为未连接。
+// 后台按 pingInterval 探测每个后端，探测失败的后端不再分配调用，连接失败的后端会重新连接。
+type Pool struct {
+	name        string
+	logTag      string
+	transport   string
+	strategy    string
+	minHealthy  int
+	interval    time.Duration
+	members     []*poolMember
+	next        atomic.Uint64
+	state       *clientState
+	clientInfo  mcp.Implementation
+	stopMonitor context.CancelFunc
+}
+
+// createPool 为 urls 中的每个地址创建一个后端客户端并组合为连接池
+func (f *Factory) createPool(name string, config interfaces.ServerConfig) (interfaces.MCPClient, error) {
+	o := newOptions(f.opts)
+	logTag := interfaces.ServerLogTag(name, config)
+	pool := &Pool{
+		name:       name,
+		logTag:     logTag,
+		transport:  config.Transport,
+		strategy:   config.LoadBalance,
+		minHealthy: max(config.MinHealthy, 1),
+		interval:   pingInterval(config),
+		state:      newClientState(name, logTag, o.onStateChange),
+	}
+
+	// 后端状态变化照常发布（名称为 name[i]），同时重新评估连接池状态
+	memberOpts := append(slices.Clone(f.opts), WithStateChangeHandler(func(change interfaces.StateChange) {
+		if o.onStateChange != nil {
+			o.onStateChange(change)
+		}
+		pool.evaluate()
+	}))
+	for i, url := range config.URLs {
+		memberConfig := config
+		memberConfig.URL = url
+		memberConfig.URLs = nil
+		// 后端由连接池的 monitor 统一探测，不再各自定期 ping
+		memberConfig.PingInterval = "0s"
+		memberConfig.LogTag = fmt.Sprintf("%s[%d]", logTag, i)
+		member, err := f.createClient(fmt.Sprintf("%s[%d]", name, i), memberConfig, memberOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for %s: %w", url, err)
+		}
+		pool.members = append(pool.members, &poolMember{MCPClient: member, url: url})
+	}
+	return pool, nil
+}
+
+// Connect 并发连接所有后端，至少 minHealthy 个后端连接成功时视为连接成功
+func (p *Pool) Connect(ctx context.Context, clientInfo mcp.Implementation) error {
+	if p.state.Is(interfaces.ClientStateConnected) {
+		return nil
+	}
+	if err := p.state.Transition(interfaces.ClientStateConnecting); err != nil {
+		return err
+	}
+	p.clientInfo = clientInfo
+
+	errs := make([]error, len(p.members))
+	var wg sync.WaitGroup
+	for i, member := range p.members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := member.Connect(ctx, clientInfo); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", member.url, err)
+				slog.Warn("Backend failed to connect", "server", p.logTag, "url", member.url, "error", err)
+				return
+			}
+			member.healthy.Store(true)
+		}()
+	}
+	wg.Wait()
+
+	if healthy := p.healthyCount(); healthy < p.minHealthy {
+		// 断开已连接的后端，避免连接失败后仍占用上游会话和子进程
+		for i, member := range p.members {
+			if errs[i] == nil {
+				_ = member.Disconnect()
+				member.healthy.Store(false)
+			}
+		}
+		_ = p.state.Transition(interfaces.ClientStateFailed)
+		return fmt.Errorf("only %d of %d backends connected, need %d: %w", healthy, len(p.members), p.minHealthy, errors.Join(errs...))
+	}
+	_ = p.state.Transition(interfaces.ClientStateInitializing)
+	if err := p.state.Transition(interfaces.ClientStateConnected); err != nil {
+		return err
+	}
+
+	// 探测和重新连接在 Connect 返回后持续进行，不随连接超时取消，由 Disconnect 停止
+	if p.interval > 0 {
+		var monitorCtx context.Context
+		monitorCtx, p.stopMonitor = context.WithCancel(context.WithoutCancel(ctx))
+		go p.monitor(monitorCtx)
+	}
+	return nil
+}
+
+// monitor 定期探测后端，重新连接连接失败的后端
+func (p *Pool) monitor(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.probe(ctx)
+		}
+	}
+}
+
+// probe 并发探测所有后端并更新可用状态，返回可用的后端数
+func (p *Pool) probe(ctx context.Context) int {
+	var wg sync.WaitGroup
+	for _, member := range p.members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.probeMember(ctx, member)
+		}()
+	}
+	wg.Wait()
+
+	p.evaluate()
+	return p.healthyCount()
+}
+
+// probeMember 探测单个后端，未连接的后端尝试重新连接
+func (p *Pool) probeMember(ctx context.Context, member *poolMember) {
+	state := member.GetState()
+	if state == interfaces.ClientStateFailed || state == interfaces.ClientStateDisconnected {
+		if err := member.Connect(ctx, p.clientInfo); err != nil {
+			slog.Debug("Backend reconnect failed", "server", p.logTag, "url", member.url, "error", err)
+			return
+		}
+		slog.Info("Backend reconnected", "server", p.logTag, "url", member.url)
+		member.healthy.Store(true)
+		return
+	}
+	if state != interfaces.ClientStateConnected {
+		return
+	}
+
+	timeout := p.interval
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := member.Ping(pingCtx)
+	if healthy := err == nil; member.healthy.Swap(healthy) != healthy {
+		if healthy {
+			slog.Info("Backend is healthy again", "server", p.logTag, "url", member.url)
+		} else {
+			slog.Warn("Backend failed health check", "server", p.logTag, "url", member.url, "error", err)
+		}
+	}
+}
+
+// evaluate 根据可用后端数切换连接池状态
+func (p *Pool) evaluate() {
+	healthy := p.healthyCount()
+	switch {
+	case p.state.Is(interfaces.ClientStateConnected) && healthy < p.minHealthy:
+		slog.Warn("Not enough healthy backends", "server", p.logTag, "healthy", healthy, "min_healthy", p.minHealthy)
+		_ = p.state.Transition(interfaces.ClientStateReconnecting)
+	case p.state.Is(interfaces.ClientStateReconnecting) && healthy >= p.minHealthy:
+		_ = p.state.Transition(interfaces.ClientStateConnected)
+	}
+}
+
+// healthyCount 获取可用的后端数
+func (p *Pool) healthyCount() int {
+	count := 0
+	for _, member := range p.members {
+		if member.usable() {
+			count++
+		}
+	}
+	return count
+}
+
+// pick 按负载均衡策略选择一个可用的后端
+func (p *Pool) pick() (*poolMember, error) {
+	candidates := make([]*poolMember, 0, len(p.members))
+	for _, member := range p.members {
+		if member.usable() {
+			candidates = append(candidates, member)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no healthy backends for server %s", p.name)
+	}
+
+	// 轮询起点同时用于打散最少连接策略中连接数相同的后端
+	start := int(p.next.Add(1) % uint64(len(candidates)))
+	if p.strategy != interfaces.LoadBalanceLeastConnections {
+		return candidates[start], nil
+	}
+	selected := candidates[start]
+	for i := 1; i < len(candidates); i++ {
+		candidate := candidates[(start+i)%len(candidates)]
+		if candidate.inFlight.Load() < selected.inFlight.Load() {
+			selected = candidate
+		}
+	}
+	return selected, nil
+}
+
+// poolCall 将请求转发给按负载均衡策略选择的后端
+func poolCall[Req, Res any](p *Pool, ctx context.Context, request Req, call func(interfaces.MCPClient, context.Context, Req) (Res, error)) (Res, error) {
+	member, err := p.pick()
+	if err != nil {
+		var zero Res
+		return zero, err
+	}
+	member.inFlight.Add(1)
+	defer member.inFlight.Add(-1)
+	return call(member.MCPClient, ctx, request)
+}
+
+// Disconnect 断开所有后端
+func (p *Pool) Disconnect() error {
+	if p.stopMonitor != nil {
+		p.stopMonitor()
+		p.stopMonitor = nil
+	}
+
+	var errs []error
+	for _, member := range p.members {
+		if err := member.Disconnect(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", member.url, err))
+		}
+		member.healthy.Store(false)
+	}
+	_ = p.state.Transition(interfaces.ClientStateDisconnected)
+	return errors.Join(errs...)
+}
+
+// GetName 获取客户端名称
+func (p *Pool) GetName() string {
+	return p.name
+}
+
+// GetType 获取客户端类型
+func (p *Pool) GetType() string {
+	return p.transport
+}
+
+// IsConnected 检查可用后端数是否达到 minHealthy
+func (p *Pool) IsConnected() bool {
+	return p.state.Is(interfaces.ClientStateConnected) && p.healthyCount() >= p.minHealthy
+}
+
+// GetState 获取连接状态
+func (p *Pool) GetState() interfaces.ClientState {
+	return p.state.Get()
+}
+
+// GetServerInfo 获取第一个可用后端在初始化时返回的服务器名称和版本
+func (p *Pool) GetServerInfo() mcp.Implementation {
+	for _, member := range p.members {
+		if member.usable() {
+			return member.GetServerInfo()
+		}
+	}
+	return p.members[0].GetServerInfo()
+}
+
+// NeedsPing 是否需要定期 ping
+func (p *Pool) NeedsPing() bool {
+	return p.interval > 0
+}
+
+// Ping 探测所有后端，可用后端少于 minHealthy 时返回错误
+func (p *Pool) Ping(ctx context.Context) error {
+	if healthy := p.probe(ctx); healthy < p.minHealthy {
+		return fmt.Errorf("only %d of %d backends are healthy, need %d", healthy, len(p.members), p.minHealthy)
+	}
+	return nil
+}
+
+// BackendStats 获取每个后端的地址、连接状态和进行中的调用数
+func (p *Pool) BackendStats() []map[string]interface{} {
+	stats := make([]map[string]interface{}, 0, len(p.members))
+	for _, member := range p.members {
+		stats = append(stats, map[string]interface{}{
+			"url":      member.url,
+			"state":    member.GetState(),
+			"healthy":  member.usable(),
+			"inFlight": member.inFlight.Load(),
+		})
+	}
+	return stats
+}
+
+// MCP 协议方法实现
+
+func (p *Pool) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	return poolCall(p, ctx, request, interfaces.MCPClient.Initialize)
+}
+
+func (p *Pool) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return poolCall(p, ctx, request, interfaces.MCPClient.ListTools)
+}
+
+func (p *Pool) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return poolCall(p, ctx, request, interfaces.MCPClient.CallTool)
+}
+
+func (p *Pool) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	return poolCall(p, ctx, request, interfaces.MCPClient.ListPrompts)
+}
+
+func (p *Pool) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return poolCall(p, ctx, request, interfaces.MCPClient.GetPrompt)
+}
+
+func (p *Pool) ListResources(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	return poolCall(p, ctx, request, interfaces.MCPClient.ListResources)
+}
+
+func (p *Pool) ReadResource(ctx context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	return poolCall(p, ctx, request, interfaces.MCPClient.ReadResource)
+}
+
+func (p *Pool) ListResourceTemplates(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	return poolCall(p, ctx, request, interfaces.MCPClient.ListResourceTemplates)
+}
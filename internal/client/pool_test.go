@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// poolBackend 记录连接和调用次数的连接池后端
+type poolBackend struct {
+	interfaces.MCPClient
+	connectErr  error
+	connected   atomic.Bool
+	disconnects atomic.Int32
+	calls       atomic.Int64
+}
+
+func (b *poolBackend) Connect(ctx context.Context, clientInfo mcp.Implementation) error {
+	if b.connectErr != nil {
+		return b.connectErr
+	}
+	b.connected.Store(true)
+	return nil
+}
+
+func (b *poolBackend) Disconnect() error {
+	b.disconnects.Add(1)
+	b.connected.Store(false)
+	return nil
+}
+
+func (b *poolBackend) IsConnected() bool { return b.connected.Load() }
+
+func (b *poolBackend) GetState() interfaces.ClientState {
+	if b.connected.Load() {
+		return interfaces.ClientStateConnected
+	}
+	return interfaces.ClientStateFailed
+}
+
+func (b *poolBackend) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	b.calls.Add(1)
+	return mcp.NewToolResultText("ok"), nil
+}
+
+// newTestPool 创建由给定后端组成的连接池，不启动后台探测
+func newTestPool(strategy string, minHealthy int, backends ...*poolBackend) *Pool {
+	pool := &Pool{
+		name:       "pool",
+		logTag:     "pool",
+		strategy:   strategy,
+		minHealthy: minHealthy,
+		state:      newClientState("pool", "pool", nil),
+	}
+	for _, backend := range backends {
+		pool.members = append(pool.members, &poolMember{MCPClient: backend, url: "http://backend"})
+	}
+	return pool
+}
+
+func TestPoolConnectBelowMinHealthyDisconnects(t *testing.T) {
+	up := &poolBackend{}
+	down := &poolBackend{connectErr: errors.New("connection refused")}
+	pool := newTestPool(interfaces.LoadBalanceRoundRobin, 2, up, down)
+
+	if err := pool.Connect(context.Background(), mcp.Implementation{Name: "test", Version: "1.0.0"}); err == nil {
+		t.Fatal("Connect() error = nil with 1 of 2 required backends")
+	}
+	if state := pool.GetState(); state != interfaces.ClientStateFailed {
+		t.Errorf("GetState() = %s, want %s", state, interfaces.ClientStateFailed)
+	}
+	// 已连接的后端被断开，未连接的后端不受影响
+	if got := up.disconnects.Load(); got != 1 {
+		t.Errorf("connected backend disconnected %d times, want 1", got)
+	}
+	if got := down.disconnects.Load(); got != 0 {
+		t.Errorf("failed backend disconnected %d times, want 0", got)
+	}
+	if pool.healthyCount() != 0 {
+		t.Errorf("healthyCount() = %d after failed connect, want 0", pool.healthyCount())
+	}
+}
+
+// connectTestPool 连接给定后端组成的连接池
+func connectTestPool(t *testing.T, strategy string, minHealthy int, backends ...*poolBackend) *Pool {
+	t.Helper()
+
+	pool := newTestPool(strategy, minHealthy, backends...)
+	if err := pool.Connect(context.Background(), mcp.Implementation{Name: "test", Version: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	backends := []*poolBackend{{}, {}, {}}
+	pool := connectTestPool(t, interfaces.LoadBalanceRoundRobin, 1, backends...)
+
+	for i := 0; i < 9; i++ {
+		if _, err := pool.CallTool(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, backend := range backends {
+		if got := backend.calls.Load(); got != 3 {
+			t.Errorf("backend %d got %d calls, want 3", i, got)
+		}
+	}
+}
+
+func TestPoolLeastConnections(t *testing.T) {
+	busy, idle := &poolBackend{}, &poolBackend{}
+	pool := connectTestPool(t, interfaces.LoadBalanceLeastConnections, 1, busy, idle)
+
+	// busy 有一个进行中的调用，新调用全部分配给 idle
+	pool.members[0].inFlight.Store(1)
+	for i := 0; i < 5; i++ {
+		if _, err := pool.CallTool(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := busy.calls.Load(); got != 0 {
+		t.Errorf("busy backend got %d calls, want 0", got)
+	}
+	if got := idle.calls.Load(); got != 5 {
+		t.Errorf("idle backend got %d calls, want 5", got)
+	}
+
+	// 进行中的调用数相同时按轮询起点打散
+	pool.members[0].inFlight.Store(0)
+	for i := 0; i < 4; i++ {
+		if _, err := pool.CallTool(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := busy.calls.Load(); got != 2 {
+		t.Errorf("backend 0 got %d of 4 calls with equal load, want 2", got)
+	}
+}
+
+func TestPoolMinHealthy(t *testing.T) {
+	first, second := &poolBackend{}, &poolBackend{}
+	pool := connectTestPool(t, interfaces.LoadBalanceRoundRobin, 2, first, second)
+	if !pool.IsConnected() {
+		t.Fatal("IsConnected() = false with 2 of 2 healthy backends")
+	}
+
+	// 一个后端探测失败后可用数低于 minHealthy
+	pool.members[1].healthy.Store(false)
+	pool.evaluate()
+	if pool.IsConnected() {
+		t.Error("IsConnected() = true with 1 of 2 required backends")
+	}
+	if state := pool.GetState(); state != interfaces.ClientStateReconnecting {
+		t.Errorf("GetState() = %s, want %s", state, interfaces.ClientStateReconnecting)
+	}
+	// 不可用的后端不再分配调用
+	if _, err := pool.CallTool(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := second.calls.Load(); got != 0 {
+		t.Errorf("unhealthy backend got %d calls, want 0", got)
+	}
+
+	pool.members[1].healthy.Store(true)
+	pool.evaluate()
+	if !pool.IsConnected() {
+		t.Error("IsConnected() = false after the backend recovered")
+	}
+}
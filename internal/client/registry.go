@@ -0,0 +1,59 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// FactoryFunc 自定义传输类型的客户端构造函数
+type FactoryFunc func(name string, config interfaces.ServerConfig) (interfaces.MCPClient, error)
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[string]FactoryFunc)
+)
+
+// RegisterClientFactory 注册自定义传输类型的客户端构造函数，通常在 init() 中调用
+//
+// 注册的类型优先于内置类型。与 database/sql.Register 一致，
+// 构造函数为 nil 或重复注册同一类型时直接 panic。
+func RegisterClientFactory(transport string, factory func(name string, config interfaces.ServerConfig) (interfaces.MCPClient, error)) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if transport == "" {
+		panic("client: RegisterClientFactory transport is empty")
+	}
+	if factory == nil {
+		panic("client: RegisterClientFactory factory is nil for " + transport)
+	}
+	if _, exists := registry[transport]; exists {
+		panic(fmt.Sprintf("client: RegisterClientFactory called twice for %s", transport))
+	}
+	registry[transport] = factory
+}
+
+// RegisteredTransports 返回所有通过 RegisterClientFactory 注册的传输类型
+func RegisteredTransports() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	transports := make([]string, 0, len(registry))
+	for transport := range registry {
+		transports = append(transports, transport)
+	}
+	sort.Strings(transports)
+	return transports
+}
+
+// lookupClientFactory 查找已注册的客户端构造函数
+func lookupClientFactory(transport string) (FactoryFunc, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	factory, ok := registry[transport]
+	return factory, ok
+}
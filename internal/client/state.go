@@ -0,0 +1,83 @@
+package client
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// stateTransitions 允许的状态转换
+var stateTransitions = map[interfaces.ClientState][]interfaces.ClientState{
+	interfaces.ClientStateDisconnected: {interfaces.ClientStateConnecting},
+	interfaces.ClientStateConnecting:   {interfaces.ClientStateInitializing, interfaces.ClientStateFailed, interfaces.ClientStateDisconnected},
+	interfaces.ClientStateInitializing: {interfaces.ClientStateConnected, interfaces.ClientStateFailed, interfaces.ClientStateDisconnected},
+	interfaces.ClientStateConnected:    {interfaces.ClientStateReconnecting, interfaces.ClientStateFailed, interfaces.ClientStateDisconnected},
+	interfaces.ClientStateReconnecting: {interfaces.ClientStateConnecting, interfaces.ClientStateConnected, interfaces.ClientStateFailed, interfaces.ClientStateDisconnected},
+	interfaces.ClientStateFailed:       {interfaces.ClientStateConnecting, interfaces.ClientStateDisconnected},
+}
+
+// clientState 客户端连接状态机，只允许 stateTransitions 中定义的转换
+type clientState struct {
+	name     string
+	logTag   string
+	mutex    sync.RWMutex
+	state    interfaces.ClientState
+	onChange func(interfaces.StateChange)
+}
+
+// newClientState 创建初始为 Disconnected 的状态机，onChange 在每次状态变化后调用
+func newClientState(name, logTag string, onChange func(interfaces.StateChange)) *clientState {
+	return &clientState{
+		name:     name,
+		logTag:   logTag,
+		state:    interfaces.ClientStateDisconnected,
+		onChange: onChange,
+	}
+}
+
+// Get 获取当前状态
+func (s *clientState) Get() interfaces.ClientState {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.state
+}
+
+// Is 当前状态是否为 state
+func (s *clientState) Is(state interfaces.ClientState) bool {
+	return s.Get() == state
+}
+
+// Transition 转换到 to 状态，转换到当前状态时不做任何事，非法转换返回错误
+func (s *clientState) Transition(to interfaces.ClientState) error {
+	s.mutex.Lock()
+	from := s.state
+	if from == to {
+		s.mutex.Unlock()
+		return nil
+	}
+	if !validTransition(from, to) {
+		s.mutex.Unlock()
+		return fmt.Errorf("invalid state transition from %s to %s", from, to)
+	}
+	s.state = to
+	s.mutex.Unlock()
+
+	slog.Info("Connection state changed", "server", s.logTag, "from", from, "to", to)
+	if s.onChange != nil {
+		s.onChange(interfaces.StateChange{Client: s.name, From: from, To: to, Time: time.Now()})
+	}
+	return nil
+}
+
+// validTransition 检查状态转换是否合法
+func validTransition(from, to interfaces.ClientState) bool {
+	for _, allowed := range stateTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+func TestClientStateTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []interfaces.ClientState
+		to      interfaces.ClientState
+		wantErr bool
+	}{
+		{name: "connect", path: nil, to: interfaces.ClientStateConnecting},
+		{name: "initialize", path: []interfaces.ClientState{interfaces.ClientStateConnecting}, to: interfaces.ClientStateInitializing},
+		{name: "connected", path: []interfaces.ClientState{interfaces.ClientStateConnecting, interfaces.ClientStateInitializing}, to: interfaces.ClientStateConnected},
+		{name: "reconnect", path: []interfaces.ClientState{interfaces.ClientStateConnecting, interfaces.ClientStateInitializing, interfaces.ClientStateConnected}, to: interfaces.ClientStateReconnecting},
+		{name: "retry after failure", path: []interfaces.ClientState{interfaces.ClientStateConnecting, interfaces.ClientStateFailed}, to: interfaces.ClientStateConnecting},
+		{name: "same state is a no-op", path: nil, to: interfaces.ClientStateDisconnected},
+		{name: "skip connecting", path: nil, to: interfaces.ClientStateConnected, wantErr: true},
+		{name: "connected back to initializing", path: []interfaces.ClientState{interfaces.ClientStateConnecting, interfaces.ClientStateInitializing, interfaces.ClientStateConnected}, to: interfaces.ClientStateInitializing, wantErr: true},
+		{name: "failed to connected", path: []interfaces.ClientState{interfaces.ClientStateConnecting, interfaces.ClientStateFailed}, to: interfaces.ClientStateConnected, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newClientState("test", "test", nil)
+			for _, state := range tt.path {
+				if err := s.Transition(state); err != nil {
+					t.Fatalf("Transition(%s) error = %v", state, err)
+				}
+			}
+			from := s.Get()
+
+			err := s.Transition(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transition(%s) from %s error = %v, wantErr %v", tt.to, from, err, tt.wantErr)
+			}
+			// 非法转换不改变当前状态
+			want := tt.to
+			if tt.wantErr {
+				want = from
+			}
+			if !s.Is(want) {
+				t.Errorf("state = %s, want %s", s.Get(), want)
+			}
+		})
+	}
+}
+
+func TestClientStateOnChange(t *testing.T) {
+	var changes []interfaces.StateChange
+	s := newClientState("kb", "kb", func(change interfaces.StateChange) {
+		changes = append(changes, change)
+	})
+
+	if err := s.Transition(interfaces.ClientStateConnecting); err != nil {
+		t.Fatal(err)
+	}
+	// 重复转换和非法转换不触发回调
+	if err := s.Transition(interfaces.ClientStateConnecting); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Transition(interfaces.ClientStateReconnecting); err == nil {
+		t.Fatal("Transition(reconnecting) from connecting error = nil, want error")
+	}
+	if err := s.Transition(interfaces.ClientStateFailed); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("onChange called %d times, want 2: %+v", len(changes), changes)
+	}
+	want := []struct{ from, to interfaces.ClientState }{
+		{interfaces.ClientStateDisconnected, interfaces.ClientStateConnecting},
+		{interfaces.ClientStateConnecting, interfaces.ClientStateFailed},
+	}
+	for i, change := range changes {
+		if change.Client != "kb" || change.From != want[i].from || change.To != want[i].to {
+			t.Errorf("change %d = %+v, want kb %s -> %s", i, change, want[i].from, want[i].to)
+		}
+		if change.Time.IsZero() {
+			t.Errorf("change %d has zero time", i)
+		}
+	}
+}
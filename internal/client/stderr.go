@@ -0,0 +1,44 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// defaultStderrLogLevel 未配置 stderrLogLevel 时子进程 stderr 的日志级别
+const defaultStderrLogLevel = slog.LevelDebug
+
+// ParseStderrLogLevel 解析子进程 stderr 的日志级别，空字符串返回默认级别
+func ParseStderrLogLevel(level string) (slog.Level, error) {
+	if level == "" {
+		return defaultStderrLogLevel, nil
+	}
+	var parsed slog.Level
+	err := parsed.UnmarshalText([]byte(level))
+	return parsed, err
+}
+
+// forwardStderr 逐行读取子进程的 stderr 并写入代理日志，直到管道关闭
+//
+// 子进程的 stderr 是管道，不读取时写满缓冲区会阻塞子进程，因此始终读取，
+// 只是默认以 debug 级别记录。
+func forwardStderr(logTag string, stderr io.Reader, level slog.Level) {
+	reader := bufio.NewReader(stderr)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			slog.Log(context.Background(), level, line, "server", logTag, "source", "stderr")
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) {
+				slog.Debug("Stopped reading child process stderr", "server", logTag, "error", err)
+			}
+			return
+		}
+	}
+}
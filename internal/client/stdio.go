@@ -2,85 +2,284 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/tracing"
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// traceArgKey 注入追踪上下文的参数名
+const traceArgKey = "_trace"
+
 // StdioClient stdio 客户端实现
 type StdioClient struct {
-	name      string
-	config    interfaces.ServerConfig
-	client    *client.Client
-	connected bool
+	name        string
+	logTag      string
+	config      interfaces.ServerConfig
+	state       *clientState
+	health      *grpcHealthChecker
+	serverInfo  mcp.Implementation
+	clientInfo  mcp.Implementation
+	stopRestart context.CancelFunc
+	options     options
+
+	// mutex 保护 client 和 process，二者会被重启协程替换、被 Disconnect 清空
+	mutex   sync.RWMutex
+	client  *client.Client
+	process *stdioProcess
 }
 
 // NewStdioClient 创建新的 stdio 客户端
-func NewStdioClient(name string, config interfaces.ServerConfig) (interfaces.MCPClient, error) {
+func NewStdioClient(name string, config interfaces.ServerConfig, opts ...Option) (interfaces.MCPClient, error) {
 	if config.Command == "" {
 		return nil, fmt.Errorf("command is required for stdio client")
 	}
 
+	o := newOptions(opts)
 	return &StdioClient{
-		name:   name,
-		config: config,
+		name:    name,
+		logTag:  interfaces.ServerLogTag(name, config),
+		config:  config,
+		health:  newGRPCHealthChecker(interfaces.ServerLogTag(name, config), config.GRPCHealthTarget),
+		options: o,
+		state:   newClientState(name, interfaces.ServerLogTag(name, config), o.onStateChange),
 	}, nil
 }
 
 // Connect 连接到 MCP 服务器
 func (c *StdioClient) Connect(ctx context.Context, clientInfo mcp.Implementation) error {
-	if c.connected {
+	if c.state.Is(interfaces.ClientStateConnected) {
 		return nil
 	}
+	if err := c.state.Transition(interfaces.ClientStateConnecting); err != nil {
+		return err
+	}
 
-	// 构造环境变量
-	envs := make([]string, 0, len(c.config.Env))
-	for key, value := range c.config.Env {
-		envs = append(envs, fmt.Sprintf("%s=%s", key, value))
+	start := time.Now()
+	err := c.connect(ctx, clientInfo)
+	c.options.recordConnect(c.name, c.GetType(), start, err)
+	if err != nil {
+		_ = c.state.Transition(interfaces.ClientStateFailed)
+		return err
+	}
+	return c.state.Transition(interfaces.ClientStateConnected)
+}
+
+// connect 启动子进程并完成 Initialize 握手
+func (c *StdioClient) connect(ctx context.Context, clientInfo mcp.Implementation) error {
+	// gRPC 健康检查独立于 MCP 会话，先行启动
+	c.health.Start(ctx)
+
+	// 子进程意外退出后的重启沿用连接上下文，断开连接时取消
+	ctx, c.stopRestart = context.WithCancel(ctx)
+	c.clientInfo = clientInfo
+	if err := c.startProcess(ctx, clientInfo); err != nil {
+		c.stopRestart()
+		return err
 	}
 
-	// 创建 stdio 客户端
-	mcpClient, err := client.NewStdioMCPClient(c.config.Command, envs, c.config.Args...)
+	// 配置了保活间隔时启动定期 ping
+	if interval := c.keepaliveInterval(); interval > 0 {
+		go c.startPingTask(ctx, interval)
+	}
+
+	return nil
+}
+
+// startProcess 启动子进程、完成 Initialize 握手并监视子进程退出
+func (c *StdioClient) startProcess(ctx context.Context, clientInfo mcp.Implementation) error {
+	process, stdioTransport, err := startStdioProcess(c.config.Command, c.config.Args, c.environ(), c.config.WorkDir)
 	if err != nil {
 		return fmt.Errorf("failed to create stdio client: %w", err)
 	}
+	mcpClient := client.NewClient(stdioTransport)
+	if err := mcpClient.Start(ctx); err != nil {
+		c.stopProcess(mcpClient, process)
+		return fmt.Errorf("failed to start stdio client: %w", err)
+	}
 
-	c.client = mcpClient
-	c.connected = true
-
-	// 初始化请求
-	initRequest := mcp.InitializeRequest{}
-	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
-	initRequest.Params.ClientInfo = clientInfo
-	initRequest.Params.Capabilities = mcp.ClientCapabilities{
-		Experimental: make(map[string]interface{}),
-		Roots:        nil,
-		Sampling:     nil,
+	// 转发子进程的 stderr 到代理日志，配置已在加载时校验
+	if stderr, ok := client.GetStderr(mcpClient); ok {
+		level, _ := ParseStderrLogLevel(c.config.StderrLogLevel)
+		go forwardStderr(c.logTag, stderr, level)
 	}
+	_ = c.state.Transition(interfaces.ClientStateInitializing)
 
-	_, err = c.client.Initialize(ctx, initRequest)
+	// 初始化请求，子进程在握手期间退出时立即失败
+	initCtx, cancel := process.bind(ctx)
+	defer cancel()
+	initRequest := newInitializeRequest(c.config, clientInfo)
+	initResult, err := mcpClient.Initialize(initCtx, initRequest)
 	if err != nil {
-		c.connected = false
-		return fmt.Errorf("failed to initialize client: %w", err)
+		// 关闭已启动的子进程，避免泄漏
+		c.stopProcess(mcpClient, process)
+		return fmt.Errorf("failed to initialize client: %w", processError(initCtx, err))
+	}
+	logInitializeResult(c.logTag, initRequest, initResult)
+	c.serverInfo = initResult.ServerInfo
+
+	// 重启期间 Disconnect 已取消上下文时不再接管新的子进程
+	c.mutex.Lock()
+	if err := ctx.Err(); err != nil {
+		c.mutex.Unlock()
+		c.stopProcess(mcpClient, process)
+		return err
 	}
+	c.client = mcpClient
+	c.process = process
+	c.mutex.Unlock()
+
+	slog.Info("Successfully initialized stdio MCP client", "server", c.logTag)
 
-	log.Printf("<%s> Successfully initialized stdio MCP client", c.name)
+	go c.watchProcess(ctx, process)
 	return nil
 }
 
+// environ 构造子进程的环境变量，默认继承代理进程的环境变量，env 中的同名变量覆盖继承的值
+func (c *StdioClient) environ() []string {
+	// 非 nil 的空切片表示不继承任何环境变量
+	envs := make([]string, 0, len(c.config.Env))
+	if c.config.InheritEnv == nil || *c.config.InheritEnv {
+		envs = append(envs, os.Environ()...)
+	}
+	for key, value := range c.config.Env {
+		envs = append(envs, fmt.Sprintf("%s=%s", key, value))
+	}
+	return envs
+}
+
+// stopProcess 关闭客户端并等待子进程退出，不触发自动重启
+func (c *StdioClient) stopProcess(mcpClient *client.Client, process *stdioProcess) {
+	process.stopping.Store(true)
+	_ = mcpClient.Close()
+	process.wait(stdioStopTimeout)
+}
+
+// watchProcess 等待子进程退出，意外退出时关闭失效的客户端、标记连接失败并按配置自动重启
+func (c *StdioClient) watchProcess(ctx context.Context, process *stdioProcess) {
+	<-process.exited.Done()
+	if process.stopping.Load() {
+		return
+	}
+
+	// Disconnect 可能已同时取走该子进程，此时由 Disconnect 负责清理
+	c.mutex.Lock()
+	if c.process != process {
+		c.mutex.Unlock()
+		return
+	}
+	deadClient := c.client
+	c.client = nil
+	c.process = nil
+	c.mutex.Unlock()
+	_ = deadClient.Close()
+
+	slog.Error("Stdio process exited unexpectedly", "server", c.logTag, "exit_code", process.ExitCode(), "error", process.err)
+	if c.config.AutoRestart == nil || !*c.config.AutoRestart {
+		_ = c.state.Transition(interfaces.ClientStateFailed)
+		return
+	}
+	_ = c.state.Transition(interfaces.ClientStateReconnecting)
+	c.restart(ctx)
+}
+
+// restart 按指数退避重启子进程，连续失败达到 maxRestarts 次后放弃
+//
+// 重启次数按每次意外退出分别计算，重启成功后下一次退出重新从 0 开始。
+func (c *StdioClient) restart(ctx context.Context) {
+	backoff := stdioRestartMinBackoff
+	for restarts := 0; ; restarts++ {
+		if c.config.MaxRestarts > 0 && restarts >= c.config.MaxRestarts {
+			slog.Error("Stdio process reached the restart limit, giving up", "server", c.logTag, "max_restarts", c.config.MaxRestarts)
+			_ = c.state.Transition(interfaces.ClientStateFailed)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		slog.Warn("Restarting stdio process", "server", c.logTag, "attempt", restarts+1, "backoff", backoff)
+		_ = c.state.Transition(interfaces.ClientStateConnecting)
+		start := time.Now()
+		err := c.startProcess(ctx, c.clientInfo)
+		c.options.recordConnect(c.name, c.GetType(), start, err)
+		if err == nil {
+			_ = c.state.Transition(interfaces.ClientStateConnected)
+			return
+		}
+
+		slog.Error("Failed to restart stdio process", "server", c.logTag, "attempt", restarts+1, "error", err)
+		_ = c.state.Transition(interfaces.ClientStateFailed)
+		backoff = min(backoff*2, stdioRestartMaxBackoff)
+	}
+}
+
+// processError 请求因子进程退出而取消时返回更明确的错误
+func processError(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), errStdioProcessExited) {
+		return errStdioProcessExited
+	}
+	return err
+}
+
+// keepaliveInterval 解析保活间隔，未配置时返回 0
+func (c *StdioClient) keepaliveInterval() time.Duration {
+	if c.config.StdioKeepaliveInterval == "" {
+		return 0
+	}
+	// 配置已在加载时校验
+	interval, _ := time.ParseDuration(c.config.StdioKeepaliveInterval)
+	return interval
+}
+
+// startPingTask 启动定时 ping 任务，防止子进程因空闲而退出
+func (c *StdioClient) startPingTask(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Context done, stopping ping", "server", c.logTag)
+			return
+		case <-ticker.C:
+			if mcpClient, _, err := c.session(); err == nil {
+				_ = mcpClient.Ping(ctx)
+			}
+		}
+	}
+}
+
 // Disconnect 断开连接
 func (c *StdioClient) Disconnect() error {
-	if !c.connected || c.client == nil {
-		return nil
+	c.health.Stop()
+
+	if c.stopRestart != nil {
+		c.stopRestart()
+		c.stopRestart = nil
 	}
 
-	err := c.client.Close()
-	c.connected = false
+	c.mutex.Lock()
+	mcpClient, process := c.client, c.process
 	c.client = nil
-	return err
+	c.process = nil
+	c.mutex.Unlock()
+
+	if mcpClient != nil {
+		c.stopProcess(mcpClient, process)
+	}
+	_ = c.state.Transition(interfaces.ClientStateDisconnected)
+	return nil
 }
 
 // GetName 获取客户端名称
@@ -95,76 +294,134 @@ func (c *StdioClient) GetType() string {
 
 // IsConnected 检查连接状态
 func (c *StdioClient) IsConnected() bool {
-	return c.connected
+	return c.state.Is(interfaces.ClientStateConnected) && c.health.Healthy()
+}
+
+// GetState 获取连接状态
+func (c *StdioClient) GetState() interfaces.ClientState {
+	return c.state.Get()
+}
+
+// GetServerInfo 获取上游在初始化时返回的服务器名称和版本
+func (c *StdioClient) GetServerInfo() mcp.Implementation {
+	return c.serverInfo
 }
 
 // NeedsPing 是否需要定期 ping
 func (c *StdioClient) NeedsPing() bool {
-	return false // stdio 客户端不需要 ping
+	return c.keepaliveInterval() > 0 // 仅在配置了保活间隔时需要 ping
 }
 
 // Ping 发送 ping 消息
 func (c *StdioClient) Ping(ctx context.Context) error {
-	if !c.connected || c.client == nil {
-		return fmt.Errorf("client not connected")
+	mcpClient, _, err := c.session()
+	if err != nil {
+		return err
+	}
+	return mcpClient.Ping(ctx)
+}
+
+// session 获取当前的客户端和子进程，未连接时返回错误
+func (c *StdioClient) session() (*client.Client, *stdioProcess, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if !c.state.Is(interfaces.ClientStateConnected) || c.client == nil {
+		return nil, nil, fmt.Errorf("client not connected")
 	}
-	return c.client.Ping(ctx)
+	return c.client, c.process, nil
 }
 
 // MCP 协议方法实现
 
 func (c *StdioClient) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, _, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.Initialize(ctx, request)
+	return mcpClient.Initialize(ctx, request)
 }
 
 func (c *StdioClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, _, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ListTools(ctx, request)
+	return mcpClient.ListTools(ctx, request)
 }
 
 func (c *StdioClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, process, err := c.session()
+	if err != nil {
+		return nil, err
+	}
+	if c.config.TracingEnabled != nil && *c.config.TracingEnabled {
+		request = injectTraceContext(ctx, request)
+	}
+
+	// 子进程退出时结束等待中的调用
+	ctx, cancel := process.bind(ctx)
+	defer cancel()
+	result, err := mcpClient.CallTool(ctx, request)
+	if err != nil {
+		return nil, processError(ctx, err)
+	}
+	return result, nil
+}
+
+// injectTraceContext 将追踪上下文以非标准的 _trace 参数传给子进程，不支持追踪的子进程可直接忽略
+func injectTraceContext(ctx context.Context, request mcp.CallToolRequest) mcp.CallToolRequest {
+	traceContext, ok := tracing.FromContext(ctx)
+	if !ok {
+		return request
+	}
+
+	// 复制参数，避免修改调用方的数据
+	args := make(map[string]any, len(request.GetArguments())+1)
+	for key, value := range request.GetArguments() {
+		args[key] = value
 	}
-	return c.client.CallTool(ctx, request)
+	args[traceArgKey] = traceContext
+	request.Params.Arguments = args
+	return request
 }
 
 func (c *StdioClient) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, _, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ListPrompts(ctx, request)
+	return mcpClient.ListPrompts(ctx, request)
 }
 
 func (c *StdioClient) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, _, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.GetPrompt(ctx, request)
+	return mcpClient.GetPrompt(ctx, request)
 }
 
 func (c *StdioClient) ListResources(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, _, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ListResources(ctx, request)
+	return mcpClient.ListResources(ctx, request)
 }
 
 func (c *StdioClient) ReadResource(ctx context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, _, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ReadResource(ctx, request)
+	return mcpClient.ReadResource(ctx, request)
 }
 
 func (c *StdioClient) ListResourceTemplates(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, _, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ListResourceTemplates(ctx, request)
+	return mcpClient.ListResourceTemplates(ctx, request)
 }
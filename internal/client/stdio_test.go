@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestStdioClientConnectMissingBinary(t *testing.T) {
+	const command = "mcp-proxy-test-missing-binary"
+	stdioClient, err := NewStdioClient("missing", interfaces.ServerConfig{Transport: interfaces.ClientTypeStdio, Command: command})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stdioClient.Connect(context.Background(), mcp.Implementation{Name: "test", Version: "1.0.0"})
+	if err == nil {
+		t.Fatal("Connect() error = nil, want executable not found")
+	}
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("Connect() error = %v, want exec.ErrNotFound", err)
+	}
+	if want := "exec: \"" + command + "\": executable file not found"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Connect() error = %q, want it to contain %q", err, want)
+	}
+
+	if stdioClient.IsConnected() {
+		t.Error("IsConnected() = true after failed connect")
+	}
+	if state := stdioClient.GetState(); state != interfaces.ClientStateFailed {
+		t.Errorf("GetState() = %s, want %s", state, interfaces.ClientStateFailed)
+	}
+	// 未连接时调用返回错误而不是空指针 panic
+	if _, err := stdioClient.ListTools(context.Background(), mcp.ListToolsRequest{}); err == nil {
+		t.Error("ListTools() error = nil on a failed client")
+	}
+	if err := stdioClient.Disconnect(); err != nil {
+		t.Errorf("Disconnect() error = %v", err)
+	}
+}
+
+func TestNewStdioClientRequiresCommand(t *testing.T) {
+	if _, err := NewStdioClient("empty", interfaces.ServerConfig{Transport: interfaces.ClientTypeStdio}); err == nil {
+		t.Error("NewStdioClient() error = nil, want command is required")
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+const (
+	// stdioStopTimeout 断开连接时等待子进程退出的时长，超时后强制结束
+	stdioStopTimeout = 5 * time.Second
+	// stdioRestartMinBackoff 子进程意外退出后重启的初始间隔
+	stdioRestartMinBackoff = time.Second
+	// stdioRestartMaxBackoff 子进程意外退出后重启的最大间隔
+	stdioRestartMaxBackoff = time.Minute
+)
+
+// errStdioProcessExited 子进程退出时进行中的请求返回的错误
+var errStdioProcessExited = errors.New("stdio process exited")
+
+// stdioProcess 由代理启动并等待的 stdio 子进程
+//
+// mcp-go 的 stdio 传输自行等待子进程且不暴露退出状态，
+// 因此由代理启动子进程，再通过 transport.NewIO 交给 mcp-go 客户端读写。
+type stdioProcess struct {
+	cmd      *exec.Cmd
+	exited   context.Context
+	stopping atomic.Bool
+	err      error
+}
+
+// startStdioProcess 在 dir 目录下启动子进程并返回与其标准输入输出相连的传输，env 为子进程的完整环境变量
+func startStdioProcess(command string, args []string, env []string, dir string) (*stdioProcess, *transport.Stdio, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = env
+	cmd.Dir = dir
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	// stdout 和 stderr 使用自行创建的管道：exec 的 StdoutPipe 会在 Wait 时关闭读端，
+	// 子进程退出前写出的最后几行（通常就是崩溃原因）可能来不及读取
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		_ = stdin.Close()
+		return nil, nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		_ = stdin.Close()
+		_, _ = stdoutReader.Close(), stdoutWriter.Close()
+		return nil, nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+
+	err = cmd.Start()
+	// 子进程已持有写端，关闭父进程的副本，子进程退出后读端才能读到 EOF
+	_, _ = stdoutWriter.Close(), stderrWriter.Close()
+	if err != nil {
+		_ = stdin.Close()
+		_, _ = stdoutReader.Close(), stderrReader.Close()
+		return nil, nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	exited, markExited := context.WithCancel(context.Background())
+	process := &stdioProcess{cmd: cmd, exited: exited}
+	go func() {
+		process.err = cmd.Wait()
+		markExited()
+	}()
+
+	return process, transport.NewIO(closeOnEOF{stdoutReader}, stdin, stderrReader), nil
+}
+
+// ExitCode 获取子进程的退出码，被信号结束时为 -1
+func (p *stdioProcess) ExitCode() int {
+	return p.cmd.ProcessState.ExitCode()
+}
+
+// bind 返回在子进程退出时以 errStdioProcessExited 取消的上下文
+//
+// mcp-go 的 stdio 传输在子进程退出后不会结束等待中的请求，需要由调用方取消。
+func (p *stdioProcess) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(p.exited, func() {
+		cancel(errStdioProcessExited)
+	})
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// wait 等待子进程退出，超过 timeout 后强制结束
+func (p *stdioProcess) wait(timeout time.Duration) {
+	select {
+	case <-p.exited.Done():
+		return
+	case <-time.After(timeout):
+	}
+	_ = p.cmd.Process.Kill()
+	<-p.exited.Done()
+}
+
+// closeOnEOF 读到 EOF 后关闭文件，mcp-go 关闭传输时不会关闭 stdout
+type closeOnEOF struct {
+	*os.File
+}
+
+// Read 读取数据，读到 EOF 时关闭文件
+func (f closeOnEOF) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	if errors.Is(err, io.EOF) {
+		_ = f.File.Close()
+	}
+	return n, err
+}
@@ -2,8 +2,10 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
@@ -14,34 +16,89 @@ import (
 
 // StreamableClient Streamable HTTP 客户端实现
 type StreamableClient struct {
-	name      string
-	config    interfaces.ServerConfig
-	client    *client.Client
-	connected bool
+	name         string
+	logTag       string
+	config       interfaces.ServerConfig
+	state        *clientState
+	health       *grpcHealthChecker
+	oauth2       *oauth2TokenManager
+	serverInfo   mcp.Implementation
+	options      options
+	pingInterval time.Duration
+	tlsConfig    *tls.Config
+
+	// mutex 保护 client，Disconnect 会在协议方法执行期间将其清空
+	mutex  sync.RWMutex
+	client *client.Client
 }
 
 // NewStreamableClient 创建新的 Streamable HTTP 客户端
-func NewStreamableClient(name string, config interfaces.ServerConfig) (interfaces.MCPClient, error) {
+func NewStreamableClient(name string, config interfaces.ServerConfig, opts ...Option) (interfaces.MCPClient, error) {
 	if config.URL == "" {
 		return nil, fmt.Errorf("url is required for streamable client")
 	}
 
+	tlsConfig, err := newClientTLSConfig(interfaces.ServerLogTag(name, config), config.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &StreamableClient{
-		name:   name,
-		config: config,
+		name:         name,
+		logTag:       interfaces.ServerLogTag(name, config),
+		config:       config,
+		health:       newGRPCHealthChecker(interfaces.ServerLogTag(name, config), config.GRPCHealthTarget),
+		oauth2:       newOAuth2TokenManager(interfaces.ServerLogTag(name, config), config),
+		options:      o,
+		state:        newClientState(name, interfaces.ServerLogTag(name, config), o.onStateChange),
+		pingInterval: pingInterval(config),
+		tlsConfig:    tlsConfig,
 	}, nil
 }
 
 // Connect 连接到 MCP 服务器
 func (c *StreamableClient) Connect(ctx context.Context, clientInfo mcp.Implementation) error {
-	if c.connected {
+	if c.state.Is(interfaces.ClientStateConnected) {
 		return nil
 	}
+	if err := c.state.Transition(interfaces.ClientStateConnecting); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := c.connect(ctx, clientInfo)
+	c.options.recordConnect(c.name, c.GetType(), start, err)
+	if err != nil {
+		// 关闭已创建的传输并停止后台任务，避免每次重试泄漏
+		_ = c.teardown()
+		_ = c.state.Transition(interfaces.ClientStateFailed)
+		return err
+	}
+	return c.state.Transition(interfaces.ClientStateConnected)
+}
+
+// connect 启动传输并完成 Initialize 握手
+func (c *StreamableClient) connect(ctx context.Context, clientInfo mcp.Implementation) error {
+	// gRPC 健康检查独立于 MCP 会话，先行启动
+	c.health.Start(ctx)
+
+	// OAuth2 令牌在建立连接前获取，之后由后台任务刷新
+	if err := c.oauth2.Start(ctx); err != nil {
+		return err
+	}
 
 	// 创建 Streamable HTTP 客户端选项
 	var options []transport.StreamableHTTPCOption
-	if len(c.config.Headers) > 0 {
-		options = append(options, transport.WithHTTPHeaders(c.config.Headers))
+	if c.oauth2 != nil {
+		options = append(options, transport.WithHTTPHeaderFunc(c.oauth2.Headers))
+	}
+	if headers := requestHeaders(c.config); len(headers) > 0 {
+		options = append(options, transport.WithHTTPHeaders(headers))
+	}
+	// 自定义客户端必须在超时选项之前设置，超时作用于当前客户端
+	if httpClient := newHTTPClient(c.config, c.tlsConfig); httpClient != nil {
+		options = append(options, transport.WithHTTPBasicClient(httpClient))
 	}
 	if c.config.Timeout > 0 {
 		options = append(options, transport.WithHTTPTimeout(c.config.Timeout))
@@ -53,53 +110,50 @@ func (c *StreamableClient) Connect(ctx context.Context, clientInfo mcp.Implement
 		return fmt.Errorf("failed to create streamable client: %w", err)
 	}
 
+	c.mutex.Lock()
 	c.client = mcpClient
+	c.mutex.Unlock()
 
 	// 启动客户端
-	err = c.client.Start(ctx)
+	err = mcpClient.Start(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to start streamable client: %w", err)
 	}
 
-	c.connected = true
+	_ = c.state.Transition(interfaces.ClientStateInitializing)
 
 	// 初始化请求
-	initRequest := mcp.InitializeRequest{}
-	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
-	initRequest.Params.ClientInfo = clientInfo
-	initRequest.Params.Capabilities = mcp.ClientCapabilities{
-		Experimental: make(map[string]interface{}),
-		Roots:        nil,
-		Sampling:     nil,
-	}
-
-	_, err = c.client.Initialize(ctx, initRequest)
+	initRequest := newInitializeRequest(c.config, clientInfo)
+	initResult, err := mcpClient.Initialize(ctx, initRequest)
 	if err != nil {
-		c.connected = false
 		return fmt.Errorf("failed to initialize client: %w", err)
 	}
+	logInitializeResult(c.logTag, initRequest, initResult)
+	c.serverInfo = initResult.ServerInfo
 
-	log.Printf("<%s> Successfully initialized streamable MCP client", c.name)
+	slog.Info("Successfully initialized streamable MCP client", "server", c.logTag)
 
 	// 启动定期 ping
-	go c.startPingTask(ctx)
+	if c.pingInterval > 0 {
+		go c.startPingTask(ctx, c.pingInterval)
+	}
 
 	return nil
 }
 
 // startPingTask 启动定时 ping 任务，保持连接活跃
-func (c *StreamableClient) startPingTask(ctx context.Context) {
-	ticker := time.NewTicker(30 * time.Second)
+func (c *StreamableClient) startPingTask(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("<%s> Context done, stopping ping", c.name)
+			slog.Info("Context done, stopping ping", "server", c.logTag)
 			return
 		case <-ticker.C:
-			if c.connected && c.client != nil {
-				_ = c.client.Ping(ctx)
+			if mcpClient, err := c.session(); err == nil {
+				_ = mcpClient.Ping(ctx)
 			}
 		}
 	}
@@ -107,14 +161,25 @@ func (c *StreamableClient) startPingTask(ctx context.Context) {
 
 // Disconnect 断开连接
 func (c *StreamableClient) Disconnect() error {
-	if !c.connected || c.client == nil {
-		return nil
-	}
+	err := c.teardown()
+	_ = c.state.Transition(interfaces.ClientStateDisconnected)
+	return err
+}
 
-	err := c.client.Close()
-	c.connected = false
+// teardown 停止健康检查和令牌刷新，关闭并清空当前传输
+func (c *StreamableClient) teardown() error {
+	c.health.Stop()
+	c.oauth2.Stop()
+
+	c.mutex.Lock()
+	mcpClient := c.client
 	c.client = nil
-	return err
+	c.mutex.Unlock()
+
+	if mcpClient == nil {
+		return nil
+	}
+	return mcpClient.Close()
 }
 
 // GetName 获取客户端名称
@@ -129,76 +194,106 @@ func (c *StreamableClient) GetType() string {
 
 // IsConnected 检查连接状态
 func (c *StreamableClient) IsConnected() bool {
-	return c.connected
+	return c.state.Is(interfaces.ClientStateConnected) && c.health.Healthy() && c.oauth2.Healthy()
+}
+
+// GetState 获取连接状态
+func (c *StreamableClient) GetState() interfaces.ClientState {
+	return c.state.Get()
+}
+
+// GetServerInfo 获取上游在初始化时返回的服务器名称和版本
+func (c *StreamableClient) GetServerInfo() mcp.Implementation {
+	return c.serverInfo
 }
 
 // NeedsPing 是否需要定期 ping
 func (c *StreamableClient) NeedsPing() bool {
-	return true // Streamable 客户端需要 ping
+	return c.pingInterval > 0 // 配置 pingInterval 为 0 时不 ping
 }
 
 // Ping 发送 ping 消息
 func (c *StreamableClient) Ping(ctx context.Context) error {
-	if !c.connected || c.client == nil {
-		return fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return err
 	}
-	return c.client.Ping(ctx)
+	return mcpClient.Ping(ctx)
+}
+
+// session 获取当前的客户端，未连接时返回错误
+func (c *StreamableClient) session() (*client.Client, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if !c.state.Is(interfaces.ClientStateConnected) || c.client == nil {
+		return nil, fmt.Errorf("client not connected")
+	}
+	return c.client, nil
 }
 
 // MCP 协议方法实现
 
 func (c *StreamableClient) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.Initialize(ctx, request)
+	return mcpClient.Initialize(ctx, request)
 }
 
 func (c *StreamableClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ListTools(ctx, request)
+	return mcpClient.ListTools(ctx, request)
 }
 
 func (c *StreamableClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.CallTool(ctx, request)
+	return mcpClient.CallTool(ctx, request)
 }
 
 func (c *StreamableClient) ListPrompts(ctx context.Context, request mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ListPrompts(ctx, request)
+	return mcpClient.ListPrompts(ctx, request)
 }
 
 func (c *StreamableClient) GetPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.GetPrompt(ctx, request)
+	return mcpClient.GetPrompt(ctx, request)
 }
 
 func (c *StreamableClient) ListResources(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ListResources(ctx, request)
+	return mcpClient.ListResources(ctx, request)
 }
 
 func (c *StreamableClient) ReadResource(ctx context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ReadResource(ctx, request)
+	return mcpClient.ReadResource(ctx, request)
 }
 
 func (c *StreamableClient) ListResourceTemplates(ctx context.Context, request mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
-	if !c.connected || c.client == nil {
-		return nil, fmt.Errorf("client not connected")
+	mcpClient, err := c.session()
+	if err != nil {
+		return nil, err
 	}
-	return c.client.ListResourceTemplates(ctx, request)
+	return mcpClient.ListResourceTemplates(ctx, request)
 }
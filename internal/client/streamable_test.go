@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestStreamableClientConnectFailureReleasesTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	mcpClient, err := NewStreamableClient("broken", interfaces.ServerConfig{Transport: interfaces.ClientTypeStreamable, URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mcpClient.Connect(context.Background(), mcp.Implementation{Name: "test", Version: "1.0.0"}); err == nil {
+		t.Fatal("Connect() error = nil, want upstream failure")
+	}
+
+	c := mcpClient.(*StreamableClient)
+	if c.client != nil {
+		t.Error("client retained after failed connect, transport leaks on retry")
+	}
+	if state := c.GetState(); state != interfaces.ClientStateFailed {
+		t.Errorf("GetState() = %s, want %s", state, interfaces.ClientStateFailed)
+	}
+	if _, err := c.ListTools(context.Background(), mcp.ListToolsRequest{}); err == nil {
+		t.Error("ListTools() error = nil on a failed client")
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// normalizeKeys 将配置中 snake_case 等写法的字段名统一为结构体的 camelCase 字段名
+//
+// 只改写结构体字段对应的键，env、headers 等用户自定义映射的键保持原样。
+func normalizeKeys(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	normalized := normalizeValue(value, reflect.TypeOf(interfaces.Config{}))
+	return json.Marshal(normalized)
+}
+
+// normalizeValue 按目标类型递归改写字段名
+func normalizeValue(value interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := make(map[string]reflect.StructField, t.NumField())
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if name := jsonFieldName(field); name != "" {
+					fields[foldKey(name)] = field
+				}
+			}
+
+			result := make(map[string]interface{}, len(v))
+			for key, item := range v {
+				field, ok := fields[foldKey(key)]
+				if !ok {
+					// 未知字段原样保留，交由 Schema 校验报告
+					result[key] = item
+					continue
+				}
+				result[jsonFieldName(field)] = normalizeValue(item, field.Type)
+			}
+			return result
+		case reflect.Map:
+			result := make(map[string]interface{}, len(v))
+			for key, item := range v {
+				result[key] = normalizeValue(item, t.Elem())
+			}
+			return result
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range v {
+				v[i] = normalizeValue(item, t.Elem())
+			}
+		}
+	}
+	return value
+}
+
+// foldKey 去掉下划线和连字符并转为小写，使 base_url、baseURL、BaseUrl 等写法等价
+func foldKey(key string) string {
+	key = strings.ReplaceAll(key, "_", "")
+	key = strings.ReplaceAll(key, "-", "")
+	return strings.ToLower(key)
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProxyConfigFile 配置目录中保存代理配置的文件名
+const ProxyConfigFile = "proxy.json"
+
+// IsDir 判断配置路径是否为配置目录
+func IsDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// DirFiles 返回配置目录中参与加载的 JSON 文件，按文件名排序
+func DirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadFromDir 从配置目录加载配置
+//
+// proxy.json 是完整的配置文件（可包含 servers），其余每个 *.json 文件
+// 是一个服务器配置，服务器名称取自文件名。合并结果与单文件配置的格式相同。
+func (p *Provider) loadFromDir(dir string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ProxyConfigFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", ProxyConfigFile, err)
+	}
+	servers := make(map[string]json.RawMessage)
+	if raw, ok := root["servers"]; ok {
+		if err := json.Unmarshal(raw, &servers); err != nil {
+			return nil, fmt.Errorf("%s: servers: %w", ProxyConfigFile, err)
+		}
+	}
+
+	files, err := DirFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if filepath.Base(file) == ProxyConfigFile {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		if _, exists := servers[name]; exists {
+			return nil, fmt.Errorf("%s: server %s is already defined", filepath.Base(file), name)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("%s: invalid JSON", filepath.Base(file))
+		}
+		servers[name] = data
+	}
+
+	if root == nil {
+		root = make(map[string]json.RawMessage)
+	}
+	root["servers"], err = json.Marshal(servers)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(root)
+}
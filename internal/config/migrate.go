@@ -0,0 +1,64 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// 迁移时为缺失的必填字段补充的默认值
+const (
+	defaultMigrateName    = "mcp-proxy"
+	defaultMigrateVersion = "1.0.0"
+	defaultMigrateAddr    = ":9090"
+)
+
+// Migrate 读取旧版或不完整的配置，补全必填字段与默认值并校验，返回完整的当前格式配置
+//
+// 目前的配置格式没有更早的版本，迁移只接受当前格式字段的任意子集。
+func Migrate(path string) ([]byte, error) {
+	p := &Provider{}
+	config, err := p.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 补全必填字段
+	if config.Proxy.Name == "" {
+		config.Proxy.Name = defaultMigrateName
+	}
+	if config.Proxy.Version == "" {
+		config.Proxy.Version = defaultMigrateVersion
+	}
+	if config.Proxy.Addr == "" {
+		config.Proxy.Addr = defaultMigrateAddr
+	}
+	if config.Proxy.BaseURL == "" {
+		config.Proxy.BaseURL = defaultBaseURL(config.Proxy.Addr)
+	}
+	if config.Servers == nil {
+		config.Servers = make(map[string]interfaces.ServerConfig)
+	}
+
+	// 补全后的配置不再与原始数据对应，跳过 Schema 校验
+	p.raw = nil
+	if err := p.Validate(config); err != nil {
+		return nil, fmt.Errorf("migrated config is invalid: %w", err)
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// defaultBaseURL 根据监听地址推导本地访问的基础 URL
+func defaultBaseURL(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "http://localhost" + addr
+	}
+	return "http://" + addr
+}
@@ -5,16 +5,35 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/ceyewan/mcp-proxy/internal/client"
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/logging"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/jwtauth"
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// metricsPrefixPattern 合法的 Prometheus 指标名前缀
+var metricsPrefixPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// namePrefixPattern 工具和提示词名称前缀的格式，与合法工具名称一致
+var namePrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 // Provider 配置提供者实现
-type Provider struct{}
+type Provider struct {
+	// raw 最近一次加载的原始配置内容，用于 Schema 校验
+	raw []byte
+	// strict 始终拒绝未知字段，不论配置中的 strictConfig
+	strict bool
+}
 
 // NewProvider 创建新的配置提供者
 func NewProvider() interfaces.ConfigProvider {
@@ -26,9 +45,11 @@ func (p *Provider) Load(path string) (*interfaces.Config, error) {
 	var data []byte
 	var err error
 
-	// 判断是否为 HTTP URL
+	// 判断是否为 HTTP URL 或配置目录
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
 		data, err = p.loadFromURL(path)
+	} else if IsDir(path) {
+		data, err = p.loadFromDir(path)
 	} else {
 		data, err = p.loadFromFile(path)
 	}
@@ -37,11 +58,33 @@ func (p *Provider) Load(path string) (*interfaces.Config, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// TOML 配置先转换为 JSON
+	if isTOML(path) {
+		data, err = tomlToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+
+	// 兼容 snake_case 等写法的字段名
+	data, err = normalizeKeys(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
 	// 解析 JSON
 	var config interfaces.Config
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	p.raw = data
+
+	// 严格模式下拒绝未知字段，避免拼写错误的字段被静默忽略
+	if p.strict || GetBool(config.Proxy.StrictConfig, false) {
+		if err := checkUnknownFields(data); err != nil {
+			return nil, err
+		}
+	}
 
 	// 设置默认值
 	p.setDefaults(&config)
@@ -71,31 +114,156 @@ func (p *Provider) loadFromURL(url string) ([]byte, error) {
 
 // setDefaults 设置默认值
 func (p *Provider) setDefaults(config *interfaces.Config) {
-	// 设置代理默认值
-	if config.Proxy.Type == "" {
-		config.Proxy.Type = interfaces.TransportTypeSSE
-	}
-	if config.Proxy.Options == nil {
-		config.Proxy.Options = &interfaces.OptionsConfig{}
+	// 设置代理默认值，其他代理未配置 version 时沿用主代理
+	p.setProxyDefaults(&config.Proxy)
+	for i := range config.Proxies {
+		if config.Proxies[i].Version == "" {
+			config.Proxies[i].Version = config.Proxy.Version
+		}
+		p.setProxyDefaults(&config.Proxies[i])
 	}
 
-	// 为每个服务器设置默认值
+	// 为每个服务器设置默认值，继承所属代理的默认配置
 	for name, serverConfig := range config.Servers {
-		if serverConfig.Options == nil {
-			serverConfig.Options = &interfaces.OptionsConfig{}
+		// servedBy 为主代理名称时等同于未配置
+		if serverConfig.ServedBy == config.Proxy.Name {
+			serverConfig.ServedBy = ""
 		}
+		config.Servers[name] = p.setServerDefaults(serverConfig, config.ProxyFor(serverConfig).Options)
+	}
+}
+
+// setProxyDefaults 设置单个代理的默认值
+func (p *Provider) setProxyDefaults(proxy *interfaces.ProxyConfig) {
+	if proxy.Type == "" {
+		proxy.Type = interfaces.TransportTypeSSE
+	}
+	if proxy.Options == nil {
+		proxy.Options = &interfaces.OptionsConfig{}
+	}
+	if proxy.AdminBasePath == "" {
+		proxy.AdminBasePath = interfaces.DefaultAdminBasePath
+	}
 
-		// 继承代理的默认配置
-		p.inheritProxyDefaults(serverConfig.Options, config.Proxy.Options)
+	// HTTP 服务器默认超时，防止慢速连接长期占用资源
+	if proxy.ServerTimeouts == nil {
+		proxy.ServerTimeouts = &interfaces.ServerTimeouts{}
+	}
+	timeouts := proxy.ServerTimeouts
+	if timeouts.ReadTimeout == "" {
+		timeouts.ReadTimeout = "30s"
+	}
+	if timeouts.WriteTimeout == "" {
+		timeouts.WriteTimeout = "60s"
+	}
+	if timeouts.IdleTimeout == "" {
+		timeouts.IdleTimeout = "120s"
+	}
+	if timeouts.ReadHeaderTimeout == "" {
+		timeouts.ReadHeaderTimeout = "5s"
+	}
+	if proxy.AdminBasePath != "/" {
+		proxy.AdminBasePath = strings.TrimSuffix(proxy.AdminBasePath, "/")
+	}
+}
 
-		// 自动检测传输类型
-		if serverConfig.Transport == "" {
-			serverConfig.Transport = p.detectTransportType(serverConfig)
+// setServerDefaults 设置单个服务器的默认值并继承代理的默认配置
+func (p *Provider) setServerDefaults(serverConfig interfaces.ServerConfig, proxyOptions *interfaces.OptionsConfig) interfaces.ServerConfig {
+	if serverConfig.Options == nil {
+		serverConfig.Options = &interfaces.OptionsConfig{}
+	}
+
+	// 继承代理的默认配置
+	p.inheritProxyDefaults(serverConfig.Options, proxyOptions)
+
+	// 统一过滤模式为小写，与校验和过滤逻辑保持一致
+	if serverConfig.Options.ToolFilter != nil {
+		serverConfig.Options.ToolFilter.Mode = strings.ToLower(serverConfig.Options.ToolFilter.Mode)
+	}
+
+	// 熔断器默认连续失败 5 次后打开 30 秒，半开状态放行一个探测请求
+	if breaker := serverConfig.CircuitBreaker; breaker != nil {
+		if breaker.Threshold == 0 {
+			breaker.Threshold = 5
+		}
+		if breaker.OpenDuration == "" {
+			breaker.OpenDuration = "30s"
 		}
+		if breaker.HalfOpenProbes == 0 {
+			breaker.HalfOpenProbes = 1
+		}
+	}
 
-		// 更新配置
-		config.Servers[name] = serverConfig
+	// 重试默认最多尝试 3 次，间隔从 100ms 开始翻倍，最大 2s
+	if retry := serverConfig.Retry; retry != nil {
+		if retry.MaxAttempts == 0 {
+			retry.MaxAttempts = 3
+		}
+		if retry.InitialDelay == "" {
+			retry.InitialDelay = "100ms"
+		}
+		if retry.MaxDelay == "" {
+			retry.MaxDelay = "2s"
+		}
+	}
+
+	// 自动检测传输类型
+	if serverConfig.Transport == "" {
+		serverConfig.Transport = p.detectTransportType(serverConfig)
+	}
+
+	// 多个上游地址默认轮询，至少 1 个后端可用
+	if len(serverConfig.URLs) > 0 {
+		if serverConfig.LoadBalance == "" {
+			serverConfig.LoadBalance = interfaces.LoadBalanceRoundRobin
+		}
+		if serverConfig.MinHealthy == 0 {
+			serverConfig.MinHealthy = 1
+		}
 	}
+
+	// SSE 和 Streamable 客户端默认定期 ping，显式配置为 0 时关闭
+	if serverConfig.PingInterval == "" && (serverConfig.Transport == interfaces.ClientTypeSSE || serverConfig.Transport == interfaces.ClientTypeStreamable) {
+		serverConfig.PingInterval = interfaces.DefaultPingInterval.String()
+	}
+	return serverConfig
+}
+
+// PrepareServer 为运行时添加的单个服务器设置默认值并验证，服务器继承 servedBy 对应代理的默认配置
+func (p *Provider) PrepareServer(config *interfaces.Config, name string, serverConfig interfaces.ServerConfig) (interfaces.ServerConfig, error) {
+	// 名称用作路由前缀
+	if strings.ContainsAny(name, "/ {}") {
+		return serverConfig, fmt.Errorf("invalid server name %q, must not contain slashes, spaces or braces", name)
+	}
+	if name == interfaces.FanOutServerName && hasFanOutGroups(config) {
+		return serverConfig, fmt.Errorf("server name %s is reserved when fanOutGroups is configured", interfaces.FanOutServerName)
+	}
+	if serverConfig.ServedBy == config.Proxy.Name {
+		serverConfig.ServedBy = ""
+	}
+	proxy := config.ProxyFor(serverConfig)
+	if serverConfig.ServedBy != "" && proxy == &config.Proxy {
+		return serverConfig, fmt.Errorf("servedBy references unknown proxy %s", serverConfig.ServedBy)
+	}
+
+	serverConfig = p.setServerDefaults(serverConfig, proxy.Options)
+	if err := p.validateServerConfig(name, serverConfig); err != nil {
+		return serverConfig, fmt.Errorf("invalid server config for %s: %w", name, err)
+	}
+	return serverConfig, nil
+}
+
+// hasFanOutGroups 是否有任一代理配置了扇出工具组
+func hasFanOutGroups(config *interfaces.Config) bool {
+	if len(config.Proxy.FanOutGroups) > 0 {
+		return true
+	}
+	for _, proxy := range config.Proxies {
+		if len(proxy.FanOutGroups) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // inheritProxyDefaults 继承代理的默认配置
@@ -109,6 +277,81 @@ func (p *Provider) inheritProxyDefaults(serverOptions, proxyOptions *interfaces.
 	if serverOptions.LogEnabled == nil {
 		serverOptions.LogEnabled = proxyOptions.LogEnabled
 	}
+	if serverOptions.ToolTimeoutFallbacks == nil {
+		serverOptions.ToolTimeoutFallbacks = proxyOptions.ToolTimeoutFallbacks
+	}
+	if serverOptions.ToolTimeout == nil {
+		serverOptions.ToolTimeout = proxyOptions.ToolTimeout
+	}
+	if serverOptions.ListPageConcurrency == 0 {
+		serverOptions.ListPageConcurrency = proxyOptions.ListPageConcurrency
+	}
+	if serverOptions.ToolCostWeights == nil {
+		serverOptions.ToolCostWeights = proxyOptions.ToolCostWeights
+	}
+	if serverOptions.InjectClaimsAsArgs == nil {
+		serverOptions.InjectClaimsAsArgs = proxyOptions.InjectClaimsAsArgs
+	}
+	if serverOptions.InjectClaimsPrefix == "" {
+		serverOptions.InjectClaimsPrefix = proxyOptions.InjectClaimsPrefix
+	}
+	if serverOptions.ToolsDiscoveryEnabled == nil {
+		serverOptions.ToolsDiscoveryEnabled = proxyOptions.ToolsDiscoveryEnabled
+	}
+	if serverOptions.StrictToolNames == nil {
+		serverOptions.StrictToolNames = proxyOptions.StrictToolNames
+	}
+	if serverOptions.SanitizeToolNames == nil {
+		serverOptions.SanitizeToolNames = proxyOptions.SanitizeToolNames
+	}
+	if serverOptions.MaxToolArgBytes == 0 {
+		serverOptions.MaxToolArgBytes = proxyOptions.MaxToolArgBytes
+	}
+	if serverOptions.MaxBodyBytes == 0 {
+		serverOptions.MaxBodyBytes = proxyOptions.MaxBodyBytes
+	}
+	if serverOptions.Middlewares == nil {
+		serverOptions.Middlewares = proxyOptions.Middlewares
+	}
+	if serverOptions.ToolGracePeriod == "" {
+		serverOptions.ToolGracePeriod = proxyOptions.ToolGracePeriod
+	}
+	if serverOptions.MaxToolResultBytes == 0 {
+		serverOptions.MaxToolResultBytes = proxyOptions.MaxToolResultBytes
+	}
+	if serverOptions.MaxToolResultPreviewBytes == 0 {
+		serverOptions.MaxToolResultPreviewBytes = proxyOptions.MaxToolResultPreviewBytes
+	}
+	if serverOptions.DebugMode == nil {
+		serverOptions.DebugMode = proxyOptions.DebugMode
+	}
+	if serverOptions.PropagateRequestID == nil {
+		serverOptions.PropagateRequestID = proxyOptions.PropagateRequestID
+	}
+	if serverOptions.ProgressEventThreshold == "" {
+		serverOptions.ProgressEventThreshold = proxyOptions.ProgressEventThreshold
+	}
+	if serverOptions.ProgressEventInterval == "" {
+		serverOptions.ProgressEventInterval = proxyOptions.ProgressEventInterval
+	}
+	if serverOptions.AllowedContentTypes == nil {
+		serverOptions.AllowedContentTypes = proxyOptions.AllowedContentTypes
+	}
+	if serverOptions.StopTimeout == "" {
+		serverOptions.StopTimeout = proxyOptions.StopTimeout
+	}
+	if serverOptions.ServerTimingEnabled == nil {
+		serverOptions.ServerTimingEnabled = proxyOptions.ServerTimingEnabled
+	}
+	if serverOptions.JWT == nil {
+		serverOptions.JWT = proxyOptions.JWT
+	}
+	if serverOptions.CORS == nil {
+		serverOptions.CORS = proxyOptions.CORS
+	}
+	if serverOptions.ToolCache == nil {
+		serverOptions.ToolCache = proxyOptions.ToolCache
+	}
 }
 
 // detectTransportType 自动检测传输类型
@@ -116,7 +359,7 @@ func (p *Provider) detectTransportType(config interfaces.ServerConfig) string {
 	if config.Command != "" {
 		return interfaces.ClientTypeStdio
 	}
-	if config.URL != "" {
+	if config.URL != "" || len(config.URLs) > 0 {
 		if config.Transport == interfaces.ClientTypeStreamable {
 			return interfaces.ClientTypeStreamable
 		}
@@ -131,21 +374,65 @@ func (p *Provider) Validate(config *interfaces.Config) error {
 		return errors.New("config is nil")
 	}
 
+	// 严格模式下按内嵌 Schema 校验原始配置
+	if GetBool(config.Proxy.StrictSchema, false) && p.raw != nil {
+		if err := validateSchema(p.raw); err != nil {
+			return fmt.Errorf("config does not match schema: %w", err)
+		}
+	}
+
 	// 验证代理配置
 	if err := p.validateProxyConfig(&config.Proxy); err != nil {
 		return fmt.Errorf("invalid proxy config: %w", err)
 	}
 
+	// 验证扇出工具组
+	if err := p.validateFanOutGroups(config, &config.Proxy); err != nil {
+		return fmt.Errorf("invalid proxy config: %w", err)
+	}
+
+	// 验证其他代理配置
+	if err := p.validateProxies(config); err != nil {
+		return err
+	}
+
 	// 验证服务器配置
 	for name, serverConfig := range config.Servers {
 		if err := p.validateServerConfig(name, serverConfig); err != nil {
 			return fmt.Errorf("invalid server config for %s: %w", name, err)
 		}
+		if serverConfig.ServedBy != "" && config.ProxyFor(serverConfig) == &config.Proxy {
+			return fmt.Errorf("invalid server config for %s: servedBy references unknown proxy %s", name, serverConfig.ServedBy)
+		}
 	}
 
 	return nil
 }
 
+// validateProxies 验证 proxies 中的代理配置
+//
+// 管理 API、pprof、日志、指标前缀等进程级配置只取自主代理 proxy，在其他代理上配置视为错误。
+func (p *Provider) validateProxies(config *interfaces.Config) error {
+	names := map[string]bool{config.Proxy.Name: true}
+	for i := range config.Proxies {
+		proxy := &config.Proxies[i]
+		if err := p.validateProxyConfig(proxy); err != nil {
+			return fmt.Errorf("invalid proxies[%d] config: %w", i, err)
+		}
+		if names[proxy.Name] {
+			return fmt.Errorf("invalid proxies[%d] config: duplicate proxy name %s", i, proxy.Name)
+		}
+		names[proxy.Name] = true
+		if proxy.AdminAddr != "" || proxy.PprofAddr != "" || proxy.EnablePprof != nil {
+			return fmt.Errorf("invalid proxy config for %s: adminAddr, pprofAddr and enablePprof are only supported on proxy", proxy.Name)
+		}
+		if err := p.validateFanOutGroups(config, proxy); err != nil {
+			return fmt.Errorf("invalid proxy config for %s: %w", proxy.Name, err)
+		}
+	}
+	return nil
+}
+
 // validateProxyConfig 验证代理配置
 func (p *Provider) validateProxyConfig(config *interfaces.ProxyConfig) error {
 	if config.Name == "" {
@@ -167,6 +454,92 @@ func (p *Provider) validateProxyConfig(config *interfaces.ProxyConfig) error {
 		return fmt.Errorf("unsupported transport type: %s", config.Type)
 	}
 
+	// 验证 TLS 证书文件
+	if config.TLS != nil {
+		if err := validateTLS(config.TLS); err != nil {
+			return err
+		}
+	}
+
+	// pprof 只能注册在管理端口或独立端口上，不能暴露在代理端口
+	if config.EnablePprof != nil && *config.EnablePprof {
+		if config.AdminAddr == "" && config.PprofAddr == "" {
+			return fmt.Errorf("enablePprof requires adminAddr or pprofAddr")
+		}
+		if config.PprofAddr != "" && (config.PprofAddr == config.Addr || config.PprofAddr == config.AdminAddr) {
+			return fmt.Errorf("pprofAddr must differ from addr and adminAddr: %s", config.PprofAddr)
+		}
+	}
+
+	// 验证管理 API 路由前缀
+	if config.AdminBasePath != "" && (!strings.HasPrefix(config.AdminBasePath, "/") || strings.ContainsAny(config.AdminBasePath, " {}")) {
+		return fmt.Errorf("invalid adminBasePath: %s, must start with / and must not contain spaces or braces", config.AdminBasePath)
+	}
+
+	// 验证文件描述符上限
+	if config.MaxOpenFDs < 0 {
+		return fmt.Errorf("maxOpenFDs must not be negative")
+	}
+
+	// 验证 SSE 连接数上限
+	if config.MaxSSEConnections < 0 {
+		return fmt.Errorf("maxSSEConnections must not be negative")
+	}
+	if maxAge, err := GetDuration(config.SSEMaxConnectionAge); err != nil {
+		return fmt.Errorf("invalid sseMaxConnectionAge: %w", err)
+	} else if maxAge < 0 {
+		return fmt.Errorf("sseMaxConnectionAge must not be negative")
+	}
+	if timeout, err := GetDuration(config.ReadyzPingTimeout); err != nil {
+		return fmt.Errorf("invalid readyzPingTimeout: %w", err)
+	} else if timeout < 0 {
+		return fmt.Errorf("readyzPingTimeout must not be negative")
+	}
+
+	// 验证 HTTP 服务器超时
+	if config.ServerTimeouts != nil {
+		if err := validateServerTimeouts(config.ServerTimeouts); err != nil {
+			return err
+		}
+	}
+
+	// 验证全局限流配置
+	if config.Options != nil && config.Options.RateLimit != nil {
+		if err := validateRateLimit(config.Options.RateLimit); err != nil {
+			return err
+		}
+	}
+
+	// 验证日志输出目标
+	if config.Options != nil {
+		if err := logging.Validate(logging.Options{
+			Output:         config.Options.LogOutput,
+			RotateSizeMB:   config.Options.LogRotateSizeMB,
+			SyslogFacility: config.Options.SyslogFacility,
+			SyslogSeverity: config.Options.SyslogSeverity,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// 验证日志格式
+	if config.LogFormat != "" && config.LogFormat != logging.FormatText && config.LogFormat != logging.FormatJSON {
+		return fmt.Errorf("invalid logFormat: %s, expected %s or %s", config.LogFormat, logging.FormatText, logging.FormatJSON)
+	}
+
+	// 验证健康检查路径前缀
+	if config.HealthPath != "" && !strings.HasPrefix(config.HealthPath, "/") {
+		return fmt.Errorf("invalid healthPath: %s, must start with /", config.HealthPath)
+	}
+
+	// 验证指标前缀
+	if config.MetricsPath != "" && !strings.HasPrefix(config.MetricsPath, "/") {
+		return fmt.Errorf("invalid metricsPath: %s, must start with /", config.MetricsPath)
+	}
+	if config.MetricsPrefix != "" && !metricsPrefixPattern.MatchString(config.MetricsPrefix) {
+		return fmt.Errorf("invalid metrics prefix: %s, only [a-zA-Z0-9_] allowed and must not start with a digit", config.MetricsPrefix)
+	}
+
 	return nil
 }
 
@@ -177,7 +550,7 @@ func (p *Provider) validateServerConfig(name string, config interfaces.ServerCon
 	}
 
 	// 验证传输类型
-	validTypes := []string{interfaces.ClientTypeStdio, interfaces.ClientTypeSSE, interfaces.ClientTypeStreamable}
+	validTypes := append([]string{interfaces.ClientTypeStdio, interfaces.ClientTypeSSE, interfaces.ClientTypeStreamable}, client.RegisteredTransports()...)
 	if config.Transport != "" && !p.contains(validTypes, config.Transport) {
 		return fmt.Errorf("unsupported transport type: %s", config.Transport)
 	}
@@ -188,9 +561,167 @@ func (p *Provider) validateServerConfig(name string, config interfaces.ServerCon
 		if config.Command == "" {
 			return errors.New("command is required for stdio transport")
 		}
+		if config.WorkDir != "" {
+			if info, err := os.Stat(config.WorkDir); err != nil {
+				return fmt.Errorf("invalid workDir: %w", err)
+			} else if !info.IsDir() {
+				return fmt.Errorf("invalid workDir: %s is not a directory", config.WorkDir)
+			}
+		}
 	case interfaces.ClientTypeSSE, interfaces.ClientTypeStreamable:
-		if config.URL == "" {
-			return errors.New("url is required for sse/streamable transport")
+		if config.URL == "" && len(config.URLs) == 0 {
+			return errors.New("url or urls is required for sse/streamable transport")
+		}
+		if err := p.validateURLs(config); err != nil {
+			return err
+		}
+	default:
+		if len(config.URLs) > 0 {
+			return fmt.Errorf("urls is only supported for sse/streamable transport, got %s", config.Transport)
+		}
+	}
+	if config.Transport != interfaces.ClientTypeStdio && (config.WorkDir != "" || config.InheritEnv != nil) {
+		return fmt.Errorf("workDir and inheritEnv are only supported for stdio transport, got %s", config.Transport)
+	}
+
+	// 验证 OAuth2 client credentials 配置
+	if err := p.validateOAuth2(config); err != nil {
+		return err
+	}
+
+	// 验证协议版本
+	if config.ProtocolVersion != "" {
+		if !p.contains(mcp.ValidProtocolVersions, config.ProtocolVersion) {
+			return fmt.Errorf("unrecognized protocol version: %s, supported: %s", config.ProtocolVersion, strings.Join(mcp.ValidProtocolVersions, ", "))
+		}
+		if config.ProtocolVersion < mcp.LATEST_PROTOCOL_VERSION {
+			slog.Warn("Protocol version is older than latest", "server", name, "protocol_version", config.ProtocolVersion, "latest", mcp.LATEST_PROTOCOL_VERSION)
+		}
+	}
+
+	// 验证上游连接池和连接超时
+	if config.MaxIdleConns < 0 || config.MaxConnsPerHost < 0 {
+		return fmt.Errorf("maxIdleConns and maxConnsPerHost must not be negative")
+	}
+	if _, err := GetDuration(config.IdleConnTimeout); err != nil {
+		return fmt.Errorf("invalid idleConnTimeout: %w", err)
+	}
+	if timeout, err := GetDuration(config.ConnectTimeout); err != nil || timeout < 0 {
+		return fmt.Errorf("invalid connectTimeout: %s", config.ConnectTimeout)
+	}
+	switch config.ConnectTimeoutBehavior {
+	case "", interfaces.ConnectTimeoutBehaviorSkip, interfaces.ConnectTimeoutBehaviorFatal, interfaces.ConnectTimeoutBehaviorRetry:
+	default:
+		return fmt.Errorf("invalid connectTimeoutBehavior: %s, must be one of skip, fatal, retry", config.ConnectTimeoutBehavior)
+	}
+	if interval, err := GetDuration(config.StdioKeepaliveInterval); err != nil || interval < 0 {
+		return fmt.Errorf("invalid stdioKeepaliveInterval: %s", config.StdioKeepaliveInterval)
+	}
+	if config.MaxRestarts < 0 {
+		return fmt.Errorf("maxRestarts must not be negative: %d", config.MaxRestarts)
+	}
+	if _, err := client.ParseStderrLogLevel(config.StderrLogLevel); err != nil {
+		return fmt.Errorf("invalid stderrLogLevel: %s, expected debug, info, warn or error", config.StderrLogLevel)
+	}
+	if timeout, err := GetDuration(config.DefaultToolTimeout); err != nil || timeout < 0 {
+		return fmt.Errorf("invalid defaultToolTimeout: %s", config.DefaultToolTimeout)
+	}
+	if config.Options != nil {
+		for tool, value := range config.Options.ToolTimeout {
+			if timeout, err := time.ParseDuration(value); err != nil || timeout <= 0 {
+				return fmt.Errorf("invalid toolTimeout[%s]: %q, must be a positive duration", tool, value)
+			}
+		}
+	}
+	if interval, err := GetDuration(config.PingInterval); err != nil || interval < 0 {
+		return fmt.Errorf("invalid pingInterval: %s", config.PingInterval)
+	}
+	if config.TLS != nil {
+		if err := validateClientTLS(config.TLS); err != nil {
+			return err
+		}
+	}
+	if config.Retry != nil {
+		if err := validateRetry(config.Retry); err != nil {
+			return err
+		}
+	}
+
+	// 验证列表并发数
+	if config.Options != nil && config.Options.ListPageConcurrency < 0 {
+		return fmt.Errorf("listPageConcurrency must not be negative: %d", config.Options.ListPageConcurrency)
+	}
+	if config.Options != nil && config.Options.MaxToolArgBytes < 0 {
+		return fmt.Errorf("maxToolArgBytes must not be negative: %d", config.Options.MaxToolArgBytes)
+	}
+	if config.Options != nil && config.Options.MaxBodyBytes < 0 {
+		return fmt.Errorf("maxBodyBytes must not be negative: %d", config.Options.MaxBodyBytes)
+	}
+	if config.Options != nil && (config.Options.MaxToolResultBytes < 0 || config.Options.MaxToolResultPreviewBytes < 0) {
+		return fmt.Errorf("maxToolResultBytes and maxToolResultPreviewBytes must not be negative")
+	}
+	if config.Options != nil && config.Options.MaxToolResultBytes > 0 && config.Options.MaxToolResultPreviewBytes > config.Options.MaxToolResultBytes {
+		return fmt.Errorf("maxToolResultPreviewBytes %d must not exceed maxToolResultBytes %d", config.Options.MaxToolResultPreviewBytes, config.Options.MaxToolResultBytes)
+	}
+	if config.Options != nil {
+		for _, contentType := range config.Options.AllowedContentTypes {
+			if mediaType, subType, ok := strings.Cut(contentType, "/"); !ok || mediaType == "" || subType == "" {
+				return fmt.Errorf("invalid allowedContentTypes entry %q, expected type/subtype", contentType)
+			}
+		}
+	}
+	if config.Options != nil {
+		if _, err := GetDuration(config.Options.ToolGracePeriod); err != nil {
+			return fmt.Errorf("invalid toolGracePeriod: %w", err)
+		}
+		if _, err := GetDuration(config.Options.ProgressEventThreshold); err != nil {
+			return fmt.Errorf("invalid progressEventThreshold: %w", err)
+		}
+		if _, err := GetDuration(config.Options.ProgressEventInterval); err != nil {
+			return fmt.Errorf("invalid progressEventInterval: %w", err)
+		}
+		if timeout, err := GetDuration(config.Options.StopTimeout); err != nil {
+			return fmt.Errorf("invalid stopTimeout: %w", err)
+		} else if timeout < 0 {
+			return fmt.Errorf("stopTimeout must not be negative")
+		}
+	}
+	if config.Options != nil && config.Options.RateLimit != nil {
+		if err := validateRateLimit(config.Options.RateLimit); err != nil {
+			return err
+		}
+	}
+	if config.Options != nil && config.Options.CORS != nil {
+		if err := validateCORS(config.Options.CORS); err != nil {
+			return err
+		}
+	}
+	if config.Options != nil && config.Options.ToolNamePrefix != "" && !namePrefixPattern.MatchString(config.Options.ToolNamePrefix) {
+		return fmt.Errorf("invalid toolNamePrefix: %q, must only contain letters, digits, underscores and hyphens", config.Options.ToolNamePrefix)
+	}
+	if config.Options != nil && config.Options.PromptNamePrefix != "" && !namePrefixPattern.MatchString(config.Options.PromptNamePrefix) {
+		return fmt.Errorf("invalid promptNamePrefix: %q, must only contain letters, digits, underscores and hyphens", config.Options.PromptNamePrefix)
+	}
+	if config.Options != nil && config.Options.ToolCache != nil {
+		if err := validateToolCache(config.Options.ToolCache); err != nil {
+			return err
+		}
+	}
+	if config.Options != nil {
+		if err := p.validateJWT(config.Options); err != nil {
+			return err
+		}
+	}
+	if config.Options != nil {
+		if err := p.validateMiddlewares(config.Options.Middlewares); err != nil {
+			return fmt.Errorf("invalid middlewares: %w", err)
+		}
+	}
+
+	// 验证熔断器配置
+	if config.CircuitBreaker != nil {
+		if err := p.validateCircuitBreaker(config.CircuitBreaker); err != nil {
+			return fmt.Errorf("invalid circuit breaker: %w", err)
 		}
 	}
 
@@ -200,13 +731,232 @@ func (p *Provider) validateServerConfig(name string, config interfaces.ServerCon
 			return fmt.Errorf("invalid tool filter: %w", err)
 		}
 	}
+	if config.Options != nil && config.Options.PromptFilter != nil {
+		if err := p.validateToolFilter(config.Options.PromptFilter); err != nil {
+			return fmt.Errorf("invalid prompt filter: %w", err)
+		}
+		if config.Options.PromptFilter.ListURL != "" {
+			return errors.New("invalid prompt filter: listURL is only supported for toolFilter")
+		}
+	}
+	if config.Options != nil && config.Options.ResourceFilter != nil {
+		if err := p.validateToolFilter(config.Options.ResourceFilter); err != nil {
+			return fmt.Errorf("invalid resource filter: %w", err)
+		}
+		if config.Options.ResourceFilter.ListURL != "" {
+			return errors.New("invalid resource filter: listURL is only supported for toolFilter")
+		}
+	}
+
+	return nil
+}
+
+// validateFanOutGroups 验证扇出工具组，目标必须为 serverName.toolName 且服务器已配置
+func (p *Provider) validateFanOutGroups(config *interfaces.Config, proxy *interfaces.ProxyConfig) error {
+	if _, err := GetDuration(proxy.FanOutTimeout); err != nil {
+		return fmt.Errorf("invalid fanOutTimeout: %w", err)
+	}
+	if len(proxy.FanOutGroups) == 0 {
+		return nil
+	}
+	if _, exists := config.Servers[interfaces.FanOutServerName]; exists {
+		return fmt.Errorf("server name %s is reserved when fanOutGroups is configured", interfaces.FanOutServerName)
+	}
+	for name, targets := range proxy.FanOutGroups {
+		if name == "" {
+			return errors.New("fan-out tool name is required")
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("fan-out tool %s has no targets", name)
+		}
+		for _, target := range targets {
+			serverName, toolName, ok := strings.Cut(target, ".")
+			if !ok || serverName == "" || toolName == "" {
+				return fmt.Errorf("fan-out tool %s: invalid target %q, expected serverName.toolName", name, target)
+			}
+			serverConfig, exists := config.Servers[serverName]
+			if !exists {
+				return fmt.Errorf("fan-out tool %s: unknown server %s", name, serverName)
+			}
+			// 扇出目标只能是同一代理提供的服务器
+			if config.ProxyFor(serverConfig) != proxy {
+				return fmt.Errorf("fan-out tool %s: server %s is not served by proxy %s", name, serverName, proxy.Name)
+			}
+			// 扇出路由只应用代理级认证，目标服务器单独配置的认证会被绕过
+			if !sameAuth(serverConfig.Options, proxy.Options) {
+				return fmt.Errorf("fan-out tool %s: server %s sets its own authTokens or jwt, which the fan-out route does not enforce", name, serverName)
+			}
+		}
+	}
+	return nil
+}
+
+// sameAuth 服务器的 authTokens 和 jwt 是否与代理一致，未配置时服务器继承代理的认证
+func sameAuth(serverOptions, proxyOptions *interfaces.OptionsConfig) bool {
+	var serverTokens, proxyTokens []string
+	var serverJWT, proxyJWT *interfaces.JWTConfig
+	if serverOptions != nil {
+		serverTokens, serverJWT = serverOptions.AuthTokens, serverOptions.JWT
+	}
+	if proxyOptions != nil {
+		proxyTokens, proxyJWT = proxyOptions.AuthTokens, proxyOptions.JWT
+	}
+	return slices.Equal(serverTokens, proxyTokens) && reflect.DeepEqual(serverJWT, proxyJWT)
+}
+
+// validateURLs 验证多个上游地址及负载均衡配置
+func (p *Provider) validateURLs(config interfaces.ServerConfig) error {
+	if len(config.URLs) == 0 {
+		if config.LoadBalance != "" || config.MinHealthy != 0 {
+			return errors.New("loadBalance and minHealthy require urls")
+		}
+		return nil
+	}
+	if config.URL != "" {
+		return errors.New("url and urls are mutually exclusive")
+	}
+	for i, url := range config.URLs {
+		if url == "" {
+			return fmt.Errorf("urls[%d] must not be empty", i)
+		}
+	}
+	switch config.LoadBalance {
+	case interfaces.LoadBalanceRoundRobin, interfaces.LoadBalanceLeastConnections:
+	default:
+		return fmt.Errorf("invalid loadBalance: %s, must be one of %s, %s", config.LoadBalance, interfaces.LoadBalanceRoundRobin, interfaces.LoadBalanceLeastConnections)
+	}
+	if config.MinHealthy < 0 || config.MinHealthy > len(config.URLs) {
+		return fmt.Errorf("invalid minHealthy: %d, must be between 1 and the number of urls (%d)", config.MinHealthy, len(config.URLs))
+	}
+	return nil
+}
+
+// validateOAuth2 验证 OAuth2 配置，任一字段设置时 clientID、clientSecret 和 tokenURL 均为必填
+func (p *Provider) validateOAuth2(config interfaces.ServerConfig) error {
+	if config.OAuth2ClientID == "" && config.OAuth2ClientSecret == "" && config.OAuth2TokenURL == "" && len(config.OAuth2Scopes) == 0 {
+		return nil
+	}
+	if config.OAuth2ClientID == "" || config.OAuth2ClientSecret == "" || config.OAuth2TokenURL == "" {
+		return errors.New("oauth2ClientID, oauth2ClientSecret and oauth2TokenURL are all required for oauth2")
+	}
+	if !strings.HasPrefix(config.OAuth2TokenURL, "http://") && !strings.HasPrefix(config.OAuth2TokenURL, "https://") {
+		return fmt.Errorf("invalid oauth2TokenURL: %s, must be a http(s) url", config.OAuth2TokenURL)
+	}
+	if config.Transport == interfaces.ClientTypeStdio {
+		return errors.New("oauth2 is only supported for sse/streamable transport")
+	}
+	if config.AuthToken != "" {
+		return errors.New("authToken and oauth2 must not be used together")
+	}
+	return nil
+}
+
+// validateRateLimit 验证限流配置
+func validateRateLimit(rateLimit *interfaces.RateLimitConfig) error {
+	if rateLimit.Rate <= 0 {
+		return fmt.Errorf("rateLimit.rate must be positive")
+	}
+	if rateLimit.Burst < 0 {
+		return fmt.Errorf("rateLimit.burst must not be negative")
+	}
+	return nil
+}
+
+// validateRetry 验证工具调用重试配置
+func validateRetry(retry *interfaces.RetryConfig) error {
+	if retry.MaxAttempts < 1 {
+		return fmt.Errorf("retry.maxAttempts must be at least 1")
+	}
+	initialDelay, err := time.ParseDuration(retry.InitialDelay)
+	if err != nil || initialDelay <= 0 {
+		return fmt.Errorf("invalid retry.initialDelay: %q, must be a positive duration", retry.InitialDelay)
+	}
+	maxDelay, err := time.ParseDuration(retry.MaxDelay)
+	if err != nil || maxDelay < initialDelay {
+		return fmt.Errorf("invalid retry.maxDelay: %q, must not be less than initialDelay", retry.MaxDelay)
+	}
+	return nil
+}
+
+// validateServerTimeouts 验证 HTTP 服务器超时配置
+func validateServerTimeouts(timeouts *interfaces.ServerTimeouts) error {
+	for name, value := range map[string]string{
+		"readTimeout":       timeouts.ReadTimeout,
+		"writeTimeout":      timeouts.WriteTimeout,
+		"idleTimeout":       timeouts.IdleTimeout,
+		"readHeaderTimeout": timeouts.ReadHeaderTimeout,
+	} {
+		if timeout, err := GetDuration(value); err != nil || timeout < 0 {
+			return fmt.Errorf("invalid serverTimeouts.%s: %q", name, value)
+		}
+	}
+	return nil
+}
+
+// validateCORS 验证 CORS 配置
+func validateCORS(cors *interfaces.CORSConfig) error {
+	if len(cors.AllowOrigins) == 0 {
+		return fmt.Errorf("cors.allowOrigins must not be empty")
+	}
+	if cors.MaxAge < 0 {
+		return fmt.Errorf("cors.maxAge must not be negative")
+	}
+	return nil
+}
+
+// validateToolCache 验证工具结果缓存配置
+func validateToolCache(cache *interfaces.ToolCacheConfig) error {
+	if ttl, err := time.ParseDuration(cache.TTL); err != nil || ttl <= 0 {
+		return fmt.Errorf("invalid toolCache.ttl: %q, must be a positive duration", cache.TTL)
+	}
+	if cache.MaxEntries < 0 {
+		return fmt.Errorf("toolCache.maxEntries must not be negative")
+	}
+	for tool, ttl := range cache.Tools {
+		if duration, err := time.ParseDuration(ttl); err != nil || duration < 0 {
+			return fmt.Errorf("invalid toolCache.tools[%s]: %q", tool, ttl)
+		}
+	}
+	return nil
+}
 
+// validateJWT 验证 JWT 认证配置，JWT 与 authTokens 不能同时使用
+func (p *Provider) validateJWT(options *interfaces.OptionsConfig) error {
+	if options.JWT == nil {
+		return nil
+	}
+	if !strings.HasPrefix(options.JWT.JWKSURL, "http://") && !strings.HasPrefix(options.JWT.JWKSURL, "https://") {
+		return fmt.Errorf("invalid jwt.jwksURL: %q, must be a http(s) url", options.JWT.JWKSURL)
+	}
+	if options.JWT.Algorithm != "" && !jwtauth.SupportedAlgorithm(options.JWT.Algorithm) {
+		return fmt.Errorf("unsupported jwt.algorithm: %s", options.JWT.Algorithm)
+	}
+	if _, err := GetDuration(options.JWT.JWKSRefreshInterval); err != nil {
+		return fmt.Errorf("invalid jwt.jwksRefreshInterval: %w", err)
+	}
+	if len(options.AuthTokens) > 0 {
+		return errors.New("authTokens and jwt must not be used together")
+	}
 	return nil
 }
 
 // validateToolFilter 验证工具过滤配置
 func (p *Provider) validateToolFilter(filter *interfaces.ToolFilterConfig) error {
-	if len(filter.List) > 0 {
+	if filter.ListURL != "" && !strings.HasPrefix(filter.ListURL, "http://") && !strings.HasPrefix(filter.ListURL, "https://") {
+		return fmt.Errorf("invalid listURL: %s, must be a http(s) url", filter.ListURL)
+	}
+	if interval, err := GetDuration(filter.ListRefreshInterval); err != nil || interval < 0 {
+		return fmt.Errorf("invalid listRefreshInterval: %s", filter.ListRefreshInterval)
+	}
+	for _, entry := range filter.List {
+		// /.../ 包裹的条目为正则表达式
+		if len(entry) >= 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			if _, err := regexp.Compile(entry[1 : len(entry)-1]); err != nil {
+				return fmt.Errorf("invalid tool filter pattern %q: %w", entry, err)
+			}
+		}
+	}
+	if len(filter.List) > 0 || filter.ListURL != "" {
 		mode := strings.ToLower(filter.Mode)
 		if mode != interfaces.ToolFilterModeAllow && mode != interfaces.ToolFilterModeBlock {
 			return fmt.Errorf("invalid filter mode: %s, must be 'allow' or 'block'", filter.Mode)
@@ -215,6 +965,43 @@ func (p *Provider) validateToolFilter(filter *interfaces.ToolFilterConfig) error
 	return nil
 }
 
+// validateMiddlewares 验证中间件实例配置，ID 不能为空且不能重复
+func (p *Provider) validateMiddlewares(middlewares []interfaces.MiddlewareInstanceConfig) error {
+	ids := make(map[string]struct{}, len(middlewares))
+	for _, middleware := range middlewares {
+		if middleware.ID == "" {
+			return fmt.Errorf("middleware of type %q is missing an id", middleware.Type)
+		}
+		if middleware.Type == "" {
+			return fmt.Errorf("middleware %s is missing a type", middleware.ID)
+		}
+		if _, ok := ids[middleware.ID]; ok {
+			return fmt.Errorf("duplicate middleware id: %s", middleware.ID)
+		}
+		ids[middleware.ID] = struct{}{}
+	}
+	return nil
+}
+
+// validateCircuitBreaker 验证熔断器配置
+func (p *Provider) validateCircuitBreaker(breaker *interfaces.CircuitBreakerConfig) error {
+	if breaker.Threshold < 0 {
+		return fmt.Errorf("threshold must not be negative: %d", breaker.Threshold)
+	}
+	if duration, err := GetDuration(breaker.OpenDuration); err != nil {
+		return fmt.Errorf("invalid openDuration: %w", err)
+	} else if duration < 0 {
+		return fmt.Errorf("openDuration must not be negative: %s", breaker.OpenDuration)
+	}
+	if breaker.HalfOpenProbes < 0 {
+		return fmt.Errorf("halfOpenProbes must not be negative: %d", breaker.HalfOpenProbes)
+	}
+	if _, err := GetDuration(breaker.HalfOpenInterval); err != nil {
+		return fmt.Errorf("invalid halfOpenInterval: %w", err)
+	}
+	return nil
+}
+
 // contains 检查切片是否包含指定元素
 func (p *Provider) contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -238,6 +1025,40 @@ func GetBool(ptr *bool, defaultValue bool) bool {
 	return *ptr
 }
 
+// validateTLS 验证 TLS 证书和私钥文件存在，配置了 CA 文件时同样检查
+func validateTLS(config *interfaces.TLSConfig) error {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return fmt.Errorf("tls requires both certFile and keyFile")
+	}
+	files := map[string]string{"certFile": config.CertFile, "keyFile": config.KeyFile}
+	if config.CAFile != "" {
+		files["caFile"] = config.CAFile
+	}
+	for field, path := range files {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid tls %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// validateClientTLS 验证上游 TLS 配置，客户端证书和私钥需同时配置，配置的文件必须存在
+func validateClientTLS(config *interfaces.ClientTLSConfig) error {
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return fmt.Errorf("tls certFile and keyFile must be set together")
+	}
+	files := map[string]string{"caFile": config.CAFile, "certFile": config.CertFile, "keyFile": config.KeyFile}
+	for field, path := range files {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid tls %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
 // GetDuration 解析时间字符串
 func GetDuration(s string) (time.Duration, error) {
 	if s == "" {
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+func TestCircuitBreakerHalfOpenProbesDefault(t *testing.T) {
+	config := &interfaces.Config{
+		Servers: map[string]interfaces.ServerConfig{
+			"unset":    {CircuitBreaker: &interfaces.CircuitBreakerConfig{}},
+			"explicit": {CircuitBreaker: &interfaces.CircuitBreakerConfig{HalfOpenProbes: 3}},
+			"disabled": {},
+		},
+	}
+	(&Provider{}).setDefaults(config)
+
+	if got := config.Servers["unset"].CircuitBreaker.HalfOpenProbes; got != 1 {
+		t.Errorf("default halfOpenProbes = %d, want 1", got)
+	}
+	if got := config.Servers["explicit"].CircuitBreaker.HalfOpenProbes; got != 3 {
+		t.Errorf("explicit halfOpenProbes = %d, want 3", got)
+	}
+	if config.Servers["disabled"].CircuitBreaker != nil {
+		t.Error("circuitBreaker created for a server without one")
+	}
+}
+
+func TestValidateCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name    string
+		breaker interfaces.CircuitBreakerConfig
+		wantErr bool
+	}{
+		{name: "defaults", breaker: interfaces.CircuitBreakerConfig{HalfOpenProbes: 1}},
+		{name: "probes with interval", breaker: interfaces.CircuitBreakerConfig{HalfOpenProbes: 3, HalfOpenInterval: "2s"}},
+		{name: "negative probes", breaker: interfaces.CircuitBreakerConfig{HalfOpenProbes: -1}, wantErr: true},
+		{name: "invalid interval", breaker: interfaces.CircuitBreakerConfig{HalfOpenProbes: 1, HalfOpenInterval: "soon"}, wantErr: true},
+	}
+
+	p := &Provider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.validateCircuitBreaker(&tt.breaker)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCircuitBreaker(%+v) error = %v, wantErr %v", tt.breaker, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateToolFilterModeCase(t *testing.T) {
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{mode: "allow"},
+		{mode: "Allow"},
+		{mode: "ALLOW"},
+		{mode: "block"},
+		{mode: "Block"},
+		{mode: "bLoCk"},
+		{mode: "deny", wantErr: true},
+		{mode: "", wantErr: true},
+	}
+
+	p := &Provider{}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			err := p.validateToolFilter(&interfaces.ToolFilterConfig{Mode: tt.mode, List: []string{"fetch"}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateToolFilter(mode %q) error = %v, wantErr %v", tt.mode, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateToolResultLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxBytes     int64
+		previewBytes int64
+		wantErr      bool
+	}{
+		{name: "unset"},
+		{name: "preview defaults to limit", maxBytes: 1024},
+		{name: "preview below limit", maxBytes: 1024, previewBytes: 256},
+		{name: "preview equals limit", maxBytes: 1024, previewBytes: 1024},
+		{name: "preview above limit", maxBytes: 1024, previewBytes: 2048, wantErr: true},
+		{name: "preview without limit", previewBytes: 2048},
+		{name: "negative limit", maxBytes: -1, wantErr: true},
+	}
+
+	p := &Provider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.validateServerConfig("test", interfaces.ServerConfig{
+				Transport: interfaces.ClientTypeStreamable,
+				URL:       "http://127.0.0.1:8080/mcp",
+				Options: &interfaces.OptionsConfig{
+					MaxToolResultBytes:        tt.maxBytes,
+					MaxToolResultPreviewBytes: tt.previewBytes,
+				},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateServerConfig(maxToolResultBytes %d, maxToolResultPreviewBytes %d) error = %v, wantErr %v", tt.maxBytes, tt.previewBytes, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFanOutGroupsAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  *interfaces.OptionsConfig
+		wantErr bool
+	}{
+		{name: "inherits proxy auth"},
+		{name: "same tokens", server: &interfaces.OptionsConfig{AuthTokens: []string{"proxy-token"}}},
+		{name: "own tokens", server: &interfaces.OptionsConfig{AuthTokens: []string{"server-token"}}, wantErr: true},
+		{name: "own jwt", server: &interfaces.OptionsConfig{JWT: &interfaces.JWTConfig{JWKSURL: "https://auth.example.com/jwks.json"}}, wantErr: true},
+	}
+
+	p := &Provider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxyOptions := &interfaces.OptionsConfig{AuthTokens: []string{"proxy-token"}}
+			serverConfig := p.setServerDefaults(interfaces.ServerConfig{
+				Transport: interfaces.ClientTypeStreamable,
+				URL:       "http://127.0.0.1:8080/mcp",
+				Options:   tt.server,
+			}, proxyOptions)
+			config := &interfaces.Config{
+				Proxy: interfaces.ProxyConfig{
+					Name:         "proxy",
+					Options:      proxyOptions,
+					FanOutGroups: map[string][]string{"search_all": {"kb.search"}},
+				},
+				Servers: map[string]interfaces.ServerConfig{"kb": serverConfig},
+			}
+			err := p.validateFanOutGroups(config, &config.Proxy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFanOutGroups() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+//go:generate sh -c "go run ../../cmd --generate-schema > schema.json"
+
+//go:embed schema.json
+var schemaFS embed.FS
+
+// schemaDraft JSON Schema 规范版本
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema 返回内嵌的配置 JSON Schema
+func Schema() []byte {
+	data, err := schemaFS.ReadFile("schema.json")
+	if err != nil {
+		// 文件在编译期嵌入，读取失败说明构建有误
+		panic(fmt.Sprintf("embedded schema.json missing: %v", err))
+	}
+	return data
+}
+
+// GenerateSchema 根据 interfaces.Config 结构体生成 JSON Schema
+func GenerateSchema() ([]byte, error) {
+	root := schemaFor(reflect.TypeOf(interfaces.Config{}))
+	root["$schema"] = schemaDraft
+	root["title"] = "mcp-proxy config"
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// schemaFor 递归生成类型对应的 Schema
+func schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// time.Duration 在 JSON 中以纳秒整数表示
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return map[string]interface{}{"type": "integer"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonFieldName(field)
+			if name == "" {
+				continue
+			}
+			properties[name] = schemaFor(field.Type)
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	default:
+		// interface{} 等类型不做约束
+		return map[string]interface{}{}
+	}
+}
+
+// jsonFieldName 获取结构体字段的 JSON 名称，忽略的字段返回空串
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// validateSchema 按内嵌 Schema 校验原始 JSON 配置
+func validateSchema(data []byte) error {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(Schema(), &schema); err != nil {
+		return fmt.Errorf("failed to parse embedded schema: %w", err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	return validateValue("$", schema, value)
+}
+
+// validateValue 校验单个值，支持 type/properties/additionalProperties/items 子集
+func validateValue(path string, schema map[string]interface{}, value interface{}) error {
+	// 与 encoding/json 保持一致，null 对任意类型都合法
+	if value == nil {
+		return nil
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := path + "." + key
+			if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+				if err := validateValue(childPath, propertySchema, object[key]); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s: unknown field", childPath)
+				}
+			case map[string]interface{}:
+				if err := validateValue(childPath, additional, object[key]); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range array {
+			if err := validateValue(fmt.Sprintf("%s[%d]", path, i), items, item); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean", path)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number", path)
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return fmt.Errorf("%s: expected integer", path)
+		}
+	}
+
+	return nil
+}
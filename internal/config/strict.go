@@ -0,0 +1,92 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// checkUnknownFields 检查配置中是否存在结构体未定义的字段，返回的错误列出所有未知字段的路径
+//
+// 与 json.Decoder.DisallowUnknownFields 相比，这里一次报告全部未知字段并给出完整路径，
+// 例如 proxy.options.authtoken。传入的数据应已经过 normalizeKeys 处理。
+func checkUnknownFields(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	unknown := unknownFields(value, reflect.TypeOf(interfaces.Config{}), "")
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown config fields: %s", strings.Join(unknown, ", "))
+}
+
+// unknownFields 按目标类型递归收集未知字段的路径
+func unknownFields(value interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var unknown []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := make(map[string]reflect.StructField, t.NumField())
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if name := jsonFieldName(field); name != "" {
+					fields[name] = field
+				}
+			}
+			for key, item := range v {
+				field, ok := fields[key]
+				if !ok {
+					unknown = append(unknown, joinFieldPath(path, key))
+					continue
+				}
+				unknown = append(unknown, unknownFields(item, field.Type, joinFieldPath(path, key))...)
+			}
+		case reflect.Map:
+			for key, item := range v {
+				unknown = append(unknown, unknownFields(item, t.Elem(), joinFieldPath(path, key))...)
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range v {
+				unknown = append(unknown, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return unknown
+}
+
+// joinFieldPath 拼接字段路径
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// ValidateFile 以严格模式加载并校验配置文件，未知字段视为错误
+func ValidateFile(path string) error {
+	p := &Provider{strict: true}
+	config, err := p.Load(path)
+	if err != nil {
+		return err
+	}
+	return p.Validate(config)
+}
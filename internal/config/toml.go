@@ -0,0 +1,34 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// isTOML 根据文件或 URL 路径的扩展名判断是否为 TOML 配置
+func isTOML(path string) bool {
+	if u, err := url.Parse(path); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		path = u.Path
+	}
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+// tomlToJSON 将 TOML 配置转换为等价的 JSON，之后与 JSON 配置走相同的解析和校验流程
+//
+// 日期时间按 RFC 3339 转为字符串，inf 和 nan 无法表示为 JSON，视为错误。
+func tomlToJSON(data []byte) ([]byte, error) {
+	var document map[string]interface{}
+	if _, err := toml.Decode(string(data), &document); err != nil {
+		return nil, fmt.Errorf("toml: %w", err)
+	}
+	result, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("toml: %w", err)
+	}
+	return result, nil
+}
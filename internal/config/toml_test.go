@@ -0,0 +1,201 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTOMLToJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want string
+	}{
+		{
+			name: "tables",
+			toml: "[proxy]\naddr = \":9090\"\n[proxy.options]\nlogEnabled = true\n",
+			want: `{"proxy": {"addr": ":9090", "options": {"logEnabled": true}}}`,
+		},
+		{
+			name: "array of tables",
+			toml: "[[proxies]]\nname = \"a\"\n[[proxies]]\nname = \"b\"\n",
+			want: `{"proxies": [{"name": "a"}, {"name": "b"}]}`,
+		},
+		{
+			name: "dotted keys",
+			toml: "servers.fetch.transport = \"stdio\"\nservers.fetch.\"tool-filter\".mode = \"allow\"\n",
+			want: `{"servers": {"fetch": {"transport": "stdio", "tool-filter": {"mode": "allow"}}}}`,
+		},
+		{
+			name: "inline tables",
+			toml: "env = { HOME = \"/tmp\", nested = { a = 1 } }\n",
+			want: `{"env": {"HOME": "/tmp", "nested": {"a": 1}}}`,
+		},
+		{
+			name: "arrays",
+			toml: "args = [\"-y\", \"server\"]\nmixed = [[1, 2], [\"a\"]]\nmultiline = [\n  1,\n  2, # comment\n]\n",
+			want: `{"args": ["-y", "server"], "mixed": [[1, 2], ["a"]], "multiline": [1, 2]}`,
+		},
+		{
+			name: "strings",
+			toml: "basic = \"a\\tb\\u00e9\"\nliteral = 'C:\\path'\nmulti = \"\"\"\nline1\\\n  line2\"\"\"\nraw = '''\nno \\escape'''\n",
+			want: `{"basic": "a\tbé", "literal": "C:\\path", "multi": "line1line2", "raw": "no \\escape"}`,
+		},
+		{
+			name: "integers and floats",
+			toml: "dec = 1_000\nhex = 0xff\noct = 0o17\nbin = 0b101\nneg = -5\nrate = 0.5\nexp = 1e3\n",
+			want: `{"dec": 1000, "hex": 255, "oct": 15, "bin": 5, "neg": -5, "rate": 0.5, "exp": 1000}`,
+		},
+		{
+			name: "booleans and comments",
+			toml: "# comment\nenabled = true # trailing\ndisabled = false\n",
+			want: `{"enabled": true, "disabled": false}`,
+		},
+		{
+			name: "datetimes",
+			toml: "at = 2026-01-02T03:04:05Z\n",
+			want: `{"at": "2026-01-02T03:04:05Z"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tomlToJSON([]byte(tt.toml))
+			if err != nil {
+				t.Fatalf("tomlToJSON() error = %v", err)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("invalid JSON %s: %v", data, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("tomlToJSON() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+	}{
+		{name: "duplicate key", toml: "a = 1\na = 2\n"},
+		{name: "duplicate table", toml: "[a]\nb = 1\n[a]\nc = 2\n"},
+		{name: "missing value", toml: "a =\n"},
+		{name: "unterminated string", toml: "a = \"abc\n"},
+		{name: "inf", toml: "a = inf\n"},
+		{name: "nan", toml: "a = nan\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tomlToJSON([]byte(tt.toml)); err == nil {
+				t.Error("tomlToJSON() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestIsTOML(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "config.toml", want: true},
+		{path: "/etc/mcp/CONFIG.TOML", want: true},
+		{path: "config.json", want: false},
+		{path: "https://example.com/config.toml?rev=2", want: true},
+		{path: "https://example.com/config?format=toml", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isTOML(tt.path); got != tt.want {
+			t.Errorf("isTOML(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadTOMLMatchesJSON(t *testing.T) {
+	const jsonConfig = `{
+  "proxy": {
+    "baseURL": "http://localhost:9090",
+    "addr": ":9090",
+    "name": "MCP Proxy",
+    "version": "2.0.0",
+    "type": "sse",
+    "options": {
+      "logEnabled": true,
+      "authTokens": ["secret"],
+      "rateLimit": {"rate": 0.5, "burst": 2}
+    }
+  },
+  "servers": {
+    "fetch": {
+      "transport": "stdio",
+      "command": "uvx",
+      "args": ["mcp-server-fetch"],
+      "env": {"HOME": "/tmp"},
+      "options": {
+        "toolFilter": {"mode": "block", "list": ["fetch_raw"]}
+      }
+    },
+    "weather": {
+      "transport": "sse",
+      "url": "http://127.0.0.1:8080/sse/"
+    }
+  }
+}`
+	const tomlConfig = `
+[proxy]
+baseURL = "http://localhost:9090"
+addr = ":9090"
+name = "MCP Proxy"
+version = "2.0.0"
+type = "sse"
+
+[proxy.options]
+log_enabled = true
+authTokens = ["secret"]
+rateLimit = { rate = 0.5, burst = 2 }
+
+[servers.fetch]
+transport = "stdio"
+command = "uvx"
+args = ["mcp-server-fetch"]
+env = { HOME = "/tmp" }
+options.toolFilter.mode = "block"
+options.toolFilter.list = ["fetch_raw"]
+
+[servers.weather]
+transport = "sse"
+url = "http://127.0.0.1:8080/sse/"
+`
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "config.json")
+	tomlPath := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(jsonPath, []byte(jsonConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tomlPath, []byte(tomlConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fromJSON, err := NewProvider().Load(jsonPath)
+	if err != nil {
+		t.Fatalf("Load(json) error = %v", err)
+	}
+	fromTOML, err := NewProvider().Load(tomlPath)
+	if err != nil {
+		t.Fatalf("Load(toml) error = %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromTOML) {
+		t.Errorf("TOML config differs from JSON config:\njson: %+v\ntoml: %+v", fromJSON, fromTOML)
+	}
+}
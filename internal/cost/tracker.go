@@ -0,0 +1,114 @@
+package cost
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// bucketSize 滚动统计的时间粒度
+	bucketSize = time.Minute
+	// bucketCount 保留的时间桶数量（24 小时）
+	bucketCount = 24 * 60
+)
+
+// Recorder 工具调用成本的外部记录器，例如 Prometheus 计数器
+type Recorder interface {
+	AddToolCallCost(server, tool string, cost float64)
+}
+
+// Summary 成本汇总
+type Summary struct {
+	Total    float64                       `json:"total"`
+	LastHour float64                       `json:"lastHour"`
+	LastDay  float64                       `json:"lastDay"`
+	Servers  map[string]float64            `json:"servers"`
+	Tools    map[string]map[string]float64 `json:"tools"`
+}
+
+// bucket 单个时间桶
+type bucket struct {
+	start time.Time
+	total float64
+}
+
+// Tracker 工具调用成本统计
+type Tracker struct {
+	recorder Recorder
+	total    float64
+	servers  map[string]float64
+	tools    map[string]map[string]float64
+	buckets  [bucketCount]bucket
+	mutex    sync.Mutex
+}
+
+// NewTracker 创建新的成本统计器，recorder 可以为 nil
+func NewTracker(recorder Recorder) *Tracker {
+	return &Tracker{
+		recorder: recorder,
+		servers:  make(map[string]float64),
+		tools:    make(map[string]map[string]float64),
+	}
+}
+
+// Record 记录一次工具调用的成本
+func (t *Tracker) Record(server, tool string, cost float64) {
+	if t.recorder != nil {
+		t.recorder.AddToolCallCost(server, tool, cost)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.total += cost
+	t.servers[server] += cost
+	if t.tools[server] == nil {
+		t.tools[server] = make(map[string]float64)
+	}
+	t.tools[server][tool] += cost
+
+	start := time.Now().Truncate(bucketSize)
+	b := &t.buckets[start.Unix()/int64(bucketSize/time.Second)%bucketCount]
+	if !b.start.Equal(start) {
+		b.start = start
+		b.total = 0
+	}
+	b.total += cost
+}
+
+// Summary 获取成本汇总
+func (t *Tracker) Summary() Summary {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	summary := Summary{
+		Total:   t.total,
+		Servers: make(map[string]float64, len(t.servers)),
+		Tools:   make(map[string]map[string]float64, len(t.tools)),
+	}
+	for server, cost := range t.servers {
+		summary.Servers[server] = cost
+	}
+	for server, tools := range t.tools {
+		summary.Tools[server] = make(map[string]float64, len(tools))
+		for tool, cost := range tools {
+			summary.Tools[server][tool] = cost
+		}
+	}
+
+	now := time.Now()
+	for _, b := range t.buckets {
+		if b.start.IsZero() {
+			continue
+		}
+		age := now.Sub(b.start)
+		if age < 24*time.Hour {
+			summary.LastDay += b.total
+		}
+		if age < time.Hour {
+			summary.LastHour += b.total
+		}
+	}
+
+	return summary
+}
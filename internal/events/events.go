@@ -0,0 +1,168 @@
+// Package events 工具调用生命周期与客户端连接状态事件总线
+package events
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// 工具调用事件类型
+const (
+	EventCallStart   = "call_start"
+	EventCallSuccess = "call_success"
+	EventCallError   = "call_error"
+	EventCallTimeout = "call_timeout"
+)
+
+// defaultBufferSize 每个订阅者的默认缓冲区大小
+const defaultBufferSize = 256
+
+// ToolCallEvent 工具调用生命周期事件
+type ToolCallEvent struct {
+	EventType  string `json:"eventType"`
+	ServerName string `json:"serverName"`
+	ToolName   string `json:"toolName"`
+	RequestID  string `json:"requestId,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// EventBus 事件总线接口
+type EventBus interface {
+	// Publish 发布事件，不阻塞调用方
+	Publish(event ToolCallEvent)
+	// Subscribe 订阅事件，返回的通道在总线关闭时关闭
+	Subscribe() <-chan ToolCallEvent
+	// PublishStateChange 发布客户端连接状态变化，不阻塞调用方
+	PublishStateChange(change interfaces.StateChange)
+	// SubscribeStateChanges 订阅客户端连接状态变化，调用返回的函数取消订阅并关闭通道
+	SubscribeStateChanges() (<-chan interfaces.StateChange, func())
+}
+
+// Bus 基于带缓冲通道的事件总线实现
+//
+// 发布使用非阻塞发送，订阅者缓冲区已满时丢弃该事件，慢订阅者不会拖慢工具调用。
+type Bus struct {
+	bufferSize   int
+	toolCalls    []chan ToolCallEvent
+	stateChanges []chan interfaces.StateChange
+	mutex        sync.RWMutex
+	closed       bool
+	dropped      atomic.Int64
+}
+
+// NewBus 创建新的事件总线，bufferSize 为每个订阅者的缓冲区大小，不大于 0 时使用默认值
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &Bus{
+		bufferSize: bufferSize,
+	}
+}
+
+// Publish 向所有订阅者发布工具调用事件
+func (b *Bus) Publish(event ToolCallEvent) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.closed {
+		return
+	}
+	for _, subscriber := range b.toolCalls {
+		send(b, subscriber, event)
+	}
+}
+
+// Subscribe 订阅工具调用事件
+func (b *Bus) Subscribe() <-chan ToolCallEvent {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	subscriber := make(chan ToolCallEvent, b.bufferSize)
+	if b.closed {
+		close(subscriber)
+		return subscriber
+	}
+	b.toolCalls = append(b.toolCalls, subscriber)
+	return subscriber
+}
+
+// PublishStateChange 向所有订阅者发布客户端连接状态变化
+func (b *Bus) PublishStateChange(change interfaces.StateChange) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.closed {
+		return
+	}
+	for _, subscriber := range b.stateChanges {
+		send(b, subscriber, change)
+	}
+}
+
+// SubscribeStateChanges 订阅客户端连接状态变化
+func (b *Bus) SubscribeStateChanges() (<-chan interfaces.StateChange, func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	subscriber := make(chan interfaces.StateChange, b.bufferSize)
+	if b.closed {
+		close(subscriber)
+		return subscriber, func() {}
+	}
+	b.stateChanges = append(b.stateChanges, subscriber)
+
+	var once sync.Once
+	return subscriber, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			for i, s := range b.stateChanges {
+				if s == subscriber {
+					b.stateChanges = append(b.stateChanges[:i], b.stateChanges[i+1:]...)
+					close(subscriber)
+					return
+				}
+			}
+		})
+	}
+}
+
+// send 非阻塞发送，订阅者缓冲区已满时丢弃事件
+func send[T any](b *Bus, subscriber chan T, event T) {
+	select {
+	case subscriber <- event:
+	default:
+		if b.dropped.Add(1)%1000 == 1 {
+			slog.Warn("Event subscriber is falling behind", "dropped", b.dropped.Load())
+		}
+	}
+}
+
+// Dropped 返回因订阅者缓冲区已满而丢弃的事件数
+func (b *Bus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Close 关闭总线及所有订阅通道
+func (b *Bus) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, subscriber := range b.toolCalls {
+		close(subscriber)
+	}
+	for _, subscriber := range b.stateChanges {
+		close(subscriber)
+	}
+	b.toolCalls = nil
+	b.stateChanges = nil
+}
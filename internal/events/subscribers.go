@@ -0,0 +1,59 @@
+package events
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// ToolCallRecorder 记录工具调用结果的指标接口
+type ToolCallRecorder interface {
+	// RecordToolCall 记录一次已结束的工具调用，status 为 success、error 或 timeout
+	RecordToolCall(server, tool, status string, duration time.Duration)
+}
+
+// ClientStateRecorder 记录客户端连接状态的指标接口
+type ClientStateRecorder interface {
+	// SetClientConnected 记录客户端当前是否已连接
+	SetClientConnected(server string, connected bool)
+}
+
+// StartAuditLogger 订阅总线并记录每个结束的工具调用，总线关闭时退出
+func StartAuditLogger(bus EventBus) {
+	events := bus.Subscribe()
+	go func() {
+		for event := range events {
+			switch event.EventType {
+			case EventCallSuccess:
+				slog.Info("Audit: tool call succeeded", "server", event.ServerName, "tool", event.ToolName, "duration", time.Duration(event.DurationMs)*time.Millisecond, "request_id", event.RequestID)
+			case EventCallError, EventCallTimeout:
+				slog.Warn("Audit: tool call failed", "server", event.ServerName, "tool", event.ToolName, "event", event.EventType, "duration", time.Duration(event.DurationMs)*time.Millisecond, "request_id", event.RequestID, "error", event.Error)
+			}
+		}
+	}()
+}
+
+// StartMetricsCollector 订阅总线并将结束的工具调用记录到指标，总线关闭时退出
+func StartMetricsCollector(bus EventBus, recorder ToolCallRecorder) {
+	events := bus.Subscribe()
+	go func() {
+		for event := range events {
+			if event.EventType == EventCallStart {
+				continue
+			}
+			recorder.RecordToolCall(event.ServerName, event.ToolName, strings.TrimPrefix(event.EventType, "call_"), time.Duration(event.DurationMs)*time.Millisecond)
+		}
+	}()
+}
+
+// StartClientStateCollector 订阅客户端连接状态变化并记录到指标，总线关闭时退出
+func StartClientStateCollector(bus EventBus, recorder ClientStateRecorder) {
+	changes, _ := bus.SubscribeStateChanges()
+	go func() {
+		for change := range changes {
+			recorder.SetClientConnected(change.Client, change.To == interfaces.ClientStateConnected)
+		}
+	}()
+}
@@ -32,6 +32,10 @@ type MCPClient interface {
 	GetType() string
 	// IsConnected 检查连接状态
 	IsConnected() bool
+	// GetState 获取连接状态
+	GetState() ClientState
+	// GetServerInfo 获取上游在初始化时返回的服务器名称和版本
+	GetServerInfo() mcp.Implementation
 	// NeedsPing 是否需要定期 ping
 	NeedsPing() bool
 	// Ping 发送 ping 消息
@@ -62,6 +66,8 @@ type ConfigProvider interface {
 	Load(path string) (*Config, error)
 	// Validate 验证配置
 	Validate(config *Config) error
+	// PrepareServer 为运行时添加的服务器设置默认值并验证
+	PrepareServer(config *Config, name string, serverConfig ServerConfig) (ServerConfig, error)
 }
 
 // TransportFactory 定义传输工厂接口
@@ -112,9 +118,15 @@ type ClientManager interface {
 	GetClient(name string) MCPClient
 	// GetClients 获取所有客户端
 	GetClients() map[string]MCPClient
-	// StartAll 启动所有客户端
-	StartAll(ctx context.Context, clientInfo mcp.Implementation) error
-	// StopAll 停止所有客户端
+	// GetConnectedClients 获取已连接的客户端
+	GetConnectedClients() map[string]MCPClient
+	// GetDisconnectedClients 获取已配置但未连接的客户端
+	GetDisconnectedClients() map[string]MCPClient
+	// GetClientStats 获取客户端统计信息
+	GetClientStats() map[string]map[string]interface{}
+	// SetStopTimeout 设置停止客户端时的超时时间
+	SetStopTimeout(name string, timeout time.Duration)
+	// StopAll 停止所有客户端，返回合并后的全部停止错误
 	StopAll() error
 }
 
@@ -123,43 +135,224 @@ type ClientManager interface {
 // Config 主配置
 type Config struct {
 	Proxy   ProxyConfig             `json:"proxy"`
+	Proxies []ProxyConfig           `json:"proxies,omitempty"`
 	Servers map[string]ServerConfig `json:"servers"`
 }
 
+// ProxyFor 返回服务器所属的代理配置，servedBy 为空或不匹配任何 proxies 时返回主代理 proxy
+func (c *Config) ProxyFor(serverConfig ServerConfig) *ProxyConfig {
+	for i := range c.Proxies {
+		if serverConfig.ServedBy != "" && c.Proxies[i].Name == serverConfig.ServedBy {
+			return &c.Proxies[i]
+		}
+	}
+	return &c.Proxy
+}
+
 // ProxyConfig 代理配置
 type ProxyConfig struct {
-	BaseURL string         `json:"baseURL"`
-	Addr    string         `json:"addr"`
-	Name    string         `json:"name"`
-	Version string         `json:"version"`
-	Type    string         `json:"type"`
-	Options *OptionsConfig `json:"options,omitempty"`
+	BaseURL             string              `json:"baseURL"`
+	Addr                string              `json:"addr"`
+	Name                string              `json:"name"`
+	Version             string              `json:"version"`
+	Type                string              `json:"type"`
+	Options             *OptionsConfig      `json:"options,omitempty"`
+	MetricsPrefix       string              `json:"metricsPrefix,omitempty"`
+	StrictSchema        *bool               `json:"strictSchema,omitempty"`
+	AdminAddr           string              `json:"adminAddr,omitempty"`
+	AdminAuthTokens     []string            `json:"adminAuthTokens,omitempty"`
+	AdminBasePath       string              `json:"adminBasePath,omitempty"`
+	ClientInfoName      string              `json:"clientInfoName,omitempty"`
+	ClientInfoVersion   string              `json:"clientInfoVersion,omitempty"`
+	CONNECTProxy        *bool               `json:"connectProxy,omitempty"`
+	FanOutGroups        map[string][]string `json:"fanOutGroups,omitempty"`
+	FanOutTimeout       string              `json:"fanOutTimeout,omitempty"`
+	MaxOpenFDs          int                 `json:"maxOpenFDs,omitempty"`
+	StrictConfig        *bool               `json:"strictConfig,omitempty"`
+	Stateful            *bool               `json:"stateful,omitempty"`
+	MaxSSEConnections   int                 `json:"maxSSEConnections,omitempty"`
+	SSEMaxConnectionAge string              `json:"sseMaxConnectionAge,omitempty"`
+	ReadyzPingUpstreams *bool               `json:"readyzPingUpstreams,omitempty"`
+	ReadyzPingTimeout   string              `json:"readyzPingTimeout,omitempty"`
+	MetricsPath         string              `json:"metricsPath,omitempty"`
+	LogFormat           string              `json:"logFormat,omitempty"`
+	HealthPath          string              `json:"healthPath,omitempty"`
+	TLS                 *TLSConfig          `json:"tls,omitempty"`
+	ServerTimeouts      *ServerTimeouts     `json:"serverTimeouts,omitempty"`
+	EnablePprof         *bool               `json:"enablePprof,omitempty"`
+	PprofAddr           string              `json:"pprofAddr,omitempty"`
+}
+
+// ServerTimeouts 代理 HTTP 服务器的超时配置，显式配置为 0 表示不限制
+type ServerTimeouts struct {
+	ReadTimeout       string `json:"readTimeout,omitempty"`
+	WriteTimeout      string `json:"writeTimeout,omitempty"`
+	IdleTimeout       string `json:"idleTimeout,omitempty"`
+	ReadHeaderTimeout string `json:"readHeaderTimeout,omitempty"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Transport string            `json:"transport"`
-	Command   string            `json:"command,omitempty"`
-	Args      []string          `json:"args,omitempty"`
-	Env       map[string]string `json:"env,omitempty"`
-	URL       string            `json:"url,omitempty"`
-	Headers   map[string]string `json:"headers,omitempty"`
-	Timeout   time.Duration     `json:"timeout,omitempty"`
-	Options   *OptionsConfig    `json:"options,omitempty"`
+	Transport              string                `json:"transport"`
+	ServedBy               string                `json:"servedBy,omitempty"`
+	Command                string                `json:"command,omitempty"`
+	Args                   []string              `json:"args,omitempty"`
+	Env                    map[string]string     `json:"env,omitempty"`
+	InheritEnv             *bool                 `json:"inheritEnv,omitempty"`
+	WorkDir                string                `json:"workDir,omitempty"`
+	URL                    string                `json:"url,omitempty"`
+	URLs                   []string              `json:"urls,omitempty"`
+	LoadBalance            string                `json:"loadBalance,omitempty"`
+	MinHealthy             int                   `json:"minHealthy,omitempty"`
+	Headers                map[string]string     `json:"headers,omitempty"`
+	Timeout                time.Duration         `json:"timeout,omitempty"`
+	DefaultToolTimeout     string                `json:"defaultToolTimeout,omitempty"`
+	Options                *OptionsConfig        `json:"options,omitempty"`
+	GRPCHealthTarget       string                `json:"grpcHealthTarget,omitempty"`
+	ProtocolVersion        string                `json:"protocolVersion,omitempty"`
+	CircuitBreaker         *CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+	TracingEnabled         *bool                 `json:"tracingEnabled,omitempty"`
+	MaxIdleConns           int                   `json:"maxIdleConns,omitempty"`
+	MaxConnsPerHost        int                   `json:"maxConnsPerHost,omitempty"`
+	IdleConnTimeout        string                `json:"idleConnTimeout,omitempty"`
+	StdioKeepaliveInterval string                `json:"stdioKeepaliveInterval,omitempty"`
+	StderrLogLevel         string                `json:"stderrLogLevel,omitempty"`
+	AutoRestart            *bool                 `json:"autoRestart,omitempty"`
+	MaxRestarts            int                   `json:"maxRestarts,omitempty"`
+	AuthToken              string                `json:"authToken,omitempty"`
+	AuthScheme             string                `json:"authScheme,omitempty"`
+	OAuth2ClientID         string                `json:"oauth2ClientID,omitempty"`
+	OAuth2ClientSecret     string                `json:"oauth2ClientSecret,omitempty"`
+	OAuth2TokenURL         string                `json:"oauth2TokenURL,omitempty"`
+	OAuth2Scopes           []string              `json:"oauth2Scopes,omitempty"`
+	ConnectTimeout         string                `json:"connectTimeout,omitempty"`
+	ConnectTimeoutBehavior string                `json:"connectTimeoutBehavior,omitempty"`
+	LogTag                 string                `json:"logTag,omitempty"`
+	Optional               *bool                 `json:"optional,omitempty"`
+	PingInterval           string                `json:"pingInterval,omitempty"`
+	TLS                    *ClientTLSConfig      `json:"tls,omitempty"`
+	Retry                  *RetryConfig          `json:"retry,omitempty"`
+}
+
+// RetryConfig 工具调用遇到网络错误时的重试配置，重试间隔每次翻倍
+type RetryConfig struct {
+	MaxAttempts  int    `json:"maxAttempts,omitempty"`
+	InitialDelay string `json:"initialDelay,omitempty"`
+	MaxDelay     string `json:"maxDelay,omitempty"`
+}
+
+// ServerLogTag 返回服务器日志行使用的前缀，未配置 logTag 时使用服务器名称
+func ServerLogTag(name string, config ServerConfig) string {
+	if config.LogTag != "" {
+		return config.LogTag
+	}
+	return name
 }
 
 // OptionsConfig 选项配置
 type OptionsConfig struct {
-	PanicIfInvalid *bool             `json:"panicIfInvalid,omitempty"`
-	LogEnabled     *bool             `json:"logEnabled,omitempty"`
-	AuthTokens     []string          `json:"authTokens,omitempty"`
-	ToolFilter     *ToolFilterConfig `json:"toolFilter,omitempty"`
+	PanicIfInvalid            *bool                      `json:"panicIfInvalid,omitempty"`
+	LogEnabled                *bool                      `json:"logEnabled,omitempty"`
+	AuthTokens                []string                   `json:"authTokens,omitempty"`
+	ToolFilter                *ToolFilterConfig          `json:"toolFilter,omitempty"`
+	PromptFilter              *ToolFilterConfig          `json:"promptFilter,omitempty"`
+	ResourceFilter            *ToolFilterConfig          `json:"resourceFilter,omitempty"`
+	ToolTimeoutFallbacks      map[string]string          `json:"toolTimeoutFallbacks,omitempty"`
+	ToolTimeout               map[string]string          `json:"toolTimeout,omitempty"`
+	ListPageConcurrency       int                        `json:"listPageConcurrency,omitempty"`
+	ToolCostWeights           map[string]float64         `json:"toolCostWeights,omitempty"`
+	InjectClaimsAsArgs        []string                   `json:"injectClaimsAsArgs,omitempty"`
+	InjectClaimsPrefix        string                     `json:"injectClaimsPrefix,omitempty"`
+	ToolsDiscoveryEnabled     *bool                      `json:"toolsDiscoveryEnabled,omitempty"`
+	StrictToolNames           *bool                      `json:"strictToolNames,omitempty"`
+	SanitizeToolNames         *bool                      `json:"sanitizeToolNames,omitempty"`
+	MaxToolArgBytes           int64                      `json:"maxToolArgBytes,omitempty"`
+	MaxBodyBytes              int64                      `json:"maxBodyBytes,omitempty"`
+	Middlewares               []MiddlewareInstanceConfig `json:"middlewares,omitempty"`
+	ToolGracePeriod           string                     `json:"toolGracePeriod,omitempty"`
+	MaxToolResultBytes        int64                      `json:"maxToolResultBytes,omitempty"`
+	MaxToolResultPreviewBytes int64                      `json:"maxToolResultPreviewBytes,omitempty"`
+	ResponseHeaders           map[string]string          `json:"responseHeaders,omitempty"`
+	DebugMode                 *bool                      `json:"debugMode,omitempty"`
+	PropagateRequestID        *bool                      `json:"propagateRequestID,omitempty"`
+	LogOutput                 string                     `json:"logOutput,omitempty"`
+	LogRotateSizeMB           int                        `json:"logRotateSizeMB,omitempty"`
+	SyslogFacility            string                     `json:"syslogFacility,omitempty"`
+	SyslogSeverity            string                     `json:"syslogSeverity,omitempty"`
+	ProgressEventThreshold    string                     `json:"progressEventThreshold,omitempty"`
+	ProgressEventInterval     string                     `json:"progressEventInterval,omitempty"`
+	AllowedContentTypes       []string                   `json:"allowedContentTypes,omitempty"`
+	StopTimeout               string                     `json:"stopTimeout,omitempty"`
+	ServerTimingEnabled       *bool                      `json:"serverTimingEnabled,omitempty"`
+	JWT                       *JWTConfig                 `json:"jwt,omitempty"`
+	RateLimit                 *RateLimitConfig           `json:"rateLimit,omitempty"`
+	CORS                      *CORSConfig                `json:"cors,omitempty"`
+	ToolCache                 *ToolCacheConfig           `json:"toolCache,omitempty"`
+	ToolNamePrefix            string                     `json:"toolNamePrefix,omitempty"`
+	PromptNamePrefix          string                     `json:"promptNamePrefix,omitempty"`
+	ResourceNamePrefix        string                     `json:"resourceNamePrefix,omitempty"`
+}
+
+// ToolCacheConfig 工具调用结果缓存配置，Tools 按工具名覆盖 TTL，值为 0 时不缓存该工具
+type ToolCacheConfig struct {
+	TTL        string            `json:"ttl"`
+	MaxEntries int               `json:"maxEntries,omitempty"`
+	Tools      map[string]string `json:"tools,omitempty"`
+}
+
+// RateLimitConfig 令牌桶限流配置
+type RateLimitConfig struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst,omitempty"`
+}
+
+// CORSConfig 跨域资源共享配置，AllowOrigins 包含 * 时允许任意来源
+type CORSConfig struct {
+	AllowOrigins []string `json:"allowOrigins"`
+	AllowMethods []string `json:"allowMethods,omitempty"`
+	AllowHeaders []string `json:"allowHeaders,omitempty"`
+	MaxAge       int      `json:"maxAge,omitempty"`
+}
+
+// JWTConfig JWT 认证配置
+type JWTConfig struct {
+	Issuer              string   `json:"issuer,omitempty"`
+	Audience            string   `json:"audience,omitempty"`
+	JWKSURL             string   `json:"jwksURL"`
+	Algorithm           string   `json:"algorithm,omitempty"`
+	JWKSRefreshInterval string   `json:"jwksRefreshInterval,omitempty"`
+	Claims              []string `json:"claims,omitempty"`
 }
 
 // ToolFilterConfig 工具过滤配置
 type ToolFilterConfig struct {
-	Mode string   `json:"mode,omitempty"`
-	List []string `json:"list,omitempty"`
+	Mode                string   `json:"mode,omitempty"`
+	List                []string `json:"list,omitempty"`
+	ListURL             string   `json:"listURL,omitempty"`
+	ListRefreshInterval string   `json:"listRefreshInterval,omitempty"`
+}
+
+// TLSConfig 代理监听的 TLS 配置，设置 CAFile 时要求并校验客户端证书
+type TLSConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	CAFile   string `json:"caFile,omitempty"`
+}
+
+// ClientTLSConfig 连接上游 SSE 和 Streamable HTTP 服务器的 TLS 配置
+type ClientTLSConfig struct {
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+}
+
+// CircuitBreakerConfig 熔断器配置
+type CircuitBreakerConfig struct {
+	Threshold        int    `json:"threshold,omitempty"`
+	OpenDuration     string `json:"openDuration,omitempty"`
+	HalfOpenProbes   int    `json:"halfOpenProbes,omitempty"`
+	HalfOpenInterval string `json:"halfOpenInterval,omitempty"`
 }
 
 // TransportConfig 传输配置
@@ -175,6 +368,13 @@ type MiddlewareConfig struct {
 	Options map[string]interface{} `json:"options,omitempty"`
 }
 
+// MiddlewareInstanceConfig 中间件实例配置，同一类型可以以不同 ID 出现多次
+type MiddlewareInstanceConfig struct {
+	ID      string                 `json:"id"`
+	Type    string                 `json:"type"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
 // 常量定义
 
 // 传输类型
@@ -193,9 +393,16 @@ const (
 
 // 中间件类型
 const (
-	MiddlewareTypeAuth     = "auth"
-	MiddlewareTypeLogger   = "logger"
-	MiddlewareTypeRecovery = "recovery"
+	MiddlewareTypeAuth         = "auth"
+	MiddlewareTypeLogger       = "logger"
+	MiddlewareTypeRecovery     = "recovery"
+	MiddlewareTypeTracing      = "tracing"
+	MiddlewareTypeServerTiming = "servertiming"
+	MiddlewareTypeJWT          = "jwt"
+	MiddlewareTypeCORS         = "cors"
+	MiddlewareTypeRequestID    = "requestid"
+	MiddlewareTypeBodyLimit    = "bodylimit"
+	MiddlewareTypeRateLimit    = "ratelimit"
 )
 
 // 工具过滤模式
@@ -203,3 +410,46 @@ const (
 	ToolFilterModeAllow = "allow"
 	ToolFilterModeBlock = "block"
 )
+
+// 连接超时后的处理方式
+const (
+	ConnectTimeoutBehaviorSkip  = "skip"
+	ConnectTimeoutBehaviorFatal = "fatal"
+	ConnectTimeoutBehaviorRetry = "retry"
+)
+
+// 多个上游地址的负载均衡策略
+const (
+	LoadBalanceRoundRobin       = "round-robin"
+	LoadBalanceLeastConnections = "least-connections"
+)
+
+// ClientState 客户端连接状态
+type ClientState string
+
+// 客户端连接状态
+const (
+	ClientStateDisconnected ClientState = "disconnected"
+	ClientStateConnecting   ClientState = "connecting"
+	ClientStateInitializing ClientState = "initializing"
+	ClientStateConnected    ClientState = "connected"
+	ClientStateReconnecting ClientState = "reconnecting"
+	ClientStateFailed       ClientState = "failed"
+)
+
+// StateChange 客户端连接状态变化事件
+type StateChange struct {
+	Client string      `json:"client"`
+	From   ClientState `json:"from"`
+	To     ClientState `json:"to"`
+	Time   time.Time   `json:"time"`
+}
+
+// DefaultPingInterval SSE 和 Streamable 客户端未配置 pingInterval 时的 ping 间隔
+const DefaultPingInterval = 30 * time.Second
+
+// DefaultAdminBasePath 管理 API 的默认路由前缀
+const DefaultAdminBasePath = "/admin"
+
+// FanOutServerName 扇出工具所在虚拟服务器的名称（路由前缀）
+const FanOutServerName = "fanout"
@@ -0,0 +1,135 @@
+// Package logging 日志输出目标：标准输出、标准错误、文件（可按大小轮转）和 syslog
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// 日志输出目标
+const (
+	OutputStderr = "stderr"
+	OutputStdout = "stdout"
+	OutputSyslog = "syslog"
+	// OutputFilePrefix 文件输出的前缀，形如 file:/var/log/mcp-proxy.log
+	OutputFilePrefix = "file:"
+)
+
+// 日志格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// 默认的 syslog 设施与级别
+const (
+	DefaultSyslogFacility = "daemon"
+	DefaultSyslogSeverity = "info"
+)
+
+// syslogFacilities syslog 设施名称到编号（RFC 5424）
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverities syslog 级别名称到编号（RFC 5424）
+var syslogSeverities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3, "warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// Options 日志输出配置
+type Options struct {
+	// Output 输出目标，为空时使用 stderr
+	Output string
+	// RotateSizeMB 文件输出的轮转大小，0 表示不轮转
+	RotateSizeMB int
+	// SyslogFacility syslog 设施，为空时使用 daemon
+	SyslogFacility string
+	// SyslogSeverity syslog 级别，为空时使用 info
+	SyslogSeverity string
+	// SyslogTag syslog 标签
+	SyslogTag string
+}
+
+// Validate 校验输出目标及 syslog 设施和级别
+func Validate(opts Options) error {
+	switch {
+	case opts.Output == "", opts.Output == OutputStderr, opts.Output == OutputStdout, opts.Output == OutputSyslog:
+	case strings.HasPrefix(opts.Output, OutputFilePrefix):
+		if strings.TrimPrefix(opts.Output, OutputFilePrefix) == "" {
+			return fmt.Errorf("log output %q has no file path", opts.Output)
+		}
+	default:
+		return fmt.Errorf("unsupported log output %q, expected stderr, stdout, file:<path> or syslog", opts.Output)
+	}
+
+	if opts.RotateSizeMB < 0 {
+		return fmt.Errorf("logRotateSizeMB must not be negative")
+	}
+	if _, err := syslogPriority(opts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Open 打开日志输出目标，返回的 Closer 在不再写日志时关闭
+func Open(opts Options) (io.Writer, io.Closer, error) {
+	if err := Validate(opts); err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case opts.Output == "", opts.Output == OutputStderr:
+		return os.Stderr, io.NopCloser(nil), nil
+	case opts.Output == OutputStdout:
+		return os.Stdout, io.NopCloser(nil), nil
+	case opts.Output == OutputSyslog:
+		priority, _ := syslogPriority(opts)
+		writer, err := openSyslog(priority, opts.SyslogTag)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		return writer, writer, nil
+	default:
+		file, err := openRotatingFile(strings.TrimPrefix(opts.Output, OutputFilePrefix), int64(opts.RotateSizeMB)<<20)
+		if err != nil {
+			return nil, nil, err
+		}
+		return file, file, nil
+	}
+}
+
+// syslogPriority 计算 syslog 优先级 facility<<3 | severity
+func syslogPriority(opts Options) (int, error) {
+	facilityName := strings.ToLower(opts.SyslogFacility)
+	if facilityName == "" {
+		facilityName = DefaultSyslogFacility
+	}
+	severityName := strings.ToLower(opts.SyslogSeverity)
+	if severityName == "" {
+		severityName = DefaultSyslogSeverity
+	}
+
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility %q", opts.SyslogFacility)
+	}
+	severity, ok := syslogSeverities[severityName]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog severity %q", opts.SyslogSeverity)
+	}
+	return facility<<3 | severity, nil
+}
+
+// NewHandler 按日志格式创建 slog 处理器，未设置格式时使用文本格式
+func NewHandler(format string, writer io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	if format == FormatJSON {
+		return slog.NewJSONHandler(writer, opts)
+	}
+	return slog.NewTextHandler(writer, opts)
+}
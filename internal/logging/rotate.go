@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// rotatingFile 以追加模式写入的日志文件，超过 maxBytes 时重命名为带时间戳的备份并重新打开
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+	mutex    sync.Mutex
+}
+
+// openRotatingFile 以追加模式打开日志文件，maxBytes 为 0 时不轮转
+func openRotatingFile(path string, maxBytes int64) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxBytes: maxBytes}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open 打开日志文件并记录当前大小
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", f.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file %s: %w", f.path, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write 写入日志，写入后超过大小上限时轮转
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			// 轮转失败时继续写入当前文件，不丢日志
+			fmt.Fprintf(os.Stderr, "failed to rotate log file %s: %v\n", f.path, err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate 将当前文件重命名为 <path>.<时间戳> 并打开新文件
+//
+// 新文件打开成功后才关闭原句柄；重命名或打开失败时继续写入原句柄，不丢日志。
+func (f *rotatingFile) rotate() error {
+	backup := f.path + "." + time.Now().Format("20060102-150405.000")
+	if err := os.Rename(f.path, backup); err != nil {
+		return err
+	}
+	previous := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	return previous.Close()
+}
+
+// Close 关闭日志文件
+func (f *rotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.file.Close()
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFileKeepsAllLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxy.log")
+	f, err := openRotatingFile(path, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := strings.Repeat("x", 39) + "\n"
+	for i := 0; i < 2; i++ {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二次写入超过上限触发轮转，备份和新文件各保留一行
+	matches, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("found %d log files, want 2: %v", len(matches), matches)
+	}
+	var total int
+	for _, match := range matches {
+		data, err := os.ReadFile(match)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += strings.Count(string(data), "\n")
+	}
+	if total != 2 {
+		t.Errorf("found %d lines across log files, want 2", total)
+	}
+}
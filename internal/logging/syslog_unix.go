@@ -0,0 +1,13 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// openSyslog 连接本机 syslog
+func openSyslog(priority int, tag string) (io.WriteCloser, error) {
+	return syslog.New(syslog.Priority(priority), tag)
+}
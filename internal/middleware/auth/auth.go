@@ -1,49 +1,81 @@
 package auth
 
 import (
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/servertiming"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Middleware 认证中间件实现
 type Middleware struct {
 	tokens map[string]struct{}
+	hashes [][]byte
+	// verified 已通过 bcrypt 校验的 token，避免重复计算哈希
+	verified sync.Map
 }
 
-// New 创建新的认证中间件
+func init() {
+	// tokens 选项为字符串列表，格式错误时返回 nil，避免误建不校验的认证中间件
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeAuth, func(options map[string]interface{}) interfaces.Middleware {
+		tokens, err := registry.StringSliceOption(options, "tokens")
+		if err != nil {
+			slog.Error("Invalid auth middleware options", "server", registry.StringOption(options, registry.OptionName), "error", err)
+			return nil
+		}
+		return New(tokens)
+	})
+}
+
+// New 创建新的认证中间件，以 $2a$/$2b$/$2y$ 开头的 token 视为 bcrypt 哈希
 func New(tokens []string) interfaces.Middleware {
 	tokenSet := make(map[string]struct{}, len(tokens))
+	var hashes [][]byte
 	for _, token := range tokens {
+		if isBcryptHash(token) {
+			hashes = append(hashes, []byte(token))
+			continue
+		}
 		tokenSet[token] = struct{}{}
 	}
 
 	return &Middleware{
 		tokens: tokenSet,
+		hashes: hashes,
 	}
 }
 
 // Handle 处理 HTTP 请求
 func (m *Middleware) Handle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if len(m.tokens) == 0 {
+		if len(m.tokens) == 0 && len(m.hashes) == 0 {
 			// 没有配置 token，直接通过
 			next.ServeHTTP(w, r)
 			return
 		}
 
+		start := time.Now()
+
 		// 获取 Authorization 头
 		token := r.Header.Get("Authorization")
 		token = strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
 
 		if token == "" {
+			servertiming.Record(r.Context(), "auth", time.Since(start))
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
 		// 验证 token
-		if _, ok := m.tokens[token]; !ok {
+		valid := m.verify(token)
+		servertiming.Record(r.Context(), "auth", time.Since(start))
+		if !valid {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -52,7 +84,30 @@ func (m *Middleware) Handle(next http.Handler) http.Handler {
 	})
 }
 
+// verify 校验 token 是否匹配明文或 bcrypt 哈希
+func (m *Middleware) verify(token string) bool {
+	if _, ok := m.tokens[token]; ok {
+		return true
+	}
+	if _, ok := m.verified.Load(token); ok {
+		return true
+	}
+
+	for _, hash := range m.hashes {
+		if bcrypt.CompareHashAndPassword(hash, []byte(token)) == nil {
+			m.verified.Store(token, struct{}{})
+			return true
+		}
+	}
+	return false
+}
+
 // GetName 获取中间件名称
 func (m *Middleware) GetName() string {
 	return "auth"
 }
+
+// isBcryptHash 判断 token 是否为 bcrypt 哈希
+func isBcryptHash(token string) bool {
+	return strings.HasPrefix(token, "$2a$") || strings.HasPrefix(token, "$2b$") || strings.HasPrefix(token, "$2y$")
+}
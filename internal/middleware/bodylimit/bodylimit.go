@@ -0,0 +1,80 @@
+package bodylimit
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+)
+
+// DefaultMaxBodyBytes 未配置 maxBodyBytes 时请求体的最大字节数
+const DefaultMaxBodyBytes int64 = 4 << 20
+
+// Middleware 请求体大小限制中间件实现
+//
+// 请求体通过 io.LimitedReader 最多读取 maxBytes+1 字节，超出时返回 413，
+// 避免客户端发送任意大的请求体耗尽代理内存；未超出时以已读取的内容替换请求体交给下游。
+type Middleware struct {
+	name     string
+	maxBytes int64
+}
+
+func init() {
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeBodyLimit, func(options map[string]interface{}) interfaces.Middleware {
+		return New(registry.StringOption(options, registry.OptionName), int64(registry.IntOption(options, "maxBodyBytes")))
+	})
+}
+
+// New 创建新的请求体大小限制中间件，maxBytes 不大于 0 时使用 DefaultMaxBodyBytes
+func New(name string, maxBytes int64) interfaces.Middleware {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	return &Middleware{name: name, maxBytes: maxBytes}
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// 声明的长度已超出时无需读取请求体
+		if r.ContentLength > m.maxBytes {
+			m.reject(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(&io.LimitedReader{R: r.Body, N: m.maxBytes + 1})
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > m.maxBytes {
+			m.reject(w, r)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "bodylimit"
+}
+
+// reject 返回 413 并关闭连接，避免继续接收剩余的请求体
+func (m *Middleware) reject(w http.ResponseWriter, r *http.Request) {
+	slog.WarnContext(r.Context(), "Rejected request, body too large", "server", m.name, "path", r.URL.Path, "limit", m.maxBytes)
+	w.Header().Set("Connection", "close")
+	http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+}
@@ -0,0 +1,76 @@
+package connlimit
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/middleware/retry"
+)
+
+// rejectRetryAfter 拒绝连接时建议客户端的重试间隔
+const rejectRetryAfter = time.Second
+
+// Middleware SSE 连接数限制中间件实现
+//
+// SSE 连接在整个会话期间占用一个请求，活动连接数达到上限时新连接直接返回 503，
+// 避免代理在连接洪泛下耗尽资源。
+type Middleware struct {
+	max      int64
+	active   atomic.Int64
+	onReject func()
+}
+
+// New 创建新的 SSE 连接数限制中间件，max 为 0 表示不限制，onReject 在拒绝连接时调用
+func New(max int, onReject func()) *Middleware {
+	return &Middleware{
+		max:      int64(max),
+		onReject: onReject,
+	}
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsSSERequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if active := m.active.Add(1); m.max > 0 && active > m.max {
+			m.active.Add(-1)
+			if m.onReject != nil {
+				m.onReject()
+			}
+			retry.WriteError(w, r, http.StatusServiceUnavailable, "too many SSE connections", rejectRetryAfter)
+			return
+		}
+		defer m.active.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "connlimit"
+}
+
+// Active 获取当前活动的 SSE 连接数
+func (m *Middleware) Active() int64 {
+	return m.active.Load()
+}
+
+// Max 获取 SSE 连接数上限，0 表示不限制
+func (m *Middleware) Max() int64 {
+	return m.max
+}
+
+// IsSSERequest 判断请求是否建立 SSE 长连接
+func IsSSERequest(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	return strings.HasSuffix(r.URL.Path, "/sse") || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
@@ -0,0 +1,130 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+)
+
+// AllowAllOrigins 允许任意来源
+const AllowAllOrigins = "*"
+
+// defaultAllowMethods 未配置 allowMethods 时允许的方法，覆盖 SSE 和 Streamable HTTP 的请求
+var defaultAllowMethods = []string{http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodOptions}
+
+// exposeHeaders 浏览器客户端需要读取的响应头，Streamable HTTP 通过 Mcp-Session-Id 维持会话
+const exposeHeaders = "Mcp-Session-Id"
+
+// Middleware CORS 中间件实现
+type Middleware struct {
+	allowAll     bool
+	origins      map[string]struct{}
+	allowMethods string
+	allowHeaders string
+	maxAge       int
+}
+
+func init() {
+	// 选项与 CORSConfig 字段同名，格式错误时返回 nil
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeCORS, func(options map[string]interface{}) interfaces.Middleware {
+		var config interfaces.CORSConfig
+		var err error
+		if config.AllowOrigins, err = registry.StringSliceOption(options, "allowOrigins"); err != nil {
+			return nil
+		}
+		if config.AllowMethods, err = registry.StringSliceOption(options, "allowMethods"); err != nil {
+			return nil
+		}
+		if config.AllowHeaders, err = registry.StringSliceOption(options, "allowHeaders"); err != nil {
+			return nil
+		}
+		config.MaxAge = registry.IntOption(options, "maxAge")
+		return New(config)
+	})
+}
+
+// New 创建新的 CORS 中间件，allowOrigins 包含 * 时允许任意来源，未配置 allowHeaders 时回显预检请求的头
+func New(config interfaces.CORSConfig) interfaces.Middleware {
+	m := &Middleware{
+		origins:      make(map[string]struct{}, len(config.AllowOrigins)),
+		allowMethods: strings.Join(defaultAllowMethods, ", "),
+		allowHeaders: strings.Join(config.AllowHeaders, ", "),
+		maxAge:       config.MaxAge,
+	}
+	for _, origin := range config.AllowOrigins {
+		if origin == AllowAllOrigins {
+			m.allowAll = true
+		}
+		m.origins[origin] = struct{}{}
+	}
+	if len(config.AllowMethods) > 0 {
+		m.allowMethods = strings.Join(config.AllowMethods, ", ")
+	}
+	return m
+}
+
+// Handle 处理 HTTP 请求，预检请求直接返回 204，不再经过后续的认证等中间件
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed := m.setAllowOrigin(w.Header(), origin)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed {
+				m.setPreflightHeaders(w.Header(), r)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setAllowOrigin 来源被允许时设置 Access-Control-Allow-Origin，返回来源是否被允许
+func (m *Middleware) setAllowOrigin(header http.Header, origin string) bool {
+	if m.allowAll {
+		header.Set("Access-Control-Allow-Origin", AllowAllOrigins)
+		return true
+	}
+
+	// 响应随 Origin 变化，避免缓存将一个来源的响应用于另一个来源
+	header.Add("Vary", "Origin")
+	if _, ok := m.origins[origin]; !ok {
+		return false
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	return true
+}
+
+// setPreflightHeaders 设置预检响应的方法、请求头和缓存时间
+func (m *Middleware) setPreflightHeaders(header http.Header, r *http.Request) {
+	header.Set("Access-Control-Allow-Methods", m.allowMethods)
+
+	allowHeaders := m.allowHeaders
+	if allowHeaders == "" {
+		allowHeaders = r.Header.Get("Access-Control-Request-Headers")
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+	if allowHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", allowHeaders)
+	}
+	if m.maxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(m.maxAge))
+	}
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "cors"
+}
@@ -0,0 +1,23 @@
+package jwtauth
+
+import "context"
+
+// claimsKey 上下文中 JWT 声明的键
+type claimsKey struct{}
+
+// WithClaims 将解析后的 JWT 声明存入上下文
+func WithClaims(ctx context.Context, claims map[string]interface{}) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext 从上下文中获取 JWT 声明，不存在时返回 nil
+func ClaimsFromContext(ctx context.Context) map[string]interface{} {
+	claims, _ := ctx.Value(claimsKey{}).(map[string]interface{})
+	return claims
+}
+
+// SubjectFromContext 从上下文中获取 JWT 的 sub 声明
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ClaimsFromContext(ctx)["sub"].(string)
+	return subject
+}
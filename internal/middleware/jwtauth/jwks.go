@@ -0,0 +1,211 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// jwksFetchTimeout 获取 JWKS 的超时时间
+	jwksFetchTimeout = 10 * time.Second
+	// unknownKeyRefreshInterval 遇到未知 kid 时重新获取 JWKS 的最小间隔，避免伪造的 kid 引发请求洪泛
+	unknownKeyRefreshInterval = time.Minute
+)
+
+// jsonWebKey JWKS 中的单个公钥，只解析 RSA 和 EC 公钥所需的字段
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet 从 JWKS URL 获取的公钥集合，按 TTL 在请求路径上异步刷新
+type keySet struct {
+	url        string
+	ttl        time.Duration
+	mutex      sync.RWMutex
+	keys       map[string]crypto.PublicKey
+	fetchedAt  time.Time
+	refreshing atomic.Bool
+}
+
+// newKeySet 创建公钥集合并同步获取一次 JWKS
+func newKeySet(url string, ttl time.Duration) (*keySet, error) {
+	ks := &keySet{url: url, ttl: ttl}
+	if err := ks.refresh(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// lookup 查找 kid 对应的公钥；kid 为空且只有一个公钥时返回该公钥
+func (ks *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// key 获取 kid 对应的公钥，不存在时按最小间隔同步刷新一次后重试
+func (ks *keySet) key(kid string) (crypto.PublicKey, bool) {
+	ks.maybeRefresh()
+	if key, ok := ks.lookup(kid); ok {
+		return key, true
+	}
+
+	ks.mutex.RLock()
+	stale := time.Since(ks.fetchedAt) >= unknownKeyRefreshInterval
+	ks.mutex.RUnlock()
+	if !stale || !ks.refreshing.CompareAndSwap(false, true) {
+		return nil, false
+	}
+	defer ks.refreshing.Store(false)
+	_ = ks.refresh()
+	return ks.lookup(kid)
+}
+
+// maybeRefresh 公钥超过 TTL 时在后台刷新，刷新期间继续使用旧的公钥
+func (ks *keySet) maybeRefresh() {
+	ks.mutex.RLock()
+	expired := time.Since(ks.fetchedAt) >= ks.ttl
+	ks.mutex.RUnlock()
+	if !expired || !ks.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer ks.refreshing.Store(false)
+		_ = ks.refresh()
+	}()
+}
+
+// refresh 获取并解析 JWKS，失败时保留原有公钥
+func (ks *keySet) refresh() error {
+	keys, err := fetchJWKS(ks.url)
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	// 失败时同样更新获取时间，避免每个请求都重新获取
+	ks.fetchedAt = time.Now()
+	if err != nil {
+		return err
+	}
+	ks.keys = keys
+	return nil
+}
+
+// fetchJWKS 获取 JWKS 并解析其中的 RSA 和 EC 签名公钥，跳过不支持的公钥
+func fetchJWKS(url string) (map[string]crypto.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: HTTP error: %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS at %s contains no usable signing keys", url)
+	}
+	return keys, nil
+}
+
+// publicKey 将 JWK 转换为 RSA 或 ECDSA 公钥
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, fmt.Errorf("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+}
+
+// decodeBigInt 解码 base64url 编码的大整数
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
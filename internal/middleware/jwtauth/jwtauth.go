@@ -0,0 +1,137 @@
+package jwtauth
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+)
+
+// defaultJWKSRefreshInterval JWKS 的默认刷新间隔
+const defaultJWKSRefreshInterval = time.Hour
+
+// Middleware JWT 认证中间件实现
+//
+// 校验 Authorization: Bearer <jwt> 的签名、有效期、签发者和受众，
+// 通过后将 sub 和配置的声明存入请求上下文，供下游通过 ClaimsFromContext 读取。
+type Middleware struct {
+	config interfaces.JWTConfig
+	claims []string
+	keys   *keySet
+}
+
+func init() {
+	// 选项与 JWTConfig 字段同名，创建失败时返回 nil
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeJWT, func(options map[string]interface{}) interfaces.Middleware {
+		name := registry.StringOption(options, registry.OptionName)
+		claims, err := registry.StringSliceOption(options, "claims")
+		if err != nil {
+			slog.Error("Invalid jwt middleware options", "server", name, "error", err)
+			return nil
+		}
+		middleware, err := New(interfaces.JWTConfig{
+			Issuer:              registry.StringOption(options, "issuer"),
+			Audience:            registry.StringOption(options, "audience"),
+			JWKSURL:             registry.StringOption(options, "jwksURL"),
+			Algorithm:           registry.StringOption(options, "algorithm"),
+			JWKSRefreshInterval: registry.StringOption(options, "jwksRefreshInterval"),
+			Claims:              claims,
+		})
+		if err != nil {
+			slog.Error("Failed to create jwt middleware", "server", name, "error", err)
+			return nil
+		}
+		return middleware
+	})
+}
+
+// New 创建新的 JWT 认证中间件，启动时同步获取一次 JWKS
+func New(config interfaces.JWTConfig, extraClaims ...string) (*Middleware, error) {
+	if config.Algorithm == "" {
+		config.Algorithm = AlgorithmRS256
+	}
+	if !SupportedAlgorithm(config.Algorithm) {
+		return nil, fmt.Errorf("unsupported algorithm: %s", config.Algorithm)
+	}
+
+	ttl := defaultJWKSRefreshInterval
+	if config.JWKSRefreshInterval != "" {
+		interval, err := time.ParseDuration(config.JWKSRefreshInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwksRefreshInterval: %w", err)
+		}
+		if interval > 0 {
+			ttl = interval
+		}
+	}
+
+	keys, err := newKeySet(config.JWKSURL, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Middleware{
+		config: config,
+		claims: append(append([]string{"sub"}, config.Claims...), extraClaims...),
+		keys:   keys,
+	}, nil
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if token == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := m.verify(token)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "jwtauth"
+}
+
+// verify 校验 JWT 并返回需要存入上下文的声明
+func (m *Middleware) verify(token string) (map[string]interface{}, error) {
+	header, claims, signed, signature, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	// 只接受配置的算法，防止算法混淆攻击
+	if header.Alg != m.config.Algorithm {
+		return nil, fmt.Errorf("unexpected algorithm: %s", header.Alg)
+	}
+
+	key, ok := m.keys.key(header.Kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", header.Kid)
+	}
+	if err := verifySignature(header.Alg, key, signed, signature); err != nil {
+		return nil, err
+	}
+	if err := validateClaims(claims, m.config.Issuer, m.config.Audience, time.Now()); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]interface{}, len(m.claims))
+	for _, name := range m.claims {
+		if value, ok := claims[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected, nil
+}
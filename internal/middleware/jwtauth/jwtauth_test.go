@@ -0,0 +1,298 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// testKeys 测试用的签名私钥，按 kid 索引
+var (
+	testKeysOnce sync.Once
+	testKeys     map[string]crypto.Signer
+)
+
+// signingKeys 生成一次 RSA 和 EC 私钥并在测试间复用
+func signingKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+
+	testKeysOnce.Do(func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		ec256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		ec384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		testKeys = map[string]crypto.Signer{"rsa": rsaKey, "ec256": ec256, "ec384": ec384}
+	})
+	return testKeys
+}
+
+// encodeBigInt 以 base64url 编码大整数，EC 坐标按曲线长度左侧补零
+func encodeBigInt(value *big.Int, size int) string {
+	data := value.Bytes()
+	if len(data) < size {
+		data = append(make([]byte, size-len(data)), data...)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// jwkFor 将私钥对应的公钥编码为 JWK
+func jwkFor(kid string, key crypto.Signer) jsonWebKey {
+	switch public := key.Public().(type) {
+	case *rsa.PublicKey:
+		return jsonWebKey{Kty: "RSA", Kid: kid, Use: "sig", N: encodeBigInt(public.N, 0), E: encodeBigInt(big.NewInt(int64(public.E)), 0)}
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		return jsonWebKey{Kty: "EC", Kid: kid, Crv: public.Curve.Params().Name, X: encodeBigInt(public.X, size), Y: encodeBigInt(public.Y, size)}
+	}
+	panic("unsupported key type")
+}
+
+// newJWKSServer 启动提供给定公钥的 JWKS 服务器，返回的函数用于替换公钥集合
+func newJWKSServer(t *testing.T, kids ...string) (*httptest.Server, func(kids ...string)) {
+	t.Helper()
+
+	keys := signingKeys(t)
+	var current atomic.Pointer[[]jsonWebKey]
+	set := func(kids ...string) {
+		jwks := make([]jsonWebKey, 0, len(kids))
+		for _, kid := range kids {
+			jwks = append(jwks, jwkFor(kid, keys[kid]))
+		}
+		current.Store(&jwks)
+	}
+	set(kids...)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": *current.Load()})
+	}))
+	t.Cleanup(server.Close)
+	return server, set
+}
+
+// signToken 使用 kid 对应的私钥按 alg 签发 JWT，kid 为空时头部不含 kid
+func signToken(t *testing.T, alg, kid, keyID string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	headerData, _ := json.Marshal(header)
+	claimsData, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(headerData) + "." + base64.RawURLEncoding.EncodeToString(claimsData)
+
+	hash := algorithmHashes[alg]
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch key := signingKeys(t)[keyID].(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// newTestMiddleware 创建使用测试 JWKS 服务器的中间件
+func newTestMiddleware(t *testing.T, jwksURL string, config interfaces.JWTConfig) *Middleware {
+	t.Helper()
+
+	config.JWKSURL = jwksURL
+	middleware, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return middleware
+}
+
+func TestVerify(t *testing.T) {
+	server, _ := newJWKSServer(t, "rsa", "ec256", "ec384")
+	now := time.Now()
+	valid := map[string]interface{}{"sub": "alice", "exp": now.Add(time.Hour).Unix()}
+	withClaims := func(extra map[string]interface{}) map[string]interface{} {
+		claims := map[string]interface{}{"sub": "alice"}
+		for name, value := range extra {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		config  interfaces.JWTConfig
+		alg     string
+		kid     string
+		keyID   string
+		claims  map[string]interface{}
+		mutate  func(token string) string
+		wantErr bool
+	}{
+		{name: "RS256", alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: valid},
+		{name: "RS512", config: interfaces.JWTConfig{Algorithm: AlgorithmRS512}, alg: AlgorithmRS512, kid: "rsa", keyID: "rsa", claims: valid},
+		{name: "ES256", config: interfaces.JWTConfig{Algorithm: AlgorithmES256}, alg: AlgorithmES256, kid: "ec256", keyID: "ec256", claims: valid},
+		{name: "ES384", config: interfaces.JWTConfig{Algorithm: AlgorithmES384}, alg: AlgorithmES384, kid: "ec384", keyID: "ec384", claims: valid},
+
+		// 算法固定为配置值
+		{name: "algorithm differs from config", alg: AlgorithmRS512, kid: "rsa", keyID: "rsa", claims: valid, wantErr: true},
+		{name: "ES token with RS config", alg: AlgorithmES256, kid: "ec256", keyID: "ec256", claims: valid, wantErr: true},
+		{name: "alg none", alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: valid, mutate: func(token string) string {
+			parts := strings.Split(token, ".")
+			return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa"}`)) + "." + parts[1] + "."
+		}, wantErr: true},
+		{name: "RSA key with ES algorithm", config: interfaces.JWTConfig{Algorithm: AlgorithmES256}, alg: AlgorithmES256, kid: "rsa", keyID: "ec256", claims: valid, wantErr: true},
+
+		// kid 查找
+		{name: "unknown kid", alg: AlgorithmRS256, kid: "missing", keyID: "rsa", claims: valid, wantErr: true},
+		{name: "missing kid with several keys", alg: AlgorithmRS256, keyID: "rsa", claims: valid, wantErr: true},
+		{name: "signed by a key other than kid", config: interfaces.JWTConfig{Algorithm: AlgorithmES256}, alg: AlgorithmES256, kid: "ec256", keyID: "ec384", claims: valid, wantErr: true},
+
+		// ES 签名长度
+		{name: "P-256 signature for P-384 key", config: interfaces.JWTConfig{Algorithm: AlgorithmES384}, alg: AlgorithmES384, kid: "ec384", keyID: "ec256", claims: valid, wantErr: true},
+		{name: "ES256 truncated signature", config: interfaces.JWTConfig{Algorithm: AlgorithmES256}, alg: AlgorithmES256, kid: "ec256", keyID: "ec256", claims: valid, mutate: func(token string) string {
+			parts := strings.Split(token, ".")
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			return parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(signature[:len(signature)-1])
+		}, wantErr: true},
+		{name: "tampered claims", alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: valid, mutate: func(token string) string {
+			parts := strings.Split(token, ".")
+			claims, _ := json.Marshal(map[string]interface{}{"sub": "mallory"})
+			return parts[0] + "." + base64.RawURLEncoding.EncodeToString(claims) + "." + parts[2]
+		}, wantErr: true},
+
+		// exp 和 nbf 允许 30s 时钟偏差
+		{name: "expired within skew", alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()})},
+		{name: "expired beyond skew", alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}), wantErr: true},
+		{name: "not yet valid within skew", alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"nbf": now.Add(10 * time.Second).Unix()})},
+		{name: "not yet valid beyond skew", alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"nbf": now.Add(time.Minute).Unix()}), wantErr: true},
+		{name: "exp is not a number", alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"exp": "tomorrow"}), wantErr: true},
+
+		// 签发者和受众
+		{name: "issuer matches", config: interfaces.JWTConfig{Issuer: "https://issuer.example.com"}, alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"iss": "https://issuer.example.com"})},
+		{name: "issuer differs", config: interfaces.JWTConfig{Issuer: "https://issuer.example.com"}, alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"iss": "https://evil.example.com"}), wantErr: true},
+		{name: "issuer missing", config: interfaces.JWTConfig{Issuer: "https://issuer.example.com"}, alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: valid, wantErr: true},
+		{name: "audience string", config: interfaces.JWTConfig{Audience: "mcp-proxy"}, alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"aud": "mcp-proxy"})},
+		{name: "audience array", config: interfaces.JWTConfig{Audience: "mcp-proxy"}, alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"aud": []string{"other", "mcp-proxy"}})},
+		{name: "audience differs", config: interfaces.JWTConfig{Audience: "mcp-proxy"}, alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: withClaims(map[string]interface{}{"aud": []string{"other"}}), wantErr: true},
+		{name: "audience missing", config: interfaces.JWTConfig{Audience: "mcp-proxy"}, alg: AlgorithmRS256, kid: "rsa", keyID: "rsa", claims: valid, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middleware := newTestMiddleware(t, server.URL, tt.config)
+			token := signToken(t, tt.alg, tt.kid, tt.keyID, tt.claims)
+			if tt.mutate != nil {
+				token = tt.mutate(token)
+			}
+
+			claims, err := middleware.verify(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && claims["sub"] != "alice" {
+				t.Errorf("verify() sub = %v, want alice", claims["sub"])
+			}
+		})
+	}
+}
+
+func TestVerifySingleKeyWithoutKid(t *testing.T) {
+	server, _ := newJWKSServer(t, "rsa")
+	middleware := newTestMiddleware(t, server.URL, interfaces.JWTConfig{})
+
+	if _, err := middleware.verify(signToken(t, AlgorithmRS256, "", "rsa", map[string]interface{}{"sub": "alice"})); err != nil {
+		t.Errorf("verify() without kid error = %v, want the only key to be used", err)
+	}
+}
+
+func TestVerifyRefreshesUnknownKid(t *testing.T) {
+	server, setKeys := newJWKSServer(t, "ec256")
+	middleware := newTestMiddleware(t, server.URL, interfaces.JWTConfig{Algorithm: AlgorithmES384})
+	token := signToken(t, AlgorithmES384, "ec384", "ec384", map[string]interface{}{"sub": "alice"})
+
+	// 公钥轮换后，刚获取过的 JWKS 不会因未知 kid 立即重新获取
+	setKeys("ec256", "ec384")
+	if _, err := middleware.verify(token); err == nil {
+		t.Fatal("verify() error = nil, want unknown kid before the refresh interval")
+	}
+
+	middleware.keys.mutex.Lock()
+	middleware.keys.fetchedAt = time.Now().Add(-2 * unknownKeyRefreshInterval)
+	middleware.keys.mutex.Unlock()
+	if _, err := middleware.verify(token); err != nil {
+		t.Errorf("verify() after refresh interval error = %v, want rotated key to be fetched", err)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	server, _ := newJWKSServer(t, "rsa")
+	middleware := newTestMiddleware(t, server.URL, interfaces.JWTConfig{Claims: []string{"tenant"}})
+	handler := middleware.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := ClaimsFromContext(r.Context())
+		w.Header().Set("X-Subject", SubjectFromContext(r.Context()))
+		w.Header().Set("X-Tenant", claims["tenant"].(string))
+		if _, ok := claims["email"]; ok {
+			t.Error("unselected claim email stored in context")
+		}
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "valid token", authorization: "Bearer " + signToken(t, AlgorithmRS256, "rsa", "rsa", map[string]interface{}{"sub": "alice", "tenant": "acme", "email": "alice@example.com"}), wantStatus: http.StatusOK},
+		{name: "missing token", wantStatus: http.StatusUnauthorized},
+		{name: "malformed token", authorization: "Bearer not-a-jwt", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && (rec.Header().Get("X-Subject") != "alice" || rec.Header().Get("X-Tenant") != "acme") {
+				t.Errorf("context claims = sub %q tenant %q, want alice and acme", rec.Header().Get("X-Subject"), rec.Header().Get("X-Tenant"))
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package jwtauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew 校验 exp 和 nbf 时允许的时钟偏差
+const clockSkew = 30 * time.Second
+
+// 支持的签名算法
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmRS384 = "RS384"
+	AlgorithmRS512 = "RS512"
+	AlgorithmES256 = "ES256"
+	AlgorithmES384 = "ES384"
+	AlgorithmES512 = "ES512"
+)
+
+// algorithmHashes 签名算法使用的哈希函数
+var algorithmHashes = map[string]crypto.Hash{
+	AlgorithmRS256: crypto.SHA256,
+	AlgorithmRS384: crypto.SHA384,
+	AlgorithmRS512: crypto.SHA512,
+	AlgorithmES256: crypto.SHA256,
+	AlgorithmES384: crypto.SHA384,
+	AlgorithmES512: crypto.SHA512,
+}
+
+// SupportedAlgorithm 判断签名算法是否受支持
+func SupportedAlgorithm(algorithm string) bool {
+	_, ok := algorithmHashes[algorithm]
+	return ok
+}
+
+// tokenHeader JWT 头部
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// parseToken 拆分 JWT 并解码头部和声明，不校验签名
+func parseToken(token string) (tokenHeader, map[string]interface{}, []byte, []byte, error) {
+	var header tokenHeader
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return header, nil, nil, nil, errors.New("malformed token")
+	}
+
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return header, nil, nil, nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	if err := json.Unmarshal(headerData, &header); err != nil {
+		return header, nil, nil, nil, fmt.Errorf("malformed token header: %w", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return header, nil, nil, nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var claims map[string]interface{}
+	if err := decoder.Decode(&claims); err != nil {
+		return header, nil, nil, nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return header, nil, nil, nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+	return header, claims, []byte(parts[0] + "." + parts[1]), signature, nil
+}
+
+// verifySignature 使用公钥校验签名，算法与公钥类型不匹配时失败
+func verifySignature(algorithm string, key crypto.PublicKey, signed, signature []byte) error {
+	hash, ok := algorithmHashes[algorithm]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "RS") {
+			return errors.New("key type does not match algorithm")
+		}
+		return rsa.VerifyPKCS1v15(publicKey, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "ES") {
+			return errors.New("key type does not match algorithm")
+		}
+		// ES 签名为定长的 r||s
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key type")
+	}
+}
+
+// validateClaims 校验过期时间、生效时间、签发者和受众
+func validateClaims(claims map[string]interface{}, issuer, audience string, now time.Time) error {
+	if exp, ok, err := numericDate(claims, "exp"); err != nil {
+		return err
+	} else if ok && now.After(exp.Add(clockSkew)) {
+		return errors.New("token is expired")
+	}
+	if nbf, ok, err := numericDate(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(clockSkew).Before(nbf) {
+		return errors.New("token is not valid yet")
+	}
+
+	if issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != issuer {
+			return fmt.Errorf("unexpected issuer: %q", iss)
+		}
+	}
+	if audience != "" && !hasAudience(claims["aud"], audience) {
+		return errors.New("token audience does not match")
+	}
+	return nil
+}
+
+// numericDate 读取秒级时间戳声明
+func numericDate(claims map[string]interface{}, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("invalid %s claim", name)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s claim: %w", name, err)
+	}
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+// hasAudience 判断 aud 声明（字符串或字符串数组）是否包含指定受众
+func hasAudience(value interface{}, audience string) bool {
+	switch aud := value.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, item := range aud {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,10 +1,12 @@
 package logger
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
 )
 
 // Middleware 日志中间件实现
@@ -12,6 +14,12 @@ type Middleware struct {
 	prefix string
 }
 
+func init() {
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeLogger, func(options map[string]interface{}) interfaces.Middleware {
+		return New(registry.StringOption(options, registry.OptionName))
+	})
+}
+
 // New 创建新的日志中间件
 func New(prefix string) interfaces.Middleware {
 	return &Middleware{
@@ -19,11 +27,12 @@ func New(prefix string) interfaces.Middleware {
 	}
 }
 
-// Handle 处理 HTTP 请求
+// Handle 处理 HTTP 请求，请求结束后记录耗时
 func (m *Middleware) Handle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("<%s> Request [%s] %s", m.prefix, r.Method, r.URL.Path)
+		start := time.Now()
 		next.ServeHTTP(w, r)
+		slog.InfoContext(r.Context(), "Request", "server", m.prefix, "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
 	})
 }
 
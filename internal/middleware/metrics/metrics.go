@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics Prometheus 指标集合
+type Metrics struct {
+	registry     *prometheus.Registry
+	toolCallCost *prometheus.CounterVec
+	toolCalls    *prometheus.CounterVec
+	toolDuration *prometheus.HistogramVec
+	connectTime  *prometheus.HistogramVec
+	connRejected prometheus.Counter
+	connected    *prometheus.GaugeVec
+	rateLimited  prometheus.Counter
+	breaker      *prometheus.GaugeVec
+	cacheHits    *prometheus.CounterVec
+	cacheMisses  *prometheus.CounterVec
+}
+
+// subsystem 所有指标名固定的 mcp_ 段，metricsPrefix 加在它之前
+const subsystem = "mcp"
+
+// New 创建新的指标集合，所有指标名形如 <prefix>_mcp_<name>，prefix 为空时为 mcp_<name>
+func New(prefix string) *Metrics {
+	namespace := strings.TrimSuffix(prefix, "_")
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		toolCallCost: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "tool_call_cost_total",
+			Help:      "Weighted cost of successful tool calls.",
+		}, []string{"server", "tool"}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "tool_calls_total",
+			Help:      "Finished tool calls by status.",
+		}, []string{"server", "tool", "status"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Duration of finished tool calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"server", "tool"}),
+		connectTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "client_connect_duration_seconds",
+			Help:      "Time from starting an upstream connection to completing the MCP initialize handshake.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"server_name", "transport", "result"}),
+		connRejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "connections_rejected_total",
+			Help:      "SSE connections rejected because maxSSEConnections was reached.",
+		}),
+		connected: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "client_connected",
+			Help:      "Whether the upstream client is connected (1) or not (0).",
+		}, []string{"server"}),
+		rateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_rate_limited_total",
+			Help:      "Requests rejected with 429 by the rate limiter.",
+		}),
+		breaker: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "circuit_breaker_state",
+			Help:      "Upstream circuit breaker state: closed (0), open (1) or half-open (2).",
+		}, []string{"server"}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "tool_cache_hits_total",
+			Help:      "Tool calls served from the result cache.",
+		}, []string{"server", "tool"}),
+		cacheMisses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "tool_cache_misses_total",
+			Help:      "Cacheable tool calls forwarded upstream because no cached result was found.",
+		}, []string{"server", "tool"}),
+	}
+
+	m.registry.MustRegister(m.toolCallCost, m.toolCalls, m.toolDuration, m.connectTime, m.connRejected, m.connected, m.rateLimited, m.breaker, m.cacheHits, m.cacheMisses)
+	return m
+}
+
+// Registry 获取指标注册表
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler 返回以 Prometheus 文本格式输出所有指标的 HTTP 处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// AddToolCallCost 累加工具调用成本
+func (m *Metrics) AddToolCallCost(server, tool string, cost float64) {
+	m.toolCallCost.WithLabelValues(server, tool).Add(cost)
+}
+
+// RecordToolCall 记录一次已结束的工具调用，status 为 success、error 或 timeout
+func (m *Metrics) RecordToolCall(server, tool, status string, duration time.Duration) {
+	m.toolCalls.WithLabelValues(server, tool, status).Inc()
+	m.toolDuration.WithLabelValues(server, tool).Observe(duration.Seconds())
+}
+
+// RecordClientConnect 记录一次上游连接与初始化握手的耗时，result 为 success 或 error
+func (m *Metrics) RecordClientConnect(serverName, transport, result string, duration time.Duration) {
+	m.connectTime.WithLabelValues(serverName, transport, result).Observe(duration.Seconds())
+}
+
+// IncConnectionsRejected 记录一次因达到连接上限而被拒绝的 SSE 连接
+func (m *Metrics) IncConnectionsRejected() {
+	m.connRejected.Inc()
+}
+
+// IncRateLimited 记录一次被限流拒绝的请求
+func (m *Metrics) IncRateLimited() {
+	m.rateLimited.Inc()
+}
+
+// SetClientConnected 记录上游客户端当前是否已连接
+func (m *Metrics) SetClientConnected(server string, connected bool) {
+	value := 0.0
+	if connected {
+		value = 1
+	}
+	m.connected.WithLabelValues(server).Set(value)
+}
+
+// SetCircuitBreakerState 记录上游熔断器当前状态
+func (m *Metrics) SetCircuitBreakerState(server, state string) {
+	value := 0.0
+	switch state {
+	case "open":
+		value = 1
+	case "half-open":
+		value = 2
+	}
+	m.breaker.WithLabelValues(server).Set(value)
+}
+
+// IncToolCacheHit 记录一次工具结果缓存命中
+func (m *Metrics) IncToolCacheHit(server, tool string) {
+	m.cacheHits.WithLabelValues(server, tool).Inc()
+}
+
+// IncToolCacheMiss 记录一次工具结果缓存未命中
+func (m *Metrics) IncToolCacheMiss(server, tool string) {
+	m.cacheMisses.WithLabelValues(server, tool).Inc()
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordToolCall(t *testing.T) {
+	m := New("")
+	m.RecordToolCall("kb", "search", "success", 120*time.Millisecond)
+	m.RecordToolCall("kb", "search", "success", 80*time.Millisecond)
+	m.RecordToolCall("kb", "search", "error", 10*time.Millisecond)
+
+	if got := testutil.ToFloat64(m.toolCalls.WithLabelValues("kb", "search", "success")); got != 2 {
+		t.Errorf("success count = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.toolCalls.WithLabelValues("kb", "search", "error")); got != 1 {
+		t.Errorf("error count = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.toolDuration, "mcp_tool_call_duration_seconds"); got != 1 {
+		t.Errorf("duration series = %d, want 1", got)
+	}
+}
+
+func TestSetClientConnected(t *testing.T) {
+	m := New("")
+	m.SetClientConnected("kb", true)
+	m.SetClientConnected("fs", false)
+
+	expected := `
+# HELP mcp_client_connected Whether the upstream client is connected (1) or not (0).
+# TYPE mcp_client_connected gauge
+mcp_client_connected{server="fs"} 0
+mcp_client_connected{server="kb"} 1
+`
+	if err := testutil.CollectAndCompare(m.connected, strings.NewReader(expected), "mcp_client_connected"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{prefix: "", want: "mcp_tool_calls_total"},
+		{prefix: "staging", want: "staging_mcp_tool_calls_total"},
+		{prefix: "prod_", want: "prod_mcp_tool_calls_total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			m := New(tt.prefix)
+			m.RecordToolCall("kb", "search", "timeout", time.Second)
+
+			expected := `
+# HELP ` + tt.want + ` Finished tool calls by status.
+# TYPE ` + tt.want + ` counter
+` + tt.want + `{server="kb",status="timeout",tool="search"} 1
+`
+			if err := testutil.GatherAndCompare(m.Registry(), strings.NewReader(expected), tt.want); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRecordClientConnect(t *testing.T) {
+	m := New("")
+	m.RecordClientConnect("kb", "stdio", "success", 300*time.Millisecond)
+
+	// 与其他指标使用相同的 server 标签，便于按服务器关联
+	if got := testutil.CollectAndCount(m.connectTime, "mcp_client_connect_duration_seconds"); got != 1 {
+		t.Fatalf("connect duration series = %d, want 1", got)
+	}
+	if _, err := m.connectTime.GetMetricWith(map[string]string{"server_name": "kb", "transport": "stdio", "result": "success"}); err != nil {
+		t.Error(err)
+	}
+}
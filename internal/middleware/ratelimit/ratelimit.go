@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/retry"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/servertiming"
+	"golang.org/x/time/rate"
+)
+
+func init() {
+	// rate 为每秒请求数，必须大于 0，否则返回 nil
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeRateLimit, func(options map[string]interface{}) interfaces.Middleware {
+		limit := registry.FloatOption(options, "rate")
+		if limit <= 0 {
+			return nil
+		}
+		return New(nil, NewLimiter(limit, registry.IntOption(options, "burst")))
+	})
+}
+
+// NewLimiter 创建令牌桶限流器，以 limit 个/秒的速度补充令牌，burst 小于 1 时取 max(1, ceil(limit))
+func NewLimiter(limit float64, burst int) *rate.Limiter {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(limit)))
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+// Middleware 限流中间件实现
+//
+// 请求需要同时通过所有限流器（例如单个服务器的限流器和所有服务器共享的全局限流器），
+// 任一限流器令牌耗尽时返回 429 和 Retry-After，已预留的令牌会归还。
+type Middleware struct {
+	limiters []*rate.Limiter
+	onReject func()
+}
+
+// New 创建新的限流中间件，nil 限流器会被忽略，onReject 在拒绝请求时调用
+func New(onReject func(), limiters ...*rate.Limiter) *Middleware {
+	m := &Middleware{onReject: onReject}
+	for _, limiter := range limiters {
+		if limiter != nil {
+			m.limiters = append(m.limiters, limiter)
+		}
+	}
+	return m
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		allowed, retryAfter := m.allow(start)
+		servertiming.Record(r.Context(), "ratelimit", time.Since(start))
+		if !allowed {
+			if m.onReject != nil {
+				m.onReject()
+			}
+			retry.WriteError(w, r, http.StatusTooManyRequests, "rate limit exceeded", retryAfter)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "ratelimit"
+}
+
+// allow 从每个限流器预留一个令牌，任一限流器需要等待时取消所有预留并返回最长的等待时间
+func (m *Middleware) allow(now time.Time) (bool, time.Duration) {
+	reservations := make([]*rate.Reservation, 0, len(m.limiters))
+	var retryAfter time.Duration
+	for _, limiter := range m.limiters {
+		reservation := limiter.ReserveN(now, 1)
+		reservations = append(reservations, reservation)
+		if delay := reservation.DelayFrom(now); delay > retryAfter {
+			retryAfter = delay
+		}
+	}
+	if retryAfter == 0 {
+		return true, 0
+	}
+	for _, reservation := range reservations {
+		reservation.CancelAt(now)
+	}
+	return false, retryAfter
+}
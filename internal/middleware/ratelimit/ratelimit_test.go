@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// serve 通过中间件发送一个请求
+func serve(handler http.Handler, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimitRejectsWithRetryAfter(t *testing.T) {
+	rejected := 0
+	// 每 2 秒补充一个令牌
+	handler := New(func() { rejected++ }, NewLimiter(0.5, 1)).Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	if rec := serve(handler, ""); rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", rec.Code)
+	}
+
+	rec := serve(handler, "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if rejected != 1 {
+		t.Errorf("onReject called %d times, want 1", rejected)
+	}
+
+	// 接受 JSON 的客户端额外得到 retry_at
+	rec = serve(handler, "application/json")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("JSON request status = %d, want 429", rec.Code)
+	}
+	var body struct {
+		Error   string `json:"error"`
+		RetryAt string `json:"retry_at"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("429 body is not JSON: %v", err)
+	}
+	retryAt, err := time.Parse(time.RFC3339, body.RetryAt)
+	if err != nil {
+		t.Fatalf("retry_at = %q: %v", body.RetryAt, err)
+	}
+	if wait := time.Until(retryAt); wait < 0 || wait > 3*time.Second {
+		t.Errorf("retry_at is %s away, want within 2s", wait)
+	}
+}
+
+func TestRateLimitReturnsReservedTokens(t *testing.T) {
+	server := NewLimiter(0.001, 2)
+	global := NewLimiter(0.001, 1)
+	m := New(nil, server, global)
+	now := time.Now()
+
+	if allowed, _ := m.allow(now); !allowed {
+		t.Fatal("first request rejected, want both limiters to have tokens")
+	}
+	// 全局限流器拒绝时，服务器限流器已预留的令牌被归还
+	allowed, retryAfter := m.allow(now)
+	if allowed {
+		t.Fatal("second request allowed, want global limiter to reject")
+	}
+	if retryAfter <= 0 {
+		t.Errorf("retryAfter = %s, want the global limiter's delay", retryAfter)
+	}
+	if tokens := server.TokensAt(now); tokens < 0.99 {
+		t.Errorf("server limiter has %.2f tokens after rejection, want 1 returned", tokens)
+	}
+}
+
+func TestNewLimiterDefaultBurst(t *testing.T) {
+	tests := []struct {
+		limit float64
+		burst int
+		want  int
+	}{
+		{limit: 0.5, want: 1},
+		{limit: 2.5, want: 3},
+		{limit: 10, burst: 4, want: 4},
+	}
+	for _, tt := range tests {
+		if got := NewLimiter(tt.limit, tt.burst).Burst(); got != tt.want {
+			t.Errorf("NewLimiter(%v, %d).Burst() = %d, want %d", tt.limit, tt.burst, got, tt.want)
+		}
+	}
+}
@@ -1,21 +1,32 @@
 package recovery
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
 
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
 )
 
 // Middleware 恢复中间件实现
 type Middleware struct {
-	name string
+	name  string
+	debug bool
 }
 
-// New 创建新的恢复中间件
-func New(name string) interfaces.Middleware {
+func init() {
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeRecovery, func(options map[string]interface{}) interfaces.Middleware {
+		return New(registry.StringOption(options, registry.OptionName), registry.BoolOption(options, registry.OptionDebug))
+	})
+}
+
+// New 创建新的恢复中间件，debug 为 true 时在 500 响应体中返回堆栈信息
+func New(name string, debug bool) interfaces.Middleware {
 	return &Middleware{
-		name: name,
+		name:  name,
+		debug: debug,
 	}
 }
 
@@ -24,8 +35,16 @@ func (m *Middleware) Handle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("<%s> Recovered from panic: %v", m.name, err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				if !m.debug {
+					slog.ErrorContext(r.Context(), "Recovered from panic", "server", m.name, "panic", err)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+
+				// 调试模式下以纯文本返回堆栈，便于直接阅读
+				stack := debug.Stack()
+				slog.ErrorContext(r.Context(), "Recovered from panic", "server", m.name, "panic", err, "stack", string(stack))
+				http.Error(w, fmt.Sprintf("Internal Server Error: %v\n\n%s", err, stack), http.StatusInternalServerError)
 			}
 		}()
 		next.ServeHTTP(w, r)
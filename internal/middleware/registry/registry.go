@@ -0,0 +1,131 @@
+// Package registry 中间件插件注册表
+//
+// 内置中间件在各自包的 init() 中注册，第三方代码同样可以在 init() 中调用
+// RegisterMiddleware 注册自定义中间件，然后在 options.middlewares 中按 type 引用。
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// 代理构建中间件链时注入的选项，配置中已存在同名选项时不覆盖
+const (
+	// OptionName 日志前缀，形如 "<服务器名>/<实例 ID>"
+	OptionName = "name"
+	// OptionDebug 所属服务器是否开启调试模式
+	OptionDebug = "debug"
+)
+
+// Factory 根据实例选项创建中间件，选项无效时返回 nil
+type Factory func(options map[string]interface{}) interfaces.Middleware
+
+var (
+	mutex     sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// RegisterMiddleware 注册中间件类型，通常在 init() 中调用
+//
+// 与 database/sql.Register 一致，构造函数为 nil 或重复注册同一类型时直接 panic。
+func RegisterMiddleware(name string, factory func(options map[string]interface{}) interfaces.Middleware) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if name == "" {
+		panic("registry: RegisterMiddleware name is empty")
+	}
+	if factory == nil {
+		panic("registry: RegisterMiddleware factory is nil for " + name)
+	}
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("registry: RegisterMiddleware called twice for %s", name))
+	}
+	factories[name] = factory
+}
+
+// Lookup 查找已注册的中间件构造函数
+func Lookup(name string) (Factory, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	factory, ok := factories[name]
+	return factory, ok
+}
+
+// Names 返回所有已注册的中间件类型
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StringOption 读取字符串选项，不存在或类型不符时返回空字符串
+func StringOption(options map[string]interface{}, key string) string {
+	value, _ := options[key].(string)
+	return value
+}
+
+// BoolOption 读取布尔选项，不存在或类型不符时返回 false
+func BoolOption(options map[string]interface{}, key string) bool {
+	value, _ := options[key].(bool)
+	return value
+}
+
+// IntOption 读取整数选项，JSON 解码得到的数字为 float64，不存在或类型不符时返回 0
+func IntOption(options map[string]interface{}, key string) int {
+	switch value := options[key].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	default:
+		return 0
+	}
+}
+
+// FloatOption 读取数字选项，不存在或类型不符时返回 0
+func FloatOption(options map[string]interface{}, key string) float64 {
+	switch value := options[key].(type) {
+	case int:
+		return float64(value)
+	case float64:
+		return value
+	default:
+		return 0
+	}
+}
+
+// StringSliceOption 读取字符串列表选项，不存在时返回 nil
+func StringSliceOption(options map[string]interface{}, key string) ([]string, error) {
+	raw, ok := options[key]
+	if !ok {
+		return nil, nil
+	}
+
+	switch items := raw.(type) {
+	case []string:
+		return items, nil
+	case []interface{}:
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			value, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("option %s must be a list of strings", key)
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("option %s must be a list of strings", key)
+	}
+}
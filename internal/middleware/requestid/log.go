@@ -0,0 +1,34 @@
+package requestid
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logHandler 为带有请求 ID 的日志记录添加 request_id 属性
+type logHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler 包装 slog 处理器，使用 *Context 方法记录的日志自动带上请求 ID
+func NewLogHandler(handler slog.Handler) slog.Handler {
+	return &logHandler{Handler: handler}
+}
+
+// Handle 从上下文读取请求 ID 并添加到日志记录
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs 返回同样添加请求 ID 的处理器
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 返回同样添加请求 ID 的处理器
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,69 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID 请求 ID 的请求头和响应头
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength 接受的调用方请求 ID 的最大长度，超出时重新生成
+const maxRequestIDLength = 128
+
+// requestIDKey 上下文中请求 ID 的键
+type requestIDKey struct{}
+
+// RequestIDFromContext 获取请求 ID，未经过请求 ID 中间件时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware 请求 ID 中间件实现
+type Middleware struct{}
+
+func init() {
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeRequestID, func(options map[string]interface{}) interfaces.Middleware {
+		return New()
+	})
+}
+
+// New 创建新的请求 ID 中间件
+func New() interfaces.Middleware {
+	return &Middleware{}
+}
+
+// Handle 处理 HTTP 请求，沿用调用方的 X-Request-ID，不存在或不合法时生成 UUID v4
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "requestid"
+}
+
+// validRequestID 请求 ID 会写入日志和响应头，只接受长度有限的可打印 ASCII 字符
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package responseheaders
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// Middleware 自定义响应头中间件实现
+//
+// 非空值在调用下游之前设置；空值表示移除该响应头，
+// 因此在响应头写出时删除，以覆盖下游处理器设置的同名头。
+type Middleware struct {
+	set    map[string]string
+	remove []string
+}
+
+// New 创建新的自定义响应头中间件
+func New(headers map[string]string) interfaces.Middleware {
+	m := &Middleware{
+		set: make(map[string]string),
+	}
+	for key, value := range headers {
+		if value == "" {
+			m.remove = append(m.remove, key)
+		} else {
+			m.set[key] = value
+		}
+	}
+	return m
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, value := range m.set {
+			w.Header().Set(key, value)
+		}
+		if len(m.remove) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&responseWriter{ResponseWriter: w, remove: m.remove}, r)
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "responseheaders"
+}
+
+// responseWriter 在写出响应头前删除指定的头
+type responseWriter struct {
+	http.ResponseWriter
+	remove      []string
+	wroteHeader bool
+}
+
+// WriteHeader 删除指定的头后写出状态码
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		for _, key := range rw.remove {
+			rw.ResponseWriter.Header().Del(key)
+		}
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write 写入响应体，未写出状态码时隐式写出 200
+func (rw *responseWriter) Write(data []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(data)
+}
+
+// Flush 透传给底层写入器，保证 SSE 流式响应正常工作
+func (rw *responseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 透传给底层写入器，保证 CONNECT 隧道正常工作
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap 返回底层写入器，供 http.ResponseController 使用
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,51 @@
+package retry
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errorResponse JSON 格式的可重试错误响应
+type errorResponse struct {
+	Error   string `json:"error"`
+	RetryAt string `json:"retry_at"`
+}
+
+// WriteError 写出可重试的错误响应，例如限流（429）或熔断（503）
+//
+// 响应始终携带 Retry-After 头（向上取整的秒数）；客户端接受 JSON 时，
+// 响应体额外包含 ISO8601 格式的 retry_at 字段。
+func WriteError(w http.ResponseWriter, r *http.Request, status int, message string, retryAfter time.Duration) {
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+
+	if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(newErrorResponse(message, retryAfter))
+}
+
+// ErrorText 返回与 WriteError 响应体相同的 JSON 文本，供工具错误结果等非 HTTP 场景携带 retry_at
+func ErrorText(message string, retryAfter time.Duration) string {
+	data, _ := json.Marshal(newErrorResponse(message, retryAfter))
+	return string(data)
+}
+
+// newErrorResponse 创建可重试错误响应，retry_at 为当前时间加上 retryAfter
+func newErrorResponse(message string, retryAfter time.Duration) errorResponse {
+	return errorResponse{
+		Error:   message,
+		RetryAt: time.Now().Add(max(retryAfter, 0)).UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,148 @@
+package servertiming
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+)
+
+// HeaderServerTiming W3C Server-Timing 响应头
+const HeaderServerTiming = "Server-Timing"
+
+// MetricToolCall 处理器耗时的指标名称，为请求总耗时减去其他已记录步骤的耗时
+const MetricToolCall = "tool_call"
+
+// metric 单个计时项
+type metric struct {
+	name     string
+	duration time.Duration
+}
+
+// timings 单个请求的计时记录
+type timings struct {
+	mutex   sync.Mutex
+	start   time.Time
+	metrics []metric
+}
+
+// timingsKey 上下文中计时记录的键
+type timingsKey struct{}
+
+// Record 记录一个步骤的耗时，请求未启用 Server-Timing 时忽略
+func Record(ctx context.Context, name string, duration time.Duration) {
+	t, ok := ctx.Value(timingsKey{}).(*timings)
+	if !ok {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.metrics = append(t.metrics, metric{name: name, duration: duration})
+}
+
+// header 生成 Server-Timing 头的值，例如 "auth;dur=1.2, tool_call;dur=450.8"
+func (t *timings) header() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	parts := make([]string, 0, len(t.metrics)+1)
+	handler := time.Since(t.start)
+	for _, m := range t.metrics {
+		parts = append(parts, formatMetric(m.name, m.duration))
+		handler -= m.duration
+	}
+	if handler < 0 {
+		handler = 0
+	}
+	parts = append(parts, formatMetric(MetricToolCall, handler))
+	return strings.Join(parts, ", ")
+}
+
+// formatMetric 按 Server-Timing 格式输出以毫秒为单位的耗时
+func formatMetric(name string, duration time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.1f", name, float64(duration)/float64(time.Millisecond))
+}
+
+// Middleware Server-Timing 中间件实现，在写出响应头时附加各步骤耗时
+type Middleware struct{}
+
+func init() {
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeServerTiming, func(options map[string]interface{}) interfaces.Middleware {
+		return New()
+	})
+}
+
+// New 创建新的 Server-Timing 中间件
+func New() interfaces.Middleware {
+	return &Middleware{}
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := &timings{start: time.Now()}
+		ctx := context.WithValue(r.Context(), timingsKey{}, t)
+		next.ServeHTTP(&responseWriter{ResponseWriter: w, timings: t}, r.WithContext(ctx))
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "servertiming"
+}
+
+// responseWriter 在写出响应头前附加 Server-Timing 头
+type responseWriter struct {
+	http.ResponseWriter
+	timings     *timings
+	wroteHeader bool
+}
+
+// WriteHeader 附加 Server-Timing 头后写出状态码
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		rw.ResponseWriter.Header().Add(HeaderServerTiming, rw.timings.header())
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write 写入响应体，未写出状态码时隐式写出 200
+func (rw *responseWriter) Write(data []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(data)
+}
+
+// Flush 透传给底层写入器，保证 SSE 流式响应正常工作
+func (rw *responseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 透传给底层写入器，保证 CONNECT 隧道正常工作
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap 返回底层写入器，供 http.ResponseController 使用
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
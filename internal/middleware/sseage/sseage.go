@@ -0,0 +1,130 @@
+package sseage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/middleware/connlimit"
+)
+
+// ReconnectEvent 连接到期关闭前发送的最后一个事件，客户端收到后应立即重连
+const ReconnectEvent = "event: proxy_reconnect\ndata:{}\n\n"
+
+// errConnectionExpired 连接到期后下游继续写入时返回的错误
+var errConnectionExpired = errors.New("SSE connection reached its maximum age")
+
+// connectionStartKey 上下文中连接开始时间的键
+type connectionStartKey struct{}
+
+// ConnectionStart 获取 SSE 连接的开始时间
+func ConnectionStart(ctx context.Context) (time.Time, bool) {
+	start, ok := ctx.Value(connectionStartKey{}).(time.Time)
+	return start, ok
+}
+
+// Middleware SSE 连接最长存活时间中间件实现
+//
+// 长期存在的 SSE 连接会不断积累事件缓冲和 goroutine，也会掩盖客户端的重连问题。
+// 连接达到最长存活时间后先发送 proxy_reconnect 事件，再取消请求上下文结束连接。
+type Middleware struct {
+	maxAge time.Duration
+}
+
+// New 创建新的 SSE 连接存活时间中间件，maxAge 为 0 表示不限制
+func New(maxAge time.Duration) *Middleware {
+	return &Middleware{maxAge: maxAge}
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.maxAge <= 0 || !connlimit.IsSSERequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.WithValue(r.Context(), connectionStartKey{}, time.Now()))
+		defer cancel()
+
+		start, _ := ConnectionStart(ctx)
+		rw := &responseWriter{ResponseWriter: w}
+		timer := time.AfterFunc(time.Until(start.Add(m.maxAge)), func() {
+			rw.expire()
+			cancel()
+		})
+		defer timer.Stop()
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "sseage"
+}
+
+// responseWriter 串行化下游写入与到期事件的写入，到期后拒绝继续写入
+type responseWriter struct {
+	http.ResponseWriter
+	mutex       sync.Mutex
+	wroteHeader bool
+	expired     bool
+}
+
+// expire 发送重连事件并标记连接已到期，响应尚未开始时不发送
+func (rw *responseWriter) expire() {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+
+	if rw.wroteHeader && !rw.expired {
+		_, _ = io.WriteString(rw.ResponseWriter, ReconnectEvent)
+		if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	rw.expired = true
+}
+
+// WriteHeader 写出状态码
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write 写入响应体，连接到期后返回错误
+func (rw *responseWriter) Write(data []byte) (int, error) {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+
+	if rw.expired {
+		return 0, errConnectionExpired
+	}
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(data)
+}
+
+// Flush 透传给底层写入器，保证 SSE 流式响应正常工作
+func (rw *responseWriter) Flush() {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+
+	if rw.expired {
+		return
+	}
+	rw.wroteHeader = true
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap 返回底层写入器，供 http.ResponseController 使用
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/registry"
+)
+
+// W3C Trace Context 请求头
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+// TraceContext W3C Trace Context
+type TraceContext struct {
+	TraceParent string `json:"traceparent"`
+	TraceState  string `json:"tracestate,omitempty"`
+}
+
+// traceContextKey 上下文中追踪信息的键
+type traceContextKey struct{}
+
+// WithTraceContext 将追踪信息存入上下文
+func WithTraceContext(ctx context.Context, traceContext TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, traceContext)
+}
+
+// FromContext 从上下文中获取追踪信息
+func FromContext(ctx context.Context) (TraceContext, bool) {
+	traceContext, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return traceContext, ok
+}
+
+// Middleware 追踪上下文中间件实现，从请求头提取 W3C Trace Context
+type Middleware struct{}
+
+func init() {
+	registry.RegisterMiddleware(interfaces.MiddlewareTypeTracing, func(options map[string]interface{}) interfaces.Middleware {
+		return New()
+	})
+}
+
+// New 创建新的追踪上下文中间件
+func New() interfaces.Middleware {
+	return &Middleware{}
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent := r.Header.Get(HeaderTraceParent)
+		if traceParent == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := WithTraceContext(r.Context(), TraceContext{
+			TraceParent: traceParent,
+			TraceState:  r.Header.Get(HeaderTraceState),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "tracing"
+}
@@ -0,0 +1,35 @@
+package version
+
+import (
+	"net/http"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// HeaderName 携带构建版本号的响应头
+const HeaderName = "X-MCP-Proxy-Version"
+
+// Middleware 版本响应头中间件实现
+type Middleware struct {
+	version string
+}
+
+// New 创建新的版本响应头中间件
+func New(version string) interfaces.Middleware {
+	return &Middleware{
+		version: version,
+	}
+}
+
+// Handle 处理 HTTP 请求
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderName, m.version)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetName 获取中间件名称
+func (m *Middleware) GetName() string {
+	return "version"
+}
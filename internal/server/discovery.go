@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ToolInfo 工具发现接口返回的工具描述
+type ToolInfo struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema interface{} `json:"inputSchema"`
+	Server      string      `json:"server"`
+}
+
+// GetTools 获取已注册到代理服务器的工具，按名称排序
+func (ps *ProxyServer) GetTools() []mcp.Tool {
+	ps.toolsMutex.RLock()
+	defer ps.toolsMutex.RUnlock()
+
+	tools := make([]mcp.Tool, 0, len(ps.tools))
+	for _, tool := range ps.tools {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+	return tools
+}
+
+// HasTool 工具是否在 GetTools 返回的列表中，name 为对外暴露的名称
+func (ps *ProxyServer) HasTool(name string) bool {
+	ps.toolsMutex.RLock()
+	defer ps.toolsMutex.RUnlock()
+
+	_, ok := ps.tools[name]
+	return ok
+}
+
+// ToolsHandler 返回以 JSON 列出已注册工具的 HTTP 处理器
+func (ps *ProxyServer) ToolsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tools := ps.GetTools()
+		infos := make([]ToolInfo, 0, len(tools))
+		for _, tool := range tools {
+			infos = append(infos, newToolInfo(tool, ps.name))
+		}
+		writeToolInfos(w, ps.logTag, infos)
+	})
+}
+
+// AggregatedToolsHandler 返回合并 servedBy 代理下所有服务器工具的 HTTP 处理器
+//
+// 只包含已连接且开启了工具发现的服务器，工具名为注册到代理时的名称（含前缀）。
+// 不同服务器注册了同名工具时按服务器名称排序保留第一个。
+func AggregatedToolsHandler(manager *Manager, servedBy string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		infos := []ToolInfo{}
+		owners := make(map[string]string)
+		for _, ps := range discoverableServers(manager, servedBy) {
+			for _, tool := range ps.GetTools() {
+				if owner, ok := owners[tool.Name]; ok {
+					slog.Debug("Skipping duplicate tool in aggregated list", "server", ps.logTag, "tool", tool.Name, "registered_by", owner)
+					continue
+				}
+				owners[tool.Name] = ps.name
+				infos = append(infos, newToolInfo(tool, ps.name))
+			}
+		}
+		sort.Slice(infos, func(i, j int) bool {
+			return infos[i].Name < infos[j].Name
+		})
+		writeToolInfos(w, "tools", infos)
+	})
+}
+
+// discoverableServers 获取由 servedBy 代理提供、已连接且开启了工具发现的服务器，按名称排序
+func discoverableServers(manager *Manager, servedBy string) []*ProxyServer {
+	var servers []*ProxyServer
+	for _, ps := range manager.GetServers() {
+		if ps.serverConfig.ServedBy != servedBy || !ps.toolsDiscoveryEnabled() {
+			continue
+		}
+		if client := ps.GetClient(); client == nil || !client.IsConnected() {
+			continue
+		}
+		servers = append(servers, ps)
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].name < servers[j].name
+	})
+	return servers
+}
+
+// toolsDiscoveryEnabled 是否开启了工具发现
+func (ps *ProxyServer) toolsDiscoveryEnabled() bool {
+	options := ps.serverConfig.Options
+	return options != nil && options.ToolsDiscoveryEnabled != nil && *options.ToolsDiscoveryEnabled
+}
+
+// newToolInfo 将工具转换为工具发现接口的描述，优先使用原始输入 Schema
+func newToolInfo(tool mcp.Tool, server string) ToolInfo {
+	var inputSchema interface{} = tool.InputSchema
+	if tool.RawInputSchema != nil {
+		inputSchema = tool.RawInputSchema
+	}
+	return ToolInfo{
+		Name:        tool.Name,
+		Description: tool.Description,
+		InputSchema: inputSchema,
+		Server:      server,
+	}
+}
+
+// writeToolInfos 以 JSON 写出工具列表
+func writeToolInfos(w http.ResponseWriter, logTag string, infos []ToolInfo) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(infos); err != nil {
+		slog.Error("Failed to write tools response", "server", logTag, "error", err)
+	}
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultFanOutTimeout 扇出调用等待所有目标的默认时长
+const defaultFanOutTimeout = 30 * time.Second
+
+// FanOutServer 扇出工具服务器，将一次调用并发分发到多个上游工具并合并结果
+type FanOutServer struct {
+	manager   *Manager
+	timeout   time.Duration
+	mcpServer *server.MCPServer
+	handler   http.Handler
+}
+
+// fanOutTarget 扇出目标
+type fanOutTarget struct {
+	server string
+	tool   string
+}
+
+// String 返回 serverName.toolName 形式的目标名
+func (t fanOutTarget) String() string {
+	return t.server + "." + t.tool
+}
+
+// NewFanOutServer 根据 fanOutGroups 创建扇出工具服务器
+func NewFanOutServer(proxyConfig *interfaces.ProxyConfig, manager *Manager) (*FanOutServer, error) {
+	timeout := defaultFanOutTimeout
+	if proxyConfig.FanOutTimeout != "" {
+		parsed, err := time.ParseDuration(proxyConfig.FanOutTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fanOutTimeout: %w", err)
+		}
+		timeout = parsed
+	}
+
+	fs := &FanOutServer{
+		manager: manager,
+		timeout: timeout,
+		mcpServer: server.NewMCPServer(
+			proxyConfig.Name,
+			proxyConfig.Version,
+			server.WithToolCapabilities(false),
+			server.WithRecovery(),
+		),
+	}
+
+	// 按名称排序，保证工具列表顺序稳定
+	names := make([]string, 0, len(proxyConfig.FanOutGroups))
+	for name := range proxyConfig.FanOutGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		targets, err := parseFanOutTargets(proxyConfig.FanOutGroups[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid fan-out group %s: %w", name, err)
+		}
+		tool := mcp.NewToolWithRawSchema(
+			name,
+			fmt.Sprintf("Calls %s concurrently and merges the results", joinTargets(targets)),
+			[]byte(`{"type":"object","additionalProperties":true}`),
+		)
+		fs.mcpServer.AddTool(tool, fs.fanOutHandler(name, targets))
+		slog.Info("Registered fan-out tool", "server", interfaces.FanOutServerName, "tool", name, "targets", len(targets))
+	}
+
+	switch proxyConfig.Type {
+	case interfaces.TransportTypeSSE:
+		fs.handler = server.NewSSEServer(
+			fs.mcpServer,
+			server.WithStaticBasePath(interfaces.FanOutServerName),
+			server.WithBaseURL(proxyConfig.BaseURL),
+		)
+	case interfaces.TransportTypeHTTP:
+		fs.handler = server.NewStreamableHTTPServer(fs.mcpServer, streamableOptions(proxyConfig)...)
+	default:
+		return nil, fmt.Errorf("unsupported server type: %s", proxyConfig.Type)
+	}
+
+	return fs, nil
+}
+
+// GetHandler 获取 HTTP 处理器
+func (fs *FanOutServer) GetHandler() http.Handler {
+	return fs.handler
+}
+
+// fanOutHandler 并发调用所有目标，成功结果按目标顺序合并，失败的目标追加错误条目
+func (fs *FanOutServer) fanOutHandler(name string, targets []fanOutTarget) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, fs.timeout)
+		defer cancel()
+
+		results := make([]*mcp.CallToolResult, len(targets))
+		errs := make([]error, len(targets))
+
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func(i int, target fanOutTarget) {
+				defer wg.Done()
+				results[i], errs[i] = fs.callTarget(ctx, target, request)
+			}(i, target)
+		}
+		wg.Wait()
+
+		merged := &mcp.CallToolResult{}
+		failed := 0
+		for i, target := range targets {
+			if errs[i] == nil && results[i].IsError {
+				errs[i] = fmt.Errorf("%s", resultText(results[i]))
+			}
+			if errs[i] != nil {
+				failed++
+				slog.Warn("Fan-out target failed", "server", interfaces.FanOutServerName, "tool", name, "target", target, "error", errs[i])
+				continue
+			}
+			merged.Content = append(merged.Content, results[i].Content...)
+		}
+		for i, target := range targets {
+			if errs[i] != nil {
+				merged.Content = append(merged.Content, mcp.NewTextContent(fmt.Sprintf("error from %s: %v", target, errs[i])))
+			}
+		}
+
+		// 全部失败时整体标记为错误，部分失败仍返回成功结果
+		merged.IsError = failed == len(targets)
+		return merged, nil
+	}
+}
+
+// callTarget 调用单个扇出目标
+//
+// 调用经目标服务器自身的 MCP 服务器处理，与直接调用该服务器一样经过工具过滤、已移除工具处理、
+// 参数大小限制、超时、重试和结果缓存；目标工具名为该服务器对外暴露的名称（含 toolNamePrefix）。
+func (fs *FanOutServer) callTarget(ctx context.Context, target fanOutTarget, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	proxyServer := fs.manager.GetServer(target.server)
+	if proxyServer == nil || proxyServer.GetClient() == nil {
+		return nil, fmt.Errorf("server %s is not available", target.server)
+	}
+	if !proxyServer.HasTool(target.tool) {
+		return nil, fmt.Errorf("tool %s is not registered on server %s", target.tool, target.server)
+	}
+
+	switch response := proxyServer.handleToolCall(ctx, target.tool, request.Params.Arguments).(type) {
+	case mcp.JSONRPCResponse:
+		result, ok := response.Result.(mcp.CallToolResult)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result %T", response.Result)
+		}
+		return &result, nil
+	case mcp.JSONRPCError:
+		return nil, errors.New(response.Error.Message)
+	default:
+		return nil, fmt.Errorf("unexpected response %T", response)
+	}
+}
+
+// parseFanOutTargets 解析 serverName.toolName 形式的目标列表
+func parseFanOutTargets(entries []string) ([]fanOutTarget, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no targets")
+	}
+	targets := make([]fanOutTarget, 0, len(entries))
+	for _, entry := range entries {
+		serverName, toolName, ok := strings.Cut(entry, ".")
+		if !ok || serverName == "" || toolName == "" {
+			return nil, fmt.Errorf("invalid target %q, expected serverName.toolName", entry)
+		}
+		targets = append(targets, fanOutTarget{server: serverName, tool: toolName})
+	}
+	return targets, nil
+}
+
+// joinTargets 拼接目标名称，用于工具描述
+func joinTargets(targets []fanOutTarget) string {
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		names[i] = target.String()
+	}
+	return strings.Join(names, ", ")
+}
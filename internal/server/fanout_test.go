@@ -0,0 +1,43 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/testutil"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestFanOutAppliesTargetToolFilter(t *testing.T) {
+	open := testutil.NewMockMCPServer(t).ServerConfig()
+	filtered := testutil.NewMockMCPServer(t).ServerConfig()
+	filtered.Options = &interfaces.OptionsConfig{
+		ToolFilter: &interfaces.ToolFilterConfig{Mode: interfaces.ToolFilterModeBlock, List: []string{"echo"}},
+	}
+
+	addr := testutil.StartProxy(t, &interfaces.Config{
+		Proxy: interfaces.ProxyConfig{
+			Name:         "test-proxy",
+			Version:      "1.0.0",
+			Type:         interfaces.TransportTypeSSE,
+			FanOutGroups: map[string][]string{"echo_all": {"open.echo", "filtered.echo"}},
+		},
+		Servers: map[string]interfaces.ServerConfig{"open": open, "filtered": filtered},
+	})
+
+	result := testutil.MustCallTool(t, addr, interfaces.FanOutServerName, "echo_all", map[string]any{"message": "hi"})
+	if result.IsError {
+		t.Fatalf("fan-out result IsError = true, want partial success: %+v", result.Content)
+	}
+	if len(result.Content) != 2 {
+		t.Fatalf("fan-out returned %d content entries, want 2", len(result.Content))
+	}
+	if text, ok := result.Content[0].(mcp.TextContent); !ok || text.Text != "hi" {
+		t.Errorf("content[0] = %+v, want echo from open", result.Content[0])
+	}
+	// 被目标服务器过滤的工具不会绕过过滤直接转发给上游
+	if text, ok := result.Content[1].(mcp.TextContent); !ok || !strings.Contains(text.Text, "error from filtered.echo") {
+		t.Errorf("content[1] = %+v, want error from filtered.echo", result.Content[1])
+	}
+}
@@ -3,12 +3,14 @@ package server
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
 )
 
+var _ interfaces.ServerManager = (*Manager)(nil)
+
 // Manager 服务器管理器实现
 type Manager struct {
 	servers map[string]*ProxyServer
@@ -16,7 +18,7 @@ type Manager struct {
 }
 
 // NewManager 创建新的服务器管理器
-func NewManager() interfaces.ServerManager {
+func NewManager() *Manager {
 	return &Manager{
 		servers: make(map[string]*ProxyServer),
 	}
@@ -28,13 +30,13 @@ func (m *Manager) Start(ctx context.Context) error {
 	defer m.mutex.RUnlock()
 
 	for name, server := range m.servers {
-		log.Printf("Starting server: %s", name)
+		slog.Info("Starting server", "server", name)
 		if err := server.Start(ctx); err != nil {
 			return fmt.Errorf("failed to start server %s: %w", name, err)
 		}
 	}
 
-	log.Printf("All servers started successfully")
+	slog.Info("All servers started successfully")
 	return nil
 }
 
@@ -45,9 +47,9 @@ func (m *Manager) Stop(ctx context.Context) error {
 
 	var errors []error
 	for name, server := range m.servers {
-		log.Printf("Stopping server: %s", name)
+		slog.Info("Stopping server", "server", name)
 		if err := server.Stop(ctx); err != nil {
-			log.Printf("Error stopping server %s: %v", name, err)
+			slog.Error("Error stopping server", "server", name, "error", err)
 			errors = append(errors, fmt.Errorf("failed to stop server %s: %w", name, err))
 		}
 	}
@@ -56,7 +58,7 @@ func (m *Manager) Stop(ctx context.Context) error {
 		return errors[0] // 返回第一个错误
 	}
 
-	log.Printf("All servers stopped")
+	slog.Info("All servers stopped")
 	return nil
 }
 
@@ -111,7 +113,7 @@ func (m *Manager) AddServer(name string, server *ProxyServer) error {
 	}
 
 	m.servers[name] = server
-	log.Printf("Added server: %s", name)
+	slog.Info("Added server", "server", name)
 	return nil
 }
 
@@ -128,11 +130,11 @@ func (m *Manager) RemoveServer(name string) error {
 	// 停止服务器
 	ctx := context.Background()
 	if err := server.Stop(ctx); err != nil {
-		log.Printf("Error stopping server %s: %v", name, err)
+		slog.Error("Error stopping server", "server", name, "error", err)
 	}
 
 	delete(m.servers, name)
-	log.Printf("Removed server: %s", name)
+	slog.Info("Removed server", "server", name)
 	return nil
 }
 
@@ -155,3 +157,15 @@ func (m *Manager) GetServers() map[string]*ProxyServer {
 	}
 	return result
 }
+
+// GetServerStats 获取所有服务器的统计信息
+func (m *Manager) GetServerStats() map[string]ServerStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make(map[string]ServerStats, len(m.servers))
+	for name, server := range m.servers {
+		result[name] = server.Stats()
+	}
+	return result
+}
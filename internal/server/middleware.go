@@ -0,0 +1,437 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ceyewan/mcp-proxy/internal/cost"
+	"github.com/ceyewan/mcp-proxy/internal/events"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/jwtauth"
+	"github.com/ceyewan/mcp-proxy/internal/middleware/retry"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// timeoutFallbackMiddleware 工具调用超时时返回预设的兜底响应，而不是错误，兜底响应按上游工具名查找
+func timeoutFallbackMiddleware(name, toolNamePrefix string, fallbacks map[string]string) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err == nil || !isTimeout(ctx, err) {
+				return result, err
+			}
+
+			toolName := request.Params.Name
+			slog.WarnContext(ctx, "Tool timed out", "server", name, "tool", toolName, "error", err)
+
+			fallback, ok := fallbacks[upstreamToolName(toolNamePrefix, toolName)]
+			if !ok {
+				return result, err
+			}
+			return mcp.NewToolResultText(fallback), nil
+		}
+	}
+}
+
+// costMiddleware 按工具权重统计成功调用的成本，权重按上游工具名查找，未配置权重的工具计为 1
+func costMiddleware(name, toolNamePrefix string, weights map[string]float64, tracker *cost.Tracker) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil || result.IsError {
+				return result, err
+			}
+
+			weight, ok := weights[upstreamToolName(toolNamePrefix, request.Params.Name)]
+			if !ok {
+				weight = 1.0
+			}
+			tracker.Record(name, request.Params.Name, weight)
+			return result, nil
+		}
+	}
+}
+
+// retryAfterMiddleware 将带有重试时间的错误（如熔断器打开）转为工具错误结果，文本为包含 retry_at 的 JSON
+func retryAfterMiddleware(name string) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			var retryable interface{ RetryAfter() time.Duration }
+			if err == nil || !errors.As(err, &retryable) {
+				return result, err
+			}
+
+			slog.WarnContext(ctx, "Tool call rejected, retry later", "server", name, "tool", request.Params.Name, "error", err)
+			return mcp.NewToolResultError(retry.ErrorText(err.Error(), retryable.RetryAfter())), nil
+		}
+	}
+}
+
+// availabilityMiddleware 客户端不处于 Connected 状态时直接返回断开错误
+func availabilityMiddleware(ps *ProxyServer) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !ps.available.Load() {
+				return nil, ps.errDisconnected()
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// resultLimitMiddleware 工具调用结果序列化后超过 maxBytes 时截断为预览文本
+func resultLimitMiddleware(name string, maxBytes, previewBytes int64) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil {
+				return result, err
+			}
+
+			data, err := json.Marshal(result)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode tool result: %w", err)
+			}
+			size := int64(len(data))
+			if size <= maxBytes {
+				return result, nil
+			}
+
+			slog.WarnContext(ctx, "Tool result too large, truncating", "server", name, "tool", request.Params.Name, "bytes", size, "limit", previewBytes)
+			slog.DebugContext(ctx, "Full tool result", "server", name, "tool", request.Params.Name, "result", string(data))
+
+			preview := truncateUTF8(resultText(result), previewBytes)
+			truncated := &mcp.CallToolResult{
+				Result:  result.Result,
+				IsError: result.IsError,
+				Content: []mcp.Content{
+					mcp.NewTextContent(fmt.Sprintf("%s... [truncated: result exceeded %d bytes]", preview, maxBytes)),
+				},
+			}
+			return truncated, nil
+		}
+	}
+}
+
+// contentTypeMiddleware 移除工具调用结果中 MIME 类型不在 allowed 列表内的内容
+//
+// 文本内容视为 text/plain；allowed 支持 image/* 形式的通配。
+// 所有内容都被移除时返回错误结果说明原因。
+func contentTypeMiddleware(name string, allowed []string) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil || len(result.Content) == 0 {
+				return result, err
+			}
+
+			content := make([]mcp.Content, 0, len(result.Content))
+			var removed []string
+			for _, item := range result.Content {
+				mimeType := contentMIMEType(item)
+				if !mimeTypeAllowed(mimeType, allowed) {
+					removed = append(removed, mimeType)
+					continue
+				}
+				content = append(content, item)
+			}
+			if len(removed) == 0 {
+				return result, nil
+			}
+
+			slog.InfoContext(ctx, "Removed content items of disallowed types from tool result", "server", name, "tool", request.Params.Name, "types", strings.Join(removed, ", "))
+			if len(content) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("tool %s returned only content types that are not allowed: %s (allowed: %s)",
+					request.Params.Name, strings.Join(removed, ", "), strings.Join(allowed, ", "))), nil
+			}
+
+			filtered := *result
+			filtered.Content = content
+			return &filtered, nil
+		}
+	}
+}
+
+// contentMIMEType 返回内容项的 MIME 类型
+func contentMIMEType(content mcp.Content) string {
+	switch c := content.(type) {
+	case mcp.TextContent:
+		return "text/plain"
+	case mcp.ImageContent:
+		return c.MIMEType
+	case mcp.AudioContent:
+		return c.MIMEType
+	case mcp.EmbeddedResource:
+		switch r := c.Resource.(type) {
+		case mcp.TextResourceContents:
+			if r.MIMEType != "" {
+				return r.MIMEType
+			}
+			return "text/plain"
+		case mcp.BlobResourceContents:
+			if r.MIMEType != "" {
+				return r.MIMEType
+			}
+		}
+	}
+	return "application/octet-stream"
+}
+
+// mimeTypeAllowed 检查 MIME 类型是否匹配允许列表，忽略大小写和参数
+func mimeTypeAllowed(mimeType string, allowed []string) bool {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	mimeType = strings.TrimSpace(mimeType)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*/*" || pattern == mimeType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mimeType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// resultText 提取结果中的文本内容，非文本内容以 JSON 形式表示
+func resultText(result *mcp.CallToolResult) string {
+	var builder strings.Builder
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			builder.WriteString(text.Text)
+			continue
+		}
+		data, _ := json.Marshal(content)
+		builder.Write(data)
+	}
+	return builder.String()
+}
+
+// truncateUTF8 截断字符串到最多 maxBytes 字节，不拆分多字节字符
+func truncateUTF8(s string, maxBytes int64) string {
+	if int64(len(s)) <= maxBytes {
+		return s
+	}
+	cut := int(maxBytes)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// injectClaimsMiddleware 将 JWT 声明注入工具调用参数，注入值覆盖调用方传入的同名参数
+func injectClaimsMiddleware(claimNames []string, prefix string) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			claims := jwtauth.ClaimsFromContext(ctx)
+			if claims == nil {
+				return next(ctx, request)
+			}
+
+			// 复制参数，避免修改调用方的数据
+			args := make(map[string]any, len(request.GetArguments())+len(claimNames))
+			for key, value := range request.GetArguments() {
+				args[key] = value
+			}
+			for _, claimName := range claimNames {
+				if value, ok := claims[claimName]; ok {
+					args[prefix+"."+claimName] = value
+				}
+			}
+			request.Params.Arguments = args
+
+			return next(ctx, request)
+		}
+	}
+}
+
+const (
+	// requestIDMetaKey 转发给上游的调用方请求 ID 在 _meta 中的键
+	requestIDMetaKey = "requestId"
+	// callerRequestIDMetaKey 钩子向中间件传递调用方请求 ID 时使用的内部 _meta 键，不会发往上游
+	callerRequestIDMetaKey = "mcp-proxy/callerRequestId"
+)
+
+// callerRequestIDKey 上下文中调用方请求 ID 的键
+type callerRequestIDKey struct{}
+
+// callerRequestID 获取调用方的 JSON-RPC 请求 ID，不存在时返回空字符串
+func callerRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(callerRequestIDKey{}).(string)
+	return id
+}
+
+// recordRequestIDHook 在调用处理器之前将调用方的 JSON-RPC 请求 ID 暂存到 _meta
+//
+// mcp-go 只在钩子中提供请求 ID，钩子又无法修改上下文，
+// 因此先写入内部 _meta 键，再由 requestIDMiddleware 取出放入上下文。
+func recordRequestIDHook(ctx context.Context, id any, request *mcp.CallToolRequest) {
+	if id == nil {
+		return
+	}
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+	if request.Params.Meta.AdditionalFields == nil {
+		request.Params.Meta.AdditionalFields = make(map[string]any)
+	}
+	request.Params.Meta.AdditionalFields[callerRequestIDMetaKey] = fmt.Sprint(id)
+}
+
+// requestIDMiddleware 将暂存的调用方请求 ID 放入上下文
+//
+// mcp-go 客户端会为每个上游请求分配自己的 JSON-RPC ID，无法直接复用调用方的 ID，
+// propagate 为 true 时以 _meta.requestId 作为关联 ID 转发，调用方已设置该字段时保持不变。
+func requestIDMiddleware(propagate bool) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			meta := request.Params.Meta
+			if meta == nil {
+				return next(ctx, request)
+			}
+			id, ok := meta.AdditionalFields[callerRequestIDMetaKey].(string)
+			if !ok {
+				return next(ctx, request)
+			}
+			ctx = context.WithValue(ctx, callerRequestIDKey{}, id)
+
+			// 复制 _meta，去掉内部键后再发往上游
+			fields := make(map[string]any, len(meta.AdditionalFields))
+			for key, value := range meta.AdditionalFields {
+				if key != callerRequestIDMetaKey {
+					fields[key] = value
+				}
+			}
+			if _, exists := fields[requestIDMetaKey]; propagate && !exists {
+				fields[requestIDMetaKey] = id
+			}
+			if len(fields) == 0 && meta.ProgressToken == nil {
+				request.Params.Meta = nil
+			} else {
+				request.Params.Meta = &mcp.Meta{ProgressToken: meta.ProgressToken, AdditionalFields: fields}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// eventsMiddleware 发布工具调用的开始、成功、失败和超时事件
+func eventsMiddleware(name string, bus events.EventBus) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			event := events.ToolCallEvent{
+				EventType:  events.EventCallStart,
+				ServerName: name,
+				ToolName:   request.Params.Name,
+				RequestID:  callerRequestID(ctx),
+			}
+			bus.Publish(event)
+
+			start := time.Now()
+			result, err := next(ctx, request)
+			event.DurationMs = time.Since(start).Milliseconds()
+
+			switch {
+			case err != nil && isTimeout(ctx, err):
+				event.EventType = events.EventCallTimeout
+				event.Error = err.Error()
+			case err != nil:
+				event.EventType = events.EventCallError
+				event.Error = err.Error()
+			case result != nil && result.IsError:
+				event.EventType = events.EventCallError
+				event.Error = resultText(result)
+			default:
+				event.EventType = events.EventCallSuccess
+			}
+			bus.Publish(event)
+
+			return result, err
+		}
+	}
+}
+
+// toolProgressMethod 调用方未提供 progressToken 时使用的进度通知方法
+const toolProgressMethod = "notifications/tool_progress"
+
+// progressMiddleware 工具调用超过 threshold 仍未返回时，每隔 interval 向调用方发送进度通知
+//
+// 通知由代理生成并通过调用方的会话发送（SSE 传输中与工具结果位于同一个流），对上游透明。
+// 调用方提供了 progressToken 时发送标准的 notifications/progress，
+// 否则发送 notifications/tool_progress，参数为 {"tool", "elapsed", "status"}。
+func progressMiddleware(name string, threshold, interval time.Duration) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			mcpServer := server.ServerFromContext(ctx)
+			if mcpServer == nil {
+				return next(ctx, request)
+			}
+
+			var progressToken mcp.ProgressToken
+			if request.Params.Meta != nil {
+				progressToken = request.Params.Meta.ProgressToken
+			}
+
+			done := make(chan struct{})
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+
+				start := time.Now()
+				timer := time.NewTimer(threshold)
+				defer timer.Stop()
+				for {
+					select {
+					case <-done:
+						return
+					case <-ctx.Done():
+						return
+					case <-timer.C:
+					}
+
+					elapsed := time.Since(start).Seconds()
+					var err error
+					if progressToken != nil {
+						err = mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+							"progressToken": progressToken,
+							"progress":      elapsed,
+							"message":       fmt.Sprintf("tool %s running for %.1fs", request.Params.Name, elapsed),
+						})
+					} else {
+						err = mcpServer.SendNotificationToClient(ctx, toolProgressMethod, map[string]any{
+							"tool":    request.Params.Name,
+							"elapsed": elapsed,
+							"status":  "running",
+						})
+					}
+					if err != nil {
+						slog.DebugContext(ctx, "Failed to send progress", "server", name, "tool", request.Params.Name, "error", err)
+					}
+					timer.Reset(interval)
+				}
+			}()
+
+			result, err := next(ctx, request)
+			close(done)
+			<-stopped
+			return result, err
+		}
+	}
+}
+
+// isTimeout 判断错误是否由超时引起
+func isTimeout(ctx context.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return true
+	}
+
+	var timeoutErr interface{ Timeout() bool }
+	return errors.As(err, &timeoutErr) && timeoutErr.Timeout()
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestUpstreamToolName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		name   string
+		want   string
+	}{
+		{prefix: "", name: "search", want: "search"},
+		{prefix: "kb", name: "kb_search", want: "search"},
+		{prefix: "kb", name: "search", want: "search"},
+		{prefix: "kb", name: "kb_kb_search", want: "kb_search"},
+	}
+	for _, tt := range tests {
+		if got := upstreamToolName(tt.prefix, tt.name); got != tt.want {
+			t.Errorf("upstreamToolName(%q, %q) = %q, want %q", tt.prefix, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTimeoutFallbackUsesUpstreamName(t *testing.T) {
+	handler := timeoutFallbackMiddleware("test", "kb", map[string]string{"search": "try again later"})(
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return nil, context.DeadlineExceeded
+		},
+	)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "kb_search"
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("handler() error = %v, want fallback result", err)
+	}
+	if text, ok := result.Content[0].(mcp.TextContent); !ok || text.Text != "try again later" {
+		t.Errorf("handler() content = %+v, want fallback text", result.Content)
+	}
+}
+
+// retryableError 携带重试时间的错误，与熔断器打开时的错误一样实现 RetryAfter
+type retryableError struct{}
+
+func (retryableError) Error() string             { return "circuit breaker is open for server kb, retry in 5s" }
+func (retryableError) RetryAfter() time.Duration { return 5 * time.Second }
+
+func TestRetryAfterMiddleware(t *testing.T) {
+	errPlain := errors.New("upstream failed")
+	tests := []struct {
+		name        string
+		err         error
+		wantErr     error
+		wantRetryAt bool
+	}{
+		{name: "success"},
+		{name: "plain error", err: errPlain, wantErr: errPlain},
+		{name: "retryable error", err: retryableError{}, wantRetryAt: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := retryAfterMiddleware("test")(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return mcp.NewToolResultText("ok"), nil
+			})
+
+			start := time.Now()
+			result, err := handler(context.Background(), mcp.CallToolRequest{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("handler() error = %v, want %v", err, tt.wantErr)
+			}
+			if !tt.wantRetryAt {
+				return
+			}
+
+			if !result.IsError {
+				t.Fatal("result IsError = false, want tool error")
+			}
+			var body struct {
+				Error   string `json:"error"`
+				RetryAt string `json:"retry_at"`
+			}
+			if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &body); err != nil {
+				t.Fatalf("tool error is not JSON: %v", err)
+			}
+			if body.Error != tt.err.Error() {
+				t.Errorf("error = %q, want %q", body.Error, tt.err.Error())
+			}
+			retryAt, err := time.Parse(time.RFC3339, body.RetryAt)
+			if err != nil {
+				t.Fatalf("retry_at = %q: %v", body.RetryAt, err)
+			}
+			if wait := retryAt.Sub(start); wait < 4*time.Second || wait > 6*time.Second {
+				t.Errorf("retry_at is %s after the call, want about 5s", wait)
+			}
+		})
+	}
+}
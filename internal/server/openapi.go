@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxToolCallBodyBytes HTTP 工具调用请求体的最大字节数
+const maxToolCallBodyBytes = 4 << 20
+
+// openAPIDocument OpenAPI 3.1 文档，只包含描述工具调用所需的字段
+type openAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       openAPIInfo                `json:"info"`
+	Servers    []openAPIServer            `json:"servers,omitempty"`
+	Paths      map[string]openAPIPathItem `json:"paths"`
+	Components openAPIComponents          `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIPathItem struct {
+	Post *openAPIOperation `json:"post,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags"`
+	RequestBody openAPIRequestBody         `json:"requestBody"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema interface{} `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]interface{}         `json:"schemas"`
+	SecuritySchemes map[string]openAPISecurityItem `json:"securitySchemes,omitempty"`
+}
+
+type openAPISecurityItem struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// openAPIBearerAuth 认证方案名称，authTokens 和 JWT 均通过 Authorization: Bearer 传递
+const openAPIBearerAuth = "bearerAuth"
+
+// openAPIJSONMediaType 请求和响应的媒体类型
+const openAPIJSONMediaType = "application/json"
+
+// callToolResultSchema MCP CallToolResult 的 JSON Schema
+var callToolResultSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []string{"content"},
+	"properties": map[string]interface{}{
+		"content": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"type"},
+				"properties": map[string]interface{}{
+					"type":     map[string]interface{}{"type": "string", "enum": []string{"text", "image", "audio", "resource"}},
+					"text":     map[string]interface{}{"type": "string"},
+					"data":     map[string]interface{}{"type": "string", "contentEncoding": "base64"},
+					"mimeType": map[string]interface{}{"type": "string"},
+					"resource": map[string]interface{}{"type": "object"},
+				},
+			},
+		},
+		"structuredContent": map[string]interface{}{"type": "object"},
+		"isError":           map[string]interface{}{"type": "boolean"},
+	},
+}
+
+// errorSchema 工具调用失败时返回的错误响应
+var errorSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []string{"error"},
+	"properties": map[string]interface{}{
+		"error": map[string]interface{}{"type": "string"},
+	},
+}
+
+// OpenAPIHandler 返回描述 servedBy 代理下所有工具 HTTP 调用接口的 OpenAPI 3.1 文档处理器
+//
+// 与 AggregatedToolsHandler 一样只包含已连接且开启了工具发现的服务器，
+// 每个工具对应 POST /{server}/tools/{tool}，请求体为工具参数，响应为 CallToolResult。
+func OpenAPIHandler(proxyConfig *interfaces.ProxyConfig, manager *Manager, servedBy string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc := openAPIDocument{
+			OpenAPI: "3.1.0",
+			Info:    openAPIInfo{Title: proxyConfig.Name, Version: proxyConfig.Version},
+			Paths:   make(map[string]openAPIPathItem),
+			Components: openAPIComponents{
+				Schemas: map[string]interface{}{
+					"CallToolResult": callToolResultSchema,
+					"Error":          errorSchema,
+				},
+			},
+		}
+		if proxyConfig.BaseURL != "" {
+			doc.Servers = []openAPIServer{{URL: proxyConfig.BaseURL}}
+		}
+
+		for _, ps := range discoverableServers(manager, servedBy) {
+			var security []map[string][]string
+			if ps.requiresAuth() {
+				security = []map[string][]string{{openAPIBearerAuth: {}}}
+				doc.Components.SecuritySchemes = map[string]openAPISecurityItem{
+					openAPIBearerAuth: {Type: "http", Scheme: "bearer"},
+				}
+			}
+			for _, tool := range ps.GetTools() {
+				path := "/" + url.PathEscape(ps.name) + "/tools/" + url.PathEscape(tool.Name)
+				doc.Paths[path] = openAPIPathItem{Post: ps.toolOperation(tool, security)}
+			}
+		}
+
+		w.Header().Set("Content-Type", openAPIJSONMediaType)
+		if err := json.NewEncoder(w).Encode(doc); err != nil {
+			slog.Error("Failed to write OpenAPI document", "error", err)
+		}
+	})
+}
+
+// toolOperation 将工具描述转换为 OpenAPI 操作
+func (ps *ProxyServer) toolOperation(tool mcp.Tool, security []map[string][]string) *openAPIOperation {
+	info := newToolInfo(tool, ps.name)
+	return &openAPIOperation{
+		OperationID: ps.name + "_" + tool.Name,
+		Summary:     tool.Annotations.Title,
+		Description: tool.Description,
+		Tags:        []string{ps.name},
+		RequestBody: openAPIRequestBody{
+			Required: true,
+			Content:  map[string]openAPIMediaType{openAPIJSONMediaType: {Schema: info.InputSchema}},
+		},
+		Responses: map[string]openAPIResponse{
+			"200": {
+				Description: "Tool call result",
+				Content:     map[string]openAPIMediaType{openAPIJSONMediaType: {Schema: map[string]string{"$ref": "#/components/schemas/CallToolResult"}}},
+			},
+			"default": {
+				Description: "Tool call failed",
+				Content:     map[string]openAPIMediaType{openAPIJSONMediaType: {Schema: map[string]string{"$ref": "#/components/schemas/Error"}}},
+			},
+		},
+		Security: security,
+	}
+}
+
+// requiresAuth 服务器路由是否需要 Bearer 认证
+func (ps *ProxyServer) requiresAuth() bool {
+	options := ps.serverConfig.Options
+	return options != nil && (len(options.AuthTokens) > 0 || options.JWT != nil)
+}
+
+// CallToolHandler 返回以 HTTP 调用工具的处理器，路由需包含 {tool} 通配符
+//
+// 请求体为工具参数，调用经过与 MCP 会话相同的工具中间件。
+func (ps *ProxyServer) CallToolHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arguments := map[string]interface{}{}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxToolCallBodyBytes))
+		if err != nil {
+			writeToolCallError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &arguments); err != nil {
+				writeToolCallError(w, http.StatusBadRequest, fmt.Errorf("invalid arguments: %w", err))
+				return
+			}
+		}
+
+		switch response := ps.handleToolCall(r.Context(), r.PathValue("tool"), arguments).(type) {
+		case mcp.JSONRPCResponse:
+			w.Header().Set("Content-Type", openAPIJSONMediaType)
+			if err := json.NewEncoder(w).Encode(response.Result); err != nil {
+				slog.Error("Failed to write tool call response", "server", ps.logTag, "tool", r.PathValue("tool"), "error", err)
+			}
+		case mcp.JSONRPCError:
+			status := http.StatusBadGateway
+			if response.Error.Code == mcp.INVALID_PARAMS {
+				status = http.StatusBadRequest
+			}
+			writeToolCallError(w, status, errors.New(response.Error.Message))
+		default:
+			writeToolCallError(w, http.StatusInternalServerError, fmt.Errorf("unexpected response %T", response))
+		}
+	})
+}
+
+// handleToolCall 经 MCP 服务器调用工具，调用经过与 MCP 会话相同的过滤、参数限制、超时、重试和缓存
+func (ps *ProxyServer) handleToolCall(ctx context.Context, name string, arguments any) mcp.JSONRPCMessage {
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(1),
+		Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+		Params: mcp.CallToolParams{
+			Name:      name,
+			Arguments: arguments,
+		},
+	})
+	if err != nil {
+		return mcp.NewJSONRPCError(mcp.NewRequestId(1), mcp.INTERNAL_ERROR, err.Error(), nil)
+	}
+	return ps.mcpServer.HandleMessage(ctx, message)
+}
+
+// writeToolCallError 以 JSON 写出工具调用错误
+func writeToolCallError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", openAPIJSONMediaType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -2,32 +2,159 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ceyewan/mcp-proxy/internal/cost"
+	"github.com/ceyewan/mcp-proxy/internal/events"
 	"github.com/ceyewan/mcp-proxy/internal/interfaces"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultClaimsArgPrefix 注入 JWT 声明时参数名的默认前缀
+	defaultClaimsArgPrefix = "_auth"
+	// defaultToolGracePeriod 上游移除工具后提示"暂不可用"的默认时长
+	defaultToolGracePeriod = 10 * time.Minute
+)
+
+var (
+	// validToolNamePattern 合法的工具名称
+	validToolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	// invalidToolNameChars 工具名称中的非法字符
+	invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
 )
 
 // ProxyServer 代理服务器实现
 type ProxyServer struct {
-	name         string
-	proxyConfig  *interfaces.ProxyConfig
-	serverConfig interfaces.ServerConfig
-	mcpServer    *server.MCPServer
-	handler      http.Handler
-	client       interfaces.MCPClient
+	name                  string
+	logTag                string
+	proxyConfig           *interfaces.ProxyConfig
+	serverConfig          interfaces.ServerConfig
+	mcpServer             *server.MCPServer
+	handler               http.Handler
+	clientMutex           sync.RWMutex
+	client                interfaces.MCPClient
+	costTracker           *cost.Tracker
+	cacheRecorder         CacheRecorder
+	toolCache             *toolCache
+	tools                 map[string]mcp.Tool
+	toolsMutex            sync.RWMutex
+	removedTools          map[string]time.Time
+	refreshMutex          sync.Mutex
+	prompts               map[string]mcp.Prompt
+	resources             map[string]mcp.Resource
+	resourceTemplates     map[string]mcp.ResourceTemplate
+	resourcesMutex        sync.Mutex
+	toolFilter            atomic.Pointer[interfaces.ToolFilterConfig]
+	toolFilterMatcher     atomic.Pointer[toolMatcher]
+	remoteToolFilter      atomic.Pointer[[]string]
+	remoteToolMatcher     atomic.Pointer[toolMatcher]
+	promptFilterMatcher   *toolMatcher
+	resourceFilterMatcher *toolMatcher
+	stopToolFilterList    context.CancelFunc
+	sessions              *sessionContexts
+	eventBus              events.EventBus
+	statsMutex            sync.RWMutex
+	stats                 ServerStats
+	available             atomic.Bool
+	unsubscribe           func()
+}
+
+// ServerStats 代理服务器统计信息
+type ServerStats struct {
+	ToolCount     int    `json:"toolCount"`
+	ResourceCount int    `json:"resourceCount"`
+	PromptCount   int    `json:"promptCount"`
+	Connected     bool   `json:"connected"`
+	Transport     string `json:"transport"`
+	LogTag        string `json:"logTag"`
+	LastError     string `json:"lastError,omitempty"`
+}
+
+// Option 代理服务器可选配置
+type Option func(*ProxyServer)
+
+// WithCostTracker 设置工具调用成本统计器
+func WithCostTracker(tracker *cost.Tracker) Option {
+	return func(ps *ProxyServer) {
+		ps.costTracker = tracker
+	}
+}
+
+// WithCacheRecorder 设置工具结果缓存命中指标记录器
+func WithCacheRecorder(recorder CacheRecorder) Option {
+	return func(ps *ProxyServer) {
+		ps.cacheRecorder = recorder
+	}
+}
+
+// WithEventBus 设置工具调用生命周期事件总线
+func WithEventBus(bus events.EventBus) Option {
+	return func(ps *ProxyServer) {
+		ps.eventBus = bus
+	}
 }
 
 // NewProxyServer 创建新的代理服务器
-func NewProxyServer(name string, proxyConfig *interfaces.ProxyConfig, serverConfig interfaces.ServerConfig) (*ProxyServer, error) {
+func NewProxyServer(name string, proxyConfig *interfaces.ProxyConfig, serverConfig interfaces.ServerConfig, opts ...Option) (*ProxyServer, error) {
+	ps := &ProxyServer{
+		name:              name,
+		logTag:            interfaces.ServerLogTag(name, serverConfig),
+		proxyConfig:       proxyConfig,
+		serverConfig:      serverConfig,
+		tools:             make(map[string]mcp.Tool),
+		removedTools:      make(map[string]time.Time),
+		prompts:           make(map[string]mcp.Prompt),
+		resources:         make(map[string]mcp.Resource),
+		resourceTemplates: make(map[string]mcp.ResourceTemplate),
+		sessions:          newSessionContexts(),
+	}
+	if serverConfig.Options != nil {
+		ps.setToolFilter(serverConfig.Options.ToolFilter)
+		if serverConfig.Options.PromptFilter != nil {
+			// 配置加载时已校验
+			ps.promptFilterMatcher, _ = newToolMatcher(serverConfig.Options.PromptFilter.List)
+		}
+		if serverConfig.Options.ResourceFilter != nil {
+			// 配置加载时已校验
+			ps.resourceFilterMatcher, _ = newToolMatcher(serverConfig.Options.ResourceFilter.List)
+		}
+	}
+	for _, opt := range opts {
+		opt(ps)
+	}
+
+	// 跟随客户端连接状态更新工具可用性
+	ps.available.Store(true)
+	if ps.eventBus != nil {
+		ps.watchClientState()
+	}
+
+	// 会话生命周期钩子，调用方断开时取消进行中的上游调用
+	hooks := ps.sessions.hooks()
+
+	// 记录调用方的请求 ID，用于事件关联和向上游转发
+	hooks.AddBeforeCallTool(recordRequestIDHook)
+	propagate := serverConfig.Options != nil && serverConfig.Options.PropagateRequestID != nil && *serverConfig.Options.PropagateRequestID
+
 	// 创建 MCP 服务器选项
 	serverOpts := []server.ServerOption{
 		server.WithResourceCapabilities(true, true),
 		server.WithRecovery(),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(requestIDMiddleware(propagate)),
+		server.WithToolHandlerMiddleware(availabilityMiddleware(ps)),
 	}
 
 	// 根据配置决定是否启用日志
@@ -35,82 +162,268 @@ func NewProxyServer(name string, proxyConfig *interfaces.ProxyConfig, serverConf
 		serverOpts = append(serverOpts, server.WithLogging())
 	}
 
+	// 按工具名配置的选项以上游工具名为键，中间件查找前去掉 toolNamePrefix
+	var toolNamePrefix string
+	if serverConfig.Options != nil {
+		toolNamePrefix = serverConfig.Options.ToolNamePrefix
+	}
+
+	// 工具调用超时记录与兜底响应
+	var fallbacks map[string]string
+	if serverConfig.Options != nil {
+		fallbacks = serverConfig.Options.ToolTimeoutFallbacks
+	}
+	serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(timeoutFallbackMiddleware(ps.logTag, toolNamePrefix, fallbacks)))
+
+	// 长时间运行的工具调用向调用方发送进度通知，配置已在加载时校验
+	if serverConfig.Options != nil && serverConfig.Options.ProgressEventThreshold != "" {
+		threshold, _ := time.ParseDuration(serverConfig.Options.ProgressEventThreshold)
+		interval := threshold
+		if serverConfig.Options.ProgressEventInterval != "" {
+			interval, _ = time.ParseDuration(serverConfig.Options.ProgressEventInterval)
+		}
+		if threshold > 0 && interval > 0 {
+			serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(progressMiddleware(ps.logTag, threshold, interval)))
+		}
+	}
+
+	// 工具调用生命周期事件，位于超时兜底之内以区分超时
+	if ps.eventBus != nil {
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(eventsMiddleware(name, ps.eventBus)))
+	}
+
+	// 工具调用结果大小限制
+	if serverConfig.Options != nil && serverConfig.Options.MaxToolResultBytes > 0 {
+		maxBytes := serverConfig.Options.MaxToolResultBytes
+		previewBytes := serverConfig.Options.MaxToolResultPreviewBytes
+		if previewBytes == 0 {
+			previewBytes = maxBytes
+		}
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(resultLimitMiddleware(ps.logTag, maxBytes, previewBytes)))
+	}
+
+	// 工具调用结果内容类型限制，位于大小限制之内以免被移除的内容计入大小
+	if serverConfig.Options != nil && len(serverConfig.Options.AllowedContentTypes) > 0 {
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(contentTypeMiddleware(ps.logTag, serverConfig.Options.AllowedContentTypes)))
+	}
+
+	// 工具调用成本统计
+	if ps.costTracker != nil {
+		var weights map[string]float64
+		if serverConfig.Options != nil {
+			weights = serverConfig.Options.ToolCostWeights
+		}
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(costMiddleware(name, toolNamePrefix, weights, ps.costTracker)))
+	}
+
+	// JWT 声明注入工具调用参数
+	if serverConfig.Options != nil && len(serverConfig.Options.InjectClaimsAsArgs) > 0 {
+		prefix := serverConfig.Options.InjectClaimsPrefix
+		if prefix == "" {
+			prefix = defaultClaimsArgPrefix
+		}
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(injectClaimsMiddleware(serverConfig.Options.InjectClaimsAsArgs, prefix)))
+	}
+
+	// 工具调用结果缓存，位于声明注入之内，使不同调用方的注入参数参与缓存键
+	if serverConfig.Options != nil && serverConfig.Options.ToolCache != nil {
+		ps.toolCache = newToolCache(serverConfig.Options.ToolCache)
+		serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(toolCacheMiddleware(name, toolNamePrefix, ps.toolCache, ps.cacheRecorder)))
+	}
+
+	// 熔断器打开时返回带 retry_at 的工具错误，位于最内层，外层中间件看到的是错误结果
+	serverOpts = append(serverOpts, server.WithToolHandlerMiddleware(retryAfterMiddleware(ps.logTag)))
+
 	// 创建 MCP 服务器
-	mcpServer := server.NewMCPServer(
+	ps.mcpServer = server.NewMCPServer(
 		proxyConfig.Name,
 		proxyConfig.Version,
 		serverOpts...,
 	)
 
 	// 创建 HTTP 处理器
-	var handler http.Handler
 	switch proxyConfig.Type {
 	case interfaces.TransportTypeSSE:
-		handler = server.NewSSEServer(
-			mcpServer,
+		ps.handler = server.NewSSEServer(
+			ps.mcpServer,
 			server.WithStaticBasePath(name),
 			server.WithBaseURL(proxyConfig.BaseURL),
 		)
 	case interfaces.TransportTypeHTTP:
-		handler = server.NewStreamableHTTPServer(
-			mcpServer,
-			server.WithStateLess(true),
-		)
+		ps.handler = server.NewStreamableHTTPServer(ps.mcpServer, streamableOptions(proxyConfig)...)
 	default:
 		return nil, fmt.Errorf("unsupported server type: %s", proxyConfig.Type)
 	}
 
-	return &ProxyServer{
-		name:         name,
-		proxyConfig:  proxyConfig,
-		serverConfig: serverConfig,
-		mcpServer:    mcpServer,
-		handler:      handler,
-	}, nil
+	return ps, nil
+}
+
+// streamableOptions 返回 Streamable HTTP 服务器选项
+//
+// 默认无状态；stateful 为 true 时由 mcp-go 分配并校验 Mcp-Session-Id，
+// 代理自身不保存任何会话状态。
+func streamableOptions(proxyConfig *interfaces.ProxyConfig) []server.StreamableHTTPOption {
+	if proxyConfig.Stateful != nil && *proxyConfig.Stateful {
+		return nil
+	}
+	return []server.StreamableHTTPOption{server.WithStateLess(true)}
 }
 
 // Start 启动代理服务器
 func (ps *ProxyServer) Start(ctx context.Context) error {
-	log.Printf("<%s> Proxy server started", ps.name)
+	slog.Info("Proxy server started", "server", ps.logTag)
 	return nil
 }
 
 // Stop 停止代理服务器
 func (ps *ProxyServer) Stop(ctx context.Context) error {
-	log.Printf("<%s> Proxy server stopped", ps.name)
+	if ps.unsubscribe != nil {
+		ps.unsubscribe()
+	}
+	slog.Info("Proxy server stopped", "server", ps.logTag)
 	return nil
 }
 
 // RegisterClient 注册客户端到代理服务器
 func (ps *ProxyServer) RegisterClient(client interfaces.MCPClient) error {
+	ps.clientMutex.Lock()
 	if ps.client != nil {
+		ps.clientMutex.Unlock()
 		return fmt.Errorf("client already registered for server %s", ps.name)
 	}
-
 	ps.client = client
+	ps.clientMutex.Unlock()
+	ps.invalidateToolCache()
+
+	// 获取远程工具过滤列表
+	ps.startToolFilterList()
 
 	// 添加客户端的工具、资源等到代理服务器
-	if err := ps.addClientResources(client); err != nil {
+	err := ps.addClientResources(context.Background(), client)
+	ps.updateStats(err)
+	if err != nil {
 		return fmt.Errorf("failed to add client resources: %w", err)
 	}
 
-	log.Printf("<%s> Client registered successfully", ps.name)
+	slog.Info("Client registered successfully", "server", ps.logTag, "client", client.GetName())
 	return nil
 }
 
 // UnregisterClient 注销客户端
 func (ps *ProxyServer) UnregisterClient() error {
+	ps.clientMutex.Lock()
 	if ps.client == nil {
+		ps.clientMutex.Unlock()
 		return fmt.Errorf("no client registered for server %s", ps.name)
 	}
-
 	ps.client = nil
-	log.Printf("<%s> Client unregistered", ps.name)
+	ps.clientMutex.Unlock()
+	if ps.stopToolFilterList != nil {
+		ps.stopToolFilterList()
+		ps.stopToolFilterList = nil
+	}
+
+	// 已注册的工具、提示词和资源仍保留在 MCP 服务器中，替换为返回断开错误的处理函数
+	ps.UnregisterAllTools()
+	ps.UnregisterAllPrompts()
+	ps.UnregisterAllResources()
+
+	slog.Info("Client unregistered", "server", ps.logTag)
 	return nil
 }
 
+// UnregisterAllTools 将所有已注册工具的处理函数替换为返回断开错误的函数
+func (ps *ProxyServer) UnregisterAllTools() {
+	ps.toolsMutex.RLock()
+	tools := make([]server.ServerTool, 0, len(ps.tools))
+	for _, tool := range ps.tools {
+		tools = append(tools, server.ServerTool{
+			Tool: tool,
+			Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return nil, ps.errDisconnected()
+			},
+		})
+	}
+	ps.toolsMutex.RUnlock()
+
+	if len(tools) > 0 {
+		ps.mcpServer.AddTools(tools...)
+	}
+}
+
+// UnregisterAllPrompts 将所有已注册提示词的处理函数替换为返回断开错误的函数
+func (ps *ProxyServer) UnregisterAllPrompts() {
+	ps.resourcesMutex.Lock()
+	prompts := make([]server.ServerPrompt, 0, len(ps.prompts))
+	for _, prompt := range ps.prompts {
+		prompts = append(prompts, server.ServerPrompt{
+			Prompt: prompt,
+			Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				return nil, ps.errDisconnected()
+			},
+		})
+	}
+	ps.resourcesMutex.Unlock()
+
+	if len(prompts) > 0 {
+		ps.mcpServer.AddPrompts(prompts...)
+	}
+}
+
+// UnregisterAllResources 将所有已注册资源和资源模板的处理函数替换为返回断开错误的函数
+func (ps *ProxyServer) UnregisterAllResources() {
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return nil, ps.errDisconnected()
+	}
+
+	ps.resourcesMutex.Lock()
+	resources := make([]server.ServerResource, 0, len(ps.resources))
+	for _, resource := range ps.resources {
+		resources = append(resources, server.ServerResource{Resource: resource, Handler: handler})
+	}
+	resourceTemplates := make([]mcp.ResourceTemplate, 0, len(ps.resourceTemplates))
+	for _, resourceTemplate := range ps.resourceTemplates {
+		resourceTemplates = append(resourceTemplates, resourceTemplate)
+	}
+	ps.resourcesMutex.Unlock()
+
+	if len(resources) > 0 {
+		ps.mcpServer.AddResources(resources...)
+	}
+	for _, resourceTemplate := range resourceTemplates {
+		ps.mcpServer.AddResourceTemplate(resourceTemplate, handler)
+	}
+}
+
+// watchClientState 订阅客户端连接状态变化，仅在 Connected 状态下允许调用工具
+//
+// MCP 的工具注解没有表示可用性的字段，因此可用性在调用路径上生效：
+// 客户端离开 Connected 状态后，工具调用直接返回断开错误而不再转发给上游。
+func (ps *ProxyServer) watchClientState() {
+	changes, unsubscribe := ps.eventBus.SubscribeStateChanges()
+	ps.unsubscribe = unsubscribe
+	go func() {
+		for change := range changes {
+			if change.Client != ps.name {
+				continue
+			}
+			ps.available.Store(change.To == interfaces.ClientStateConnected)
+			if change.To == interfaces.ClientStateConnected && change.From != interfaces.ClientStateConnected {
+				ps.invalidateToolCache()
+			}
+		}
+	}()
+}
+
+// errDisconnected 上游客户端已断开时返回的错误
+func (ps *ProxyServer) errDisconnected() error {
+	return fmt.Errorf("server %s is disconnected", ps.name)
+}
+
 // GetClient 获取注册的客户端
 func (ps *ProxyServer) GetClient() interfaces.MCPClient {
+	ps.clientMutex.RLock()
+	defer ps.clientMutex.RUnlock()
 	return ps.client
 }
 
@@ -119,31 +432,154 @@ func (ps *ProxyServer) GetHandler() http.Handler {
 	return ps.handler
 }
 
+// RefreshResources 重新从上游获取工具、提示词和资源
+//
+// mcp-go 虽然支持删除工具，但直接删除会让调用方只看到 "tool not found"。
+// 上游已移除的工具仍保留注册，调用时返回说明性的错误：宽限期内提示刷新工具列表，
+// 超过 toolGracePeriod 后提示工具已被永久移除。
+func (ps *ProxyServer) RefreshResources(ctx context.Context) error {
+	ps.refreshMutex.Lock()
+	defer ps.refreshMutex.Unlock()
+
+	client := ps.GetClient()
+	if client == nil {
+		return fmt.Errorf("no client registered for server %s", ps.name)
+	}
+
+	ps.toolsMutex.Lock()
+	previous := ps.tools
+	ps.tools = make(map[string]mcp.Tool, len(previous))
+	ps.toolsMutex.Unlock()
+
+	if err := ps.addClientResources(ctx, client); err != nil {
+		// 刷新失败时保留原有工具列表
+		ps.toolsMutex.Lock()
+		for name, tool := range previous {
+			if _, ok := ps.tools[name]; !ok {
+				ps.tools[name] = tool
+			}
+		}
+		ps.toolsMutex.Unlock()
+		ps.updateStats(err)
+		return fmt.Errorf("failed to refresh resources: %w", err)
+	}
+	ps.updateStats(nil)
+
+	ps.toolsMutex.Lock()
+	defer ps.toolsMutex.Unlock()
+	for name, tool := range previous {
+		if _, ok := ps.tools[name]; ok {
+			continue
+		}
+		slog.Warn("Tool was removed upstream", "server", ps.logTag, "tool", name)
+		ps.removedTools[name] = time.Now()
+		ps.mcpServer.AddTool(tool, ps.removedToolHandler(name))
+	}
+
+	slog.Info("Resources refreshed", "server", ps.logTag)
+	return nil
+}
+
+// updateStats 在获取上游工具、提示词和资源后更新统计，err 为本次获取的错误
+func (ps *ProxyServer) updateStats(err error) {
+	ps.toolsMutex.RLock()
+	toolCount := len(ps.tools)
+	ps.toolsMutex.RUnlock()
+
+	ps.resourcesMutex.Lock()
+	promptCount := len(ps.prompts)
+	resourceCount := len(ps.resources)
+	ps.resourcesMutex.Unlock()
+
+	ps.statsMutex.Lock()
+	defer ps.statsMutex.Unlock()
+	ps.stats.ToolCount = toolCount
+	ps.stats.PromptCount = promptCount
+	ps.stats.ResourceCount = resourceCount
+	ps.stats.LastError = ""
+	if err != nil {
+		ps.stats.LastError = err.Error()
+	}
+}
+
+// Stats 获取代理服务器统计信息
+func (ps *ProxyServer) Stats() ServerStats {
+	ps.statsMutex.RLock()
+	stats := ps.stats
+	ps.statsMutex.RUnlock()
+
+	stats.Transport = ps.serverConfig.Transport
+	stats.LogTag = ps.logTag
+	if client := ps.GetClient(); client != nil {
+		stats.Connected = client.IsConnected()
+	}
+	return stats
+}
+
+// removedToolHandler 返回已被上游移除的工具的处理函数
+func (ps *ProxyServer) removedToolHandler(name string) server.ToolHandlerFunc {
+	gracePeriod := defaultToolGracePeriod
+	if ps.serverConfig.Options != nil && ps.serverConfig.Options.ToolGracePeriod != "" {
+		// 配置已在加载时校验
+		gracePeriod, _ = time.ParseDuration(ps.serverConfig.Options.ToolGracePeriod)
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ps.toolsMutex.RLock()
+		removedAt, ok := ps.removedTools[name]
+		ps.toolsMutex.RUnlock()
+
+		if ok && time.Since(removedAt) >= gracePeriod {
+			return nil, fmt.Errorf("tool %s has been permanently removed by the upstream server", name)
+		}
+		return nil, fmt.Errorf("tool %s is no longer available, please refresh the tool list", name)
+	}
+}
+
 // addClientResources 添加客户端资源到代理服务器
-func (ps *ProxyServer) addClientResources(client interfaces.MCPClient) error {
-	ctx := context.Background()
+func (ps *ProxyServer) addClientResources(ctx context.Context, client interfaces.MCPClient) error {
+	// 游标分页只能顺序获取，并发作用于不同类型的列表之间
+	concurrency := 1
+	if ps.serverConfig.Options != nil && ps.serverConfig.Options.ListPageConcurrency > 1 {
+		concurrency = ps.serverConfig.Options.ListPageConcurrency
+	}
+
+	var errorGroup errgroup.Group
+	errorGroup.SetLimit(concurrency)
 
 	// 添加工具
-	if err := ps.addTools(ctx, client); err != nil {
-		return fmt.Errorf("failed to add tools: %w", err)
-	}
+	errorGroup.Go(func() error {
+		if err := ps.addTools(ctx, client); err != nil {
+			return fmt.Errorf("failed to add tools: %w", err)
+		}
+		return nil
+	})
 
 	// 添加提示词
-	if err := ps.addPrompts(ctx, client); err != nil {
-		log.Printf("<%s> Failed to add prompts: %v", ps.name, err)
-	}
+	errorGroup.Go(func() error {
+		if err := ps.addPrompts(ctx, client); err != nil {
+			slog.Error("Failed to add prompts", "server", ps.logTag, "error", err)
+		}
+		return nil
+	})
 
 	// 添加资源
-	if err := ps.addResources(ctx, client); err != nil {
-		log.Printf("<%s> Failed to add resources: %v", ps.name, err)
-	}
+	errorGroup.Go(func() error {
+		if err := ps.addResources(ctx, client); err != nil {
+			slog.Error("Failed to add resources", "server", ps.logTag, "error", err)
+		}
+		return nil
+	})
 
 	// 添加资源模板
-	if err := ps.addResourceTemplates(ctx, client); err != nil {
-		log.Printf("<%s> Failed to add resource templates: %v", ps.name, err)
-	}
+	errorGroup.Go(func() error {
+		if err := ps.addResourceTemplates(ctx, client); err != nil {
+			slog.Error("Failed to add resource templates", "server", ps.logTag, "error", err)
+		}
+		return nil
+	})
 
-	return nil
+	return errorGroup.Wait()
 }
 
 // addTools 添加工具
@@ -163,12 +599,38 @@ func (ps *ProxyServer) addTools(ctx context.Context, client interfaces.MCPClient
 			break
 		}
 
-		log.Printf("<%s> Successfully listed %d tools", ps.name, len(tools.Tools))
+		slog.Info("Successfully listed tools", "server", ps.logTag, "count", len(tools.Tools))
 		for _, tool := range tools.Tools {
-			if filterFunc(tool.Name) {
-				log.Printf("<%s> Adding tool %s", ps.name, tool.Name)
-				ps.mcpServer.AddTool(tool, client.CallTool)
+			if !filterFunc(tool.Name) {
+				continue
+			}
+
+			handler := server.ToolHandlerFunc(cancelOnDisconnect(ps.sessions, client.CallTool))
+			if ps.serverConfig.Retry != nil {
+				handler = ps.retryToolCall(handler, ps.serverConfig.Retry)
+			}
+			if timeout := ps.toolTimeout(tool.Name); timeout > 0 {
+				handler = limitToolDuration(handler, timeout)
+			}
+			if !validToolNamePattern.MatchString(tool.Name) {
+				var ok bool
+				if tool, handler, ok = ps.handleInvalidToolName(tool, handler); !ok {
+					continue
+				}
+			}
+			if options := ps.serverConfig.Options; options != nil && options.ToolNamePrefix != "" {
+				tool, handler = prefixTool(options.ToolNamePrefix, tool, handler)
 			}
+			if options := ps.serverConfig.Options; options != nil && options.MaxToolArgBytes > 0 {
+				handler = ps.limitToolArgs(handler, options.MaxToolArgBytes)
+			}
+
+			slog.Info("Adding tool", "server", ps.logTag, "tool", tool.Name)
+			ps.mcpServer.AddTool(tool, handler)
+			ps.toolsMutex.Lock()
+			ps.tools[tool.Name] = tool
+			delete(ps.removedTools, tool.Name)
+			ps.toolsMutex.Unlock()
 		}
 
 		if tools.NextCursor == "" {
@@ -180,49 +642,156 @@ func (ps *ProxyServer) addTools(ctx context.Context, client interfaces.MCPClient
 	return nil
 }
 
+// limitToolArgs 限制工具调用参数序列化后的大小，超限时不转发给上游
+func (ps *ProxyServer) limitToolArgs(handler server.ToolHandlerFunc, maxBytes int64) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		data, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode arguments: %w", err)
+		}
+		if size := int64(len(data)); size > maxBytes {
+			slog.WarnContext(ctx, "Rejected tool call, argument payload too large", "server", ps.logTag, "tool", request.Params.Name, "bytes", size, "limit", maxBytes)
+			return nil, errors.New("argument payload too large")
+		}
+		return handler(ctx, request)
+	}
+}
+
+// toolTimeout 获取工具调用的超时时间，按上游工具名查找 toolTimeout，未配置时使用 defaultToolTimeout
+//
+// 配置已在加载时校验，返回 0 表示不设置超时。
+func (ps *ProxyServer) toolTimeout(toolName string) time.Duration {
+	if options := ps.serverConfig.Options; options != nil {
+		if value, ok := options.ToolTimeout[toolName]; ok {
+			timeout, _ := time.ParseDuration(value)
+			return timeout
+		}
+	}
+	if ps.serverConfig.DefaultToolTimeout == "" {
+		return 0
+	}
+	timeout, _ := time.ParseDuration(ps.serverConfig.DefaultToolTimeout)
+	return timeout
+}
+
+// limitToolDuration 为上游调用设置超时，超时覆盖包括重试在内的整个调用
+func limitToolDuration(handler server.ToolHandlerFunc, timeout time.Duration) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, request)
+	}
+}
+
+// handleInvalidToolName 处理名称不合法的工具，返回 false 表示跳过该工具
+func (ps *ProxyServer) handleInvalidToolName(tool mcp.Tool, handler server.ToolHandlerFunc) (mcp.Tool, server.ToolHandlerFunc, bool) {
+	options := ps.serverConfig.Options
+	if options != nil && options.SanitizeToolNames != nil && *options.SanitizeToolNames {
+		originalName := tool.Name
+		tool.Name = invalidToolNameChars.ReplaceAllString(originalName, "_")
+		slog.Warn("Tool name is invalid, registering sanitized name", "server", ps.logTag, "tool", originalName, "registered_as", tool.Name)
+
+		// 转发给上游时还原原始名称
+		return tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			request.Params.Name = originalName
+			return handler(ctx, request)
+		}, true
+	}
+
+	if options != nil && options.StrictToolNames != nil && *options.StrictToolNames {
+		slog.Warn("Skipping tool as its name is invalid", "server", ps.logTag, "tool", tool.Name)
+		return tool, handler, false
+	}
+
+	slog.Warn("Tool name is invalid", "server", ps.logTag, "tool", tool.Name)
+	return tool, handler, true
+}
+
+// prefixTool 以 {prefix}_{name} 注册工具，转发给上游时去掉前缀
+func prefixTool(prefix string, tool mcp.Tool, handler server.ToolHandlerFunc) (mcp.Tool, server.ToolHandlerFunc) {
+	name := tool.Name
+	tool.Name = prefix + "_" + name
+	return tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		request.Params.Name = name
+		return handler(ctx, request)
+	}
+}
+
+// upstreamToolName 去掉 toolNamePrefix 还原上游工具名，未配置前缀时原样返回
+func upstreamToolName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimPrefix(name, prefix+"_")
+}
+
 // createToolFilter 创建工具过滤函数
 func (ps *ProxyServer) createToolFilter() func(string) bool {
-	// 默认全部通过
-	filterFunc := func(toolName string) bool {
-		return true
+	filter := ps.toolFilter.Load()
+	if filter == nil || (len(filter.List) == 0 && filter.ListURL == "") {
+		return allowAll
 	}
 
-	// 根据配置设置过滤逻辑
-	if ps.serverConfig.Options != nil && ps.serverConfig.Options.ToolFilter != nil && len(ps.serverConfig.Options.ToolFilter.List) > 0 {
-		filterSet := make(map[string]struct{})
-		mode := strings.ToLower(ps.serverConfig.Options.ToolFilter.Mode)
-		for _, toolName := range ps.serverConfig.Options.ToolFilter.List {
-			filterSet[toolName] = struct{}{}
-		}
+	// 合并配置列表与远程列表
+	static, remote := ps.toolFilterMatcher.Load(), ps.remoteToolMatcher.Load()
+	return ps.createFilter("tool", filter.Mode, func(toolName string) bool {
+		return static.Match(toolName) || remote.Match(toolName)
+	})
+}
 
-		switch mode {
-		case interfaces.ToolFilterModeAllow:
-			filterFunc = func(toolName string) bool {
-				_, inList := filterSet[toolName]
-				if !inList {
-					log.Printf("<%s> Ignoring tool %s as it is not in allow list", ps.name, toolName)
-				}
-				return inList
+// createPromptFilter 创建提示词过滤函数
+func (ps *ProxyServer) createPromptFilter() func(string) bool {
+	if ps.serverConfig.Options == nil || ps.serverConfig.Options.PromptFilter == nil || len(ps.serverConfig.Options.PromptFilter.List) == 0 {
+		return allowAll
+	}
+	return ps.createFilter("prompt", ps.serverConfig.Options.PromptFilter.Mode, ps.promptFilterMatcher.Match)
+}
+
+// createResourceFilter 创建资源过滤函数，按资源 URI 或资源模板的 URI 模板匹配
+func (ps *ProxyServer) createResourceFilter() func(string) bool {
+	if ps.serverConfig.Options == nil || ps.serverConfig.Options.ResourceFilter == nil || len(ps.serverConfig.Options.ResourceFilter.List) == 0 {
+		return allowAll
+	}
+	return ps.createFilter("resource", ps.serverConfig.Options.ResourceFilter.Mode, ps.resourceFilterMatcher.Match)
+}
+
+// createFilter 按 allow/block 模式创建过滤函数，kind 为日志中的对象类型
+func (ps *ProxyServer) createFilter(kind, mode string, inList func(string) bool) func(string) bool {
+	switch strings.ToLower(mode) {
+	case interfaces.ToolFilterModeAllow:
+		return func(name string) bool {
+			if !inList(name) {
+				slog.Info("Ignoring "+kind+" as it is not in allow list", "server", ps.logTag, kind, name)
+				return false
 			}
-		case interfaces.ToolFilterModeBlock:
-			filterFunc = func(toolName string) bool {
-				_, inList := filterSet[toolName]
-				if inList {
-					log.Printf("<%s> Ignoring tool %s as it is in block list", ps.name, toolName)
-				}
-				return !inList
+			return true
+		}
+	case interfaces.ToolFilterModeBlock:
+		return func(name string) bool {
+			if inList(name) {
+				slog.Info("Ignoring "+kind+" as it is in block list", "server", ps.logTag, kind, name)
+				return false
 			}
-		default:
-			log.Printf("<%s> Unknown tool filter mode: %s, skipping tool filter", ps.name, mode)
+			return true
 		}
+	default:
+		slog.Warn("Unknown "+kind+" filter mode, skipping "+kind+" filter", "server", ps.logTag, "mode", mode)
+		return allowAll
 	}
+}
 
-	return filterFunc
+// allowAll 不过滤的过滤函数
+func allowAll(string) bool {
+	return true
 }
 
 // addPrompts 添加提示词
 func (ps *ProxyServer) addPrompts(ctx context.Context, client interfaces.MCPClient) error {
 	promptsRequest := mcp.ListPromptsRequest{}
+
+	// 提示词过滤函数
+	filterFunc := ps.createPromptFilter()
+
 	for {
 		prompts, err := client.ListPrompts(ctx, promptsRequest)
 		if err != nil {
@@ -233,10 +802,20 @@ func (ps *ProxyServer) addPrompts(ctx context.Context, client interfaces.MCPClie
 			break
 		}
 
-		log.Printf("<%s> Successfully listed %d prompts", ps.name, len(prompts.Prompts))
+		slog.Info("Successfully listed prompts", "server", ps.logTag, "count", len(prompts.Prompts))
 		for _, prompt := range prompts.Prompts {
-			log.Printf("<%s> Adding prompt %s", ps.name, prompt.Name)
-			ps.mcpServer.AddPrompt(prompt, client.GetPrompt)
+			if !filterFunc(prompt.Name) {
+				continue
+			}
+			handler := server.PromptHandlerFunc(cancelOnDisconnect(ps.sessions, client.GetPrompt))
+			if options := ps.serverConfig.Options; options != nil && options.PromptNamePrefix != "" {
+				prompt, handler = prefixPrompt(options.PromptNamePrefix, prompt, handler)
+			}
+			slog.Info("Adding prompt", "server", ps.logTag, "prompt", prompt.Name)
+			ps.mcpServer.AddPrompt(prompt, handler)
+			ps.resourcesMutex.Lock()
+			ps.prompts[prompt.Name] = prompt
+			ps.resourcesMutex.Unlock()
 		}
 
 		if prompts.NextCursor == "" {
@@ -247,9 +826,31 @@ func (ps *ProxyServer) addPrompts(ctx context.Context, client interfaces.MCPClie
 	return nil
 }
 
+// prefixPrompt 以 {prefix}_{name} 注册提示词，转发给上游时去掉前缀
+func prefixPrompt(prefix string, prompt mcp.Prompt, handler server.PromptHandlerFunc) (mcp.Prompt, server.PromptHandlerFunc) {
+	name := prompt.Name
+	prompt.Name = prefix + "_" + name
+	return prompt, func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		request.Params.Name = name
+		return handler(ctx, request)
+	}
+}
+
+// resourceNamePrefix 资源和资源模板名称的前缀，资源按 URI 读取，因此只修改名称
+func (ps *ProxyServer) resourceNamePrefix() string {
+	if ps.serverConfig.Options == nil || ps.serverConfig.Options.ResourceNamePrefix == "" {
+		return ""
+	}
+	return ps.serverConfig.Options.ResourceNamePrefix + "_"
+}
+
 // addResources 添加资源
 func (ps *ProxyServer) addResources(ctx context.Context, client interfaces.MCPClient) error {
 	resourcesRequest := mcp.ListResourcesRequest{}
+
+	// 资源过滤函数
+	filterFunc := ps.createResourceFilter()
+
 	for {
 		resources, err := client.ListResources(ctx, resourcesRequest)
 		if err != nil {
@@ -260,16 +861,23 @@ func (ps *ProxyServer) addResources(ctx context.Context, client interfaces.MCPCl
 			break
 		}
 
-		log.Printf("<%s> Successfully listed %d resources", ps.name, len(resources.Resources))
+		slog.Info("Successfully listed resources", "server", ps.logTag, "count", len(resources.Resources))
 		for _, resource := range resources.Resources {
-			log.Printf("<%s> Adding resource %s", ps.name, resource.Name)
-			ps.mcpServer.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			if !filterFunc(resource.URI) {
+				continue
+			}
+			resource.Name = ps.resourceNamePrefix() + resource.Name
+			slog.Info("Adding resource", "server", ps.logTag, "resource", resource.Name)
+			ps.mcpServer.AddResource(resource, cancelOnDisconnect(ps.sessions, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 				readResource, e := client.ReadResource(ctx, request)
 				if e != nil {
 					return nil, e
 				}
 				return readResource.Contents, nil
-			})
+			}))
+			ps.resourcesMutex.Lock()
+			ps.resources[resource.URI] = resource
+			ps.resourcesMutex.Unlock()
 		}
 
 		if resources.NextCursor == "" {
@@ -283,6 +891,10 @@ func (ps *ProxyServer) addResources(ctx context.Context, client interfaces.MCPCl
 // addResourceTemplates 添加资源模板
 func (ps *ProxyServer) addResourceTemplates(ctx context.Context, client interfaces.MCPClient) error {
 	resourceTemplatesRequest := mcp.ListResourceTemplatesRequest{}
+
+	// 资源过滤函数
+	filterFunc := ps.createResourceFilter()
+
 	for {
 		resourceTemplates, err := client.ListResourceTemplates(ctx, resourceTemplatesRequest)
 		if err != nil {
@@ -293,16 +905,23 @@ func (ps *ProxyServer) addResourceTemplates(ctx context.Context, client interfac
 			break
 		}
 
-		log.Printf("<%s> Successfully listed %d resource templates", ps.name, len(resourceTemplates.ResourceTemplates))
+		slog.Info("Successfully listed resource templates", "server", ps.logTag, "count", len(resourceTemplates.ResourceTemplates))
 		for _, resourceTemplate := range resourceTemplates.ResourceTemplates {
-			log.Printf("<%s> Adding resource template %s", ps.name, resourceTemplate.Name)
-			ps.mcpServer.AddResourceTemplate(resourceTemplate, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			if !filterFunc(resourceTemplate.URITemplate.Raw()) {
+				continue
+			}
+			resourceTemplate.Name = ps.resourceNamePrefix() + resourceTemplate.Name
+			slog.Info("Adding resource template", "server", ps.logTag, "resource_template", resourceTemplate.Name)
+			ps.mcpServer.AddResourceTemplate(resourceTemplate, cancelOnDisconnect(ps.sessions, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 				readResource, e := client.ReadResource(ctx, request)
 				if e != nil {
 					return nil, e
 				}
 				return readResource.Contents, nil
-			})
+			}))
+			ps.resourcesMutex.Lock()
+			ps.resourceTemplates[resourceTemplate.URITemplate.Raw()] = resourceTemplate
+			ps.resourcesMutex.Unlock()
 		}
 
 		if resourceTemplates.NextCursor == "" {
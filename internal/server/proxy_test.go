@@ -0,0 +1,162 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/server"
+	"github.com/ceyewan/mcp-proxy/internal/testutil"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// sessionIDHeader Streamable HTTP 会话头
+	sessionIDHeader = "Mcp-Session-Id"
+	// initializeBody 初始化请求
+	initializeBody = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"test","version":"1.0.0"},"capabilities":{}}}`
+)
+
+// postMCP 向 Streamable HTTP 端点发送一条 JSON-RPC 消息
+func postMCP(t *testing.T, url, sessionID, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// startStreamableProxy 启动 Streamable HTTP 代理并返回 mock 服务器的端点
+func startStreamableProxy(t *testing.T, stateful *bool) string {
+	t.Helper()
+
+	mock := testutil.NewMockMCPServer(t)
+	addr := testutil.StartProxy(t, &interfaces.Config{
+		Proxy: interfaces.ProxyConfig{
+			Name:     "test-proxy",
+			Version:  "1.0.0",
+			Type:     interfaces.TransportTypeHTTP,
+			Stateful: stateful,
+		},
+		Servers: map[string]interfaces.ServerConfig{"mock": mock.ServerConfig()},
+	})
+	return fmt.Sprintf("http://%s/mock/mcp", addr)
+}
+
+func TestStatefulSessionPersists(t *testing.T) {
+	stateful := true
+	url := startStreamableProxy(t, &stateful)
+
+	resp := postMCP(t, url, "", initializeBody)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize status = %d, want 200", resp.StatusCode)
+	}
+	sessionID := resp.Header.Get(sessionIDHeader)
+	if sessionID == "" {
+		t.Fatal("initialize returned no session ID")
+	}
+
+	// 同一会话内的后续请求都被接受
+	for i := 2; i <= 4; i++ {
+		resp := postMCP(t, url, sessionID, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/list"}`, i))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d in session status = %d, want 200", i, resp.StatusCode)
+		}
+		if got := resp.Header.Get(sessionIDHeader); got != "" && got != sessionID {
+			t.Errorf("request %d session ID = %q, want %q", i, got, sessionID)
+		}
+	}
+
+	// 未知会话被拒绝
+	resp = postMCP(t, url, "unknown-session", `{"jsonrpc":"2.0","id":5,"method":"tools/list"}`)
+	if resp.StatusCode == http.StatusOK {
+		t.Errorf("request with unknown session status = %d, want error", resp.StatusCode)
+	}
+}
+
+func TestStatelessHasNoSession(t *testing.T) {
+	for _, stateful := range []*bool{nil, new(bool)} {
+		url := startStreamableProxy(t, stateful)
+
+		resp := postMCP(t, url, "", initializeBody)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("initialize status = %d, want 200", resp.StatusCode)
+		}
+		if sessionID := resp.Header.Get(sessionIDHeader); sessionID != "" {
+			t.Errorf("stateless initialize returned session ID %q", sessionID)
+		}
+
+		// 无状态模式下不带会话的请求直接处理
+		resp = postMCP(t, url, "", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("stateless tools/list status = %d, want 200", resp.StatusCode)
+		}
+	}
+}
+
+// emptyClient 没有任何工具、提示词和资源的已连接客户端
+type emptyClient struct {
+	interfaces.MCPClient
+}
+
+func (emptyClient) GetName() string   { return "empty" }
+func (emptyClient) IsConnected() bool { return true }
+
+func (emptyClient) ListTools(context.Context, mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	return &mcp.ListToolsResult{}, nil
+}
+
+func (emptyClient) ListPrompts(context.Context, mcp.ListPromptsRequest) (*mcp.ListPromptsResult, error) {
+	return &mcp.ListPromptsResult{}, nil
+}
+
+func (emptyClient) ListResources(context.Context, mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	return &mcp.ListResourcesResult{}, nil
+}
+
+func (emptyClient) ListResourceTemplates(context.Context, mcp.ListResourceTemplatesRequest) (*mcp.ListResourceTemplatesResult, error) {
+	return &mcp.ListResourceTemplatesResult{}, nil
+}
+
+// TestStatsDuringClientChanges 在 -race 下检查 Stats 与注册、注销客户端并发执行
+func TestStatsDuringClientChanges(t *testing.T) {
+	ps, err := server.NewProxyServer("test", &interfaces.ProxyConfig{Name: "test", Version: "1.0.0", Type: interfaces.TransportTypeHTTP}, interfaces.ServerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = ps.RegisterClient(emptyClient{})
+			_ = ps.UnregisterClient()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_ = ps.Stats()
+		_ = ps.GetClient()
+	}
+	wg.Wait()
+
+	if ps.GetClient() != nil {
+		t.Error("GetClient() != nil after UnregisterClient")
+	}
+}
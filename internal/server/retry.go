@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// retryJitter 重试间隔的随机浮动比例
+const retryJitter = 0.1
+
+// retryToolCall 上游调用因网络错误失败时按指数退避重试，配置已在加载时设置默认值并校验
+//
+// isError 结果属于 MCP 层面的错误，原样返回不重试；
+// 请求上下文被取消或剩余时间不足以等待下一次重试时停止，返回最后一次的错误。
+func (ps *ProxyServer) retryToolCall(handler server.ToolHandlerFunc, config *interfaces.RetryConfig) server.ToolHandlerFunc {
+	initialDelay, _ := time.ParseDuration(config.InitialDelay)
+	maxDelay, _ := time.ParseDuration(config.MaxDelay)
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		delay := initialDelay
+		for attempt := 1; ; attempt++ {
+			result, err := handler(ctx, request)
+			if err == nil || attempt >= config.MaxAttempts || ctx.Err() != nil || !isTransientError(err) {
+				return result, err
+			}
+
+			wait := jitter(delay)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				return result, err
+			}
+			slog.WarnContext(ctx, "Tool call failed, retrying", "server", ps.logTag, "tool", request.Params.Name,
+				"attempt", attempt+1, "max_attempts", config.MaxAttempts, "delay", wait, "error", err)
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return result, err
+			case <-timer.C:
+			}
+			delay = min(delay*2, maxDelay)
+		}
+	}
+}
+
+// jitter 为重试间隔加上 ±10% 的随机浮动
+func jitter(delay time.Duration) time.Duration {
+	return time.Duration(float64(delay) * (1 + retryJitter*(2*rand.Float64()-1)))
+}
+
+// isTransientError 判断错误是否为可重试的网络错误
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
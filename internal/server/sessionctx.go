@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionContexts 记录每个客户端会话的生命周期上下文
+//
+// SSE 传输中工具调用通过 POST 提交，mcp-go 会将其上下文与 POST 请求解绑，
+// 结果经由 SSE 流返回。因此调用方断开（SSE 流关闭）时只能通过会话注销感知，
+// 会话注销时取消对应上下文，从而取消仍在进行的上游调用。
+type sessionContexts struct {
+	mutex   sync.Mutex
+	cancels map[string]context.CancelFunc
+	ctxs    map[string]context.Context
+}
+
+// newSessionContexts 创建会话上下文表
+func newSessionContexts() *sessionContexts {
+	return &sessionContexts{
+		cancels: make(map[string]context.CancelFunc),
+		ctxs:    make(map[string]context.Context),
+	}
+}
+
+// hooks 返回在会话注册时创建上下文、注销时取消上下文的钩子
+func (s *sessionContexts) hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		sessionCtx, cancel := context.WithCancel(context.Background())
+		s.mutex.Lock()
+		s.ctxs[session.SessionID()] = sessionCtx
+		s.cancels[session.SessionID()] = cancel
+		s.mutex.Unlock()
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.mutex.Lock()
+		cancel := s.cancels[session.SessionID()]
+		delete(s.ctxs, session.SessionID())
+		delete(s.cancels, session.SessionID())
+		s.mutex.Unlock()
+		if cancel != nil {
+			cancel()
+		}
+	})
+	return hooks
+}
+
+// bind 返回在会话结束时一并取消的上下文；无会话时原样返回
+func (s *sessionContexts) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return ctx, func() {}
+	}
+
+	s.mutex.Lock()
+	sessionCtx, ok := s.ctxs[session.SessionID()]
+	s.mutex.Unlock()
+	if !ok {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(sessionCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// cancelOnDisconnect 包装上游调用，调用方断开时取消进行中的请求
+func cancelOnDisconnect[Req, Res any](sessions *sessionContexts, handler func(context.Context, Req) (Res, error)) func(context.Context, Req) (Res, error) {
+	return func(ctx context.Context, request Req) (Res, error) {
+		ctx, cancel := sessions.bind(ctx)
+		defer cancel()
+		return handler(ctx, request)
+	}
+}
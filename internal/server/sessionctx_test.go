@@ -0,0 +1,107 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/ceyewan/mcp-proxy/internal/testutil"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// addBlockingTool 在模拟服务器上注册一直阻塞到上下文取消的 block 工具
+func addBlockingTool(mock *testutil.MockMCPServer) (started, cancelled <-chan struct{}) {
+	startedCh := make(chan struct{}, 1)
+	cancelledCh := make(chan struct{}, 1)
+	mock.AddTool(mcp.NewTool("block"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		startedCh <- struct{}{}
+		select {
+		case <-ctx.Done():
+			cancelledCh <- struct{}{}
+			return nil, ctx.Err()
+		case <-time.After(30 * time.Second):
+			return mcp.NewToolResultText("finished"), nil
+		}
+	})
+	return startedCh, cancelledCh
+}
+
+func TestUpstreamCallCancelledWithCaller(t *testing.T) {
+	tests := []struct {
+		name      string
+		proxyType string
+		newClient func(addr string) (*client.Client, error)
+		// abort 模拟调用方放弃请求
+		abort func(c *client.Client, cancel context.CancelFunc)
+	}{
+		{
+			name:      "streamable http request cancelled",
+			proxyType: interfaces.TransportTypeHTTP,
+			newClient: func(addr string) (*client.Client, error) {
+				return client.NewStreamableHttpClient(fmt.Sprintf("http://%s/mock/mcp", addr))
+			},
+			abort: func(c *client.Client, cancel context.CancelFunc) { cancel() },
+		},
+		{
+			name:      "sse stream closed",
+			proxyType: interfaces.TransportTypeSSE,
+			newClient: func(addr string) (*client.Client, error) {
+				return client.NewSSEMCPClient(fmt.Sprintf("http://%s/mock/sse", addr))
+			},
+			abort: func(c *client.Client, cancel context.CancelFunc) { c.Close() },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := testutil.NewMockMCPServer(t)
+			started, cancelled := addBlockingTool(mock)
+			addr := testutil.StartProxy(t, &interfaces.Config{
+				Proxy:   interfaces.ProxyConfig{Name: "test-proxy", Version: "1.0.0", Type: tt.proxyType},
+				Servers: map[string]interfaces.ServerConfig{"mock": mock.ServerConfig()},
+			})
+
+			c, err := tt.newClient(addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := c.Start(ctx); err != nil {
+				t.Fatal(err)
+			}
+			initRequest := mcp.InitializeRequest{}
+			initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+			initRequest.Params.ClientInfo = mcp.Implementation{Name: "test", Version: "1.0.0"}
+			if _, err := c.Initialize(ctx, initRequest); err != nil {
+				t.Fatal(err)
+			}
+
+			callCtx, cancelCall := context.WithCancel(ctx)
+			defer cancelCall()
+			go func() {
+				request := mcp.CallToolRequest{}
+				request.Params.Name = "block"
+				_, _ = c.CallTool(callCtx, request)
+			}()
+
+			select {
+			case <-started:
+			case <-time.After(10 * time.Second):
+				t.Fatal("upstream tool was not called")
+			}
+			tt.abort(c, cancelCall)
+
+			select {
+			case <-cancelled:
+			case <-time.After(10 * time.Second):
+				t.Fatal("upstream call was not cancelled after the caller went away")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultToolCacheMaxEntries 工具结果缓存默认的最大条目数
+const defaultToolCacheMaxEntries = 1000
+
+// CacheRecorder 记录工具结果缓存命中与未命中的指标接口
+type CacheRecorder interface {
+	IncToolCacheHit(server, tool string)
+	IncToolCacheMiss(server, tool string)
+}
+
+// toolCacheKey 缓存键，由工具名和参数 JSON 的 SHA-256 组成
+type toolCacheKey struct {
+	tool string
+	args string
+}
+
+// toolCacheEntry 缓存条目
+type toolCacheEntry struct {
+	key       toolCacheKey
+	result    *mcp.CallToolResult
+	expiresAt time.Time
+}
+
+// toolCache 按 LRU 淘汰的工具调用结果缓存
+type toolCache struct {
+	ttl        time.Duration
+	toolTTLs   map[string]time.Duration
+	maxEntries int
+
+	mutex   sync.Mutex
+	entries map[toolCacheKey]*list.Element
+	order   *list.List
+}
+
+// newToolCache 按配置创建工具结果缓存，配置已在加载时校验
+func newToolCache(config *interfaces.ToolCacheConfig) *toolCache {
+	c := &toolCache{
+		toolTTLs:   make(map[string]time.Duration, len(config.Tools)),
+		maxEntries: config.MaxEntries,
+		entries:    make(map[toolCacheKey]*list.Element),
+		order:      list.New(),
+	}
+	c.ttl, _ = time.ParseDuration(config.TTL)
+	for tool, ttl := range config.Tools {
+		c.toolTTLs[tool], _ = time.ParseDuration(ttl)
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = defaultToolCacheMaxEntries
+	}
+	return c
+}
+
+// ttlFor 获取工具的缓存时长，返回 0 表示不缓存该工具
+func (c *toolCache) ttlFor(tool string) time.Duration {
+	if ttl, ok := c.toolTTLs[tool]; ok {
+		return ttl
+	}
+	return c.ttl
+}
+
+// get 获取未过期的缓存结果
+func (c *toolCache) get(key toolCacheKey, now time.Time) (*mcp.CallToolResult, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*toolCacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.result, true
+}
+
+// put 写入缓存结果，超过最大条目数时淘汰最久未使用的条目
+func (c *toolCache) put(key toolCacheKey, result *mcp.CallToolResult, expiresAt time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*toolCacheEntry)
+		entry.result = result
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&toolCacheEntry{key: key, result: result, expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*toolCacheEntry).key)
+	}
+}
+
+// purge 清空缓存，返回清除的条目数
+func (c *toolCache) purge() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	count := c.order.Len()
+	clear(c.entries)
+	c.order.Init()
+	return count
+}
+
+// invalidateToolCache 清空工具结果缓存，客户端重新连接后上游状态可能已变化
+func (ps *ProxyServer) invalidateToolCache() {
+	if ps.toolCache == nil {
+		return
+	}
+	if count := ps.toolCache.purge(); count > 0 {
+		slog.Info("Tool result cache invalidated", "server", ps.logTag, "entries", count)
+	}
+}
+
+// toolCacheMiddleware 缓存成功的工具调用结果，命中时不再转发给上游
+//
+// 缓存键由工具名和参数 JSON 的 SHA-256 组成，错误和 isError 结果不缓存；tools 中的 TTL 按上游工具名查找。
+func toolCacheMiddleware(name, toolNamePrefix string, cache *toolCache, recorder CacheRecorder) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			toolName := request.Params.Name
+			ttl := cache.ttlFor(upstreamToolName(toolNamePrefix, toolName))
+			if ttl <= 0 {
+				return next(ctx, request)
+			}
+
+			data, err := json.Marshal(request.Params.Arguments)
+			if err != nil {
+				return next(ctx, request)
+			}
+			sum := sha256.Sum256(data)
+			key := toolCacheKey{tool: toolName, args: hex.EncodeToString(sum[:])}
+
+			if result, ok := cache.get(key, time.Now()); ok {
+				if recorder != nil {
+					recorder.IncToolCacheHit(name, toolName)
+				}
+				slog.DebugContext(ctx, "Tool result served from cache", "server", name, "tool", toolName)
+				return result, nil
+			}
+			if recorder != nil {
+				recorder.IncToolCacheMiss(name, toolName)
+			}
+
+			result, err := next(ctx, request)
+			if err == nil && result != nil && !result.IsError {
+				cache.put(key, result, time.Now().Add(ttl))
+			}
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// toolFilterListTimeout 获取远程工具过滤列表的超时时间
+const toolFilterListTimeout = 10 * time.Second
+
+// startToolFilterList 获取远程工具过滤列表，并按配置的间隔在后台刷新
+//
+// 列表变化时重新获取上游工具，使新加入阻止列表的工具立即失效。
+func (ps *ProxyServer) startToolFilterList() {
+	filter := ps.toolFilter.Load()
+	if filter == nil || filter.ListURL == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ps.stopToolFilterList = cancel
+
+	// 首次获取同步进行，保证注册工具前列表已生效
+	ps.loadToolFilterList(ctx, filter.ListURL)
+
+	// 配置已在加载时校验
+	interval, _ := time.ParseDuration(filter.ListRefreshInterval)
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !ps.loadToolFilterList(ctx, filter.ListURL) {
+					continue
+				}
+				slog.Info("Tool filter list changed, refreshing tools", "server", ps.logTag)
+				if err := ps.RefreshResources(ctx); err != nil {
+					slog.Error("Failed to refresh tools after filter list change", "server", ps.logTag, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// setToolFilter 设置工具过滤配置并预先编译其中的通配符和正则表达式
+func (ps *ProxyServer) setToolFilter(filter *interfaces.ToolFilterConfig) {
+	var matcher *toolMatcher
+	if filter != nil {
+		var err error
+		// 配置加载时已校验
+		if matcher, err = newToolMatcher(filter.List); err != nil {
+			slog.Warn("Tool filter list unavailable", "server", ps.logTag, "error", err)
+		}
+	}
+	ps.toolFilter.Store(filter)
+	ps.toolFilterMatcher.Store(matcher)
+}
+
+// UpdateToolFilter 替换工具过滤配置并重新获取上游工具，不需要重新连接上游
+func (ps *ProxyServer) UpdateToolFilter(ctx context.Context, filter *interfaces.ToolFilterConfig) error {
+	if ps.stopToolFilterList != nil {
+		ps.stopToolFilterList()
+		ps.stopToolFilterList = nil
+	}
+	ps.remoteToolFilter.Store(nil)
+	ps.remoteToolMatcher.Store(nil)
+	ps.setToolFilter(filter)
+	ps.startToolFilterList()
+
+	slog.Info("Tool filter updated, refreshing tools", "server", ps.logTag)
+	return ps.RefreshResources(ctx)
+}
+
+// toolMatcher 工具名匹配器，支持精确名称、含 * 和 ? 的通配符以及 /.../ 包裹的正则表达式
+type toolMatcher struct {
+	names    map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+// newToolMatcher 编译工具过滤列表，精确名称使用集合查找，模式按列表顺序匹配
+func newToolMatcher(entries []string) (*toolMatcher, error) {
+	matcher := &toolMatcher{names: make(map[string]struct{}, len(entries))}
+	for _, entry := range entries {
+		pattern, ok, err := compileToolPattern(entry)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			matcher.names[entry] = struct{}{}
+			continue
+		}
+		matcher.patterns = append(matcher.patterns, pattern)
+	}
+	return matcher, nil
+}
+
+// compileToolPattern 编译通配符或正则表达式条目，普通名称返回 false
+func compileToolPattern(entry string) (*regexp.Regexp, bool, error) {
+	if len(entry) >= 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+		pattern, err := regexp.Compile(entry[1 : len(entry)-1])
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid tool filter pattern %q: %w", entry, err)
+		}
+		return pattern, true, nil
+	}
+	if !strings.ContainsAny(entry, "*?") {
+		return nil, false, nil
+	}
+
+	// 通配符匹配整个名称
+	quoted := regexp.QuoteMeta(entry)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.MustCompile("^" + quoted + "$"), true, nil
+}
+
+// Match 判断工具名是否匹配任一条目
+func (m *toolMatcher) Match(name string) bool {
+	if m == nil {
+		return false
+	}
+	if _, ok := m.names[name]; ok {
+		return true
+	}
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadToolFilterList 获取远程工具过滤列表，返回列表是否发生变化；获取失败时保留上一次的列表
+func (ps *ProxyServer) loadToolFilterList(ctx context.Context, url string) bool {
+	names, err := fetchToolFilterList(ctx, url)
+	if err != nil {
+		slog.Warn("Failed to fetch tool filter list", "server", ps.logTag, "url", url, "error", err)
+		return false
+	}
+
+	matcher, err := newToolMatcher(names)
+	if err != nil {
+		slog.Warn("Ignoring invalid tool filter list", "server", ps.logTag, "url", url, "error", err)
+		return false
+	}
+
+	previous := ps.remoteToolFilter.Swap(&names)
+	if previous != nil && slices.Equal(*previous, names) {
+		return false
+	}
+	ps.remoteToolMatcher.Store(matcher)
+	slog.Info("Loaded tool filter list", "server", ps.logTag, "url", url, "tools", len(names))
+	return true
+}
+
+// fetchToolFilterList 获取纯文本格式的工具名称列表，每行一个，忽略空行和 # 开头的注释
+func fetchToolFilterList(ctx context.Context, url string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, toolFilterListTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	var names []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+)
+
+// newFilterTestServer 创建只配置了工具过滤的代理服务器
+func newFilterTestServer(t testing.TB, filter *interfaces.ToolFilterConfig) *ProxyServer {
+	t.Helper()
+
+	ps, err := NewProxyServer("test", &interfaces.ProxyConfig{Name: "test", Version: "1.0.0", Type: interfaces.TransportTypeHTTP}, interfaces.ServerConfig{
+		Options: &interfaces.OptionsConfig{ToolFilter: filter},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ps
+}
+
+func TestToolFilterModeCase(t *testing.T) {
+	tests := []struct {
+		mode          string
+		wantListed    bool
+		wantNotListed bool
+	}{
+		{mode: "allow", wantListed: true, wantNotListed: false},
+		{mode: "Allow", wantListed: true, wantNotListed: false},
+		{mode: "ALLOW", wantListed: true, wantNotListed: false},
+		{mode: "block", wantListed: false, wantNotListed: true},
+		{mode: "Block", wantListed: false, wantNotListed: true},
+		{mode: "BLOCK", wantListed: false, wantNotListed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			filter := newFilterTestServer(t, &interfaces.ToolFilterConfig{Mode: tt.mode, List: []string{"fetch"}}).createToolFilter()
+			if got := filter("fetch"); got != tt.wantListed {
+				t.Errorf("filter(fetch) = %v, want %v", got, tt.wantListed)
+			}
+			if got := filter("search"); got != tt.wantNotListed {
+				t.Errorf("filter(search) = %v, want %v", got, tt.wantNotListed)
+			}
+		})
+	}
+}
+
+func TestToolFilterListURLRefresh(t *testing.T) {
+	var list atomic.Value
+	list.Store("# blocked tools\ndangerous\n\n")
+	var fetches atomic.Int64
+	var unavailable atomic.Bool
+	listServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if unavailable.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, list.Load().(string))
+	}))
+	defer listServer.Close()
+
+	ps := newFilterTestServer(t, &interfaces.ToolFilterConfig{
+		Mode:                interfaces.ToolFilterModeBlock,
+		List:                []string{"inline"},
+		ListURL:             listServer.URL,
+		ListRefreshInterval: "20ms",
+	})
+	ps.startToolFilterList()
+	defer ps.stopToolFilterList()
+
+	// 首次获取同步完成，远程列表与配置列表合并
+	filter := ps.createToolFilter()
+	if filter("dangerous") || filter("inline") {
+		t.Fatal("tools in the inline or remote list should be blocked")
+	}
+	if !filter("rm") {
+		t.Fatal("rm should be allowed before the list changes")
+	}
+
+	list.Store("dangerous\nrm\n")
+	waitFor(t, func() bool { return !ps.createToolFilter()("rm") })
+	if !ps.createToolFilter()("safe") {
+		t.Error("tools outside the refreshed list should stay allowed")
+	}
+
+	// 获取失败时保留上一次的列表
+	unavailable.Store(true)
+	before := fetches.Load()
+	waitFor(t, func() bool { return fetches.Load() > before+1 })
+	if ps.createToolFilter()("rm") {
+		t.Error("a failed refresh should keep the previous list")
+	}
+}
+
+// waitFor 轮询 condition 直到为 true，超时后终止测试
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 2s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestToolMatcher(t *testing.T) {
+	matcher, err := newToolMatcher([]string{"read_file", "github_list_*", "/^jira_(create|update)_issue$/", "db_?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "read_file", want: true},
+		{name: "read_files", want: false},
+		{name: "github_list_repos", want: true},
+		{name: "github_list_", want: true},
+		{name: "github_create_issue", want: false},
+		{name: "my_github_list_repos", want: false},
+		{name: "jira_create_issue", want: true},
+		{name: "jira_update_issue", want: true},
+		{name: "jira_delete_issue", want: false},
+		{name: "db_1", want: true},
+		{name: "db_12", want: false},
+	}
+	for _, tt := range tests {
+		if got := matcher.Match(tt.name); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToolMatcherInvalidPattern(t *testing.T) {
+	if _, err := newToolMatcher([]string{"/[unclosed/"}); err == nil {
+		t.Error("newToolMatcher() error = nil for an invalid regular expression")
+	}
+}
+
+func TestToolFilterPatterns(t *testing.T) {
+	filter := newFilterTestServer(t, &interfaces.ToolFilterConfig{
+		Mode: interfaces.ToolFilterModeAllow,
+		List: []string{"search", "github_*", "/^fs_(read|stat)$/"},
+	}).createToolFilter()
+
+	for name, want := range map[string]bool{
+		"search":       true,
+		"github_list":  true,
+		"fs_read":      true,
+		"fs_write":     false,
+		"gitlab_list":  false,
+		"search_cache": false,
+	} {
+		if got := filter(name); got != want {
+			t.Errorf("filter(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// benchmarkToolNames 模拟上游 ListTools 返回的工具名称
+func benchmarkToolNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("github_tool_%d", i)
+	}
+	return names
+}
+
+func BenchmarkToolMatcher(b *testing.B) {
+	lists := map[string][]string{
+		"literal":  benchmarkToolNames(50),
+		"wildcard": {"gitlab_*", "jira_*", "github_tool_4?"},
+		"regex":    {"/^gitlab_/", "/^jira_(create|update)_/", "/^github_tool_4[0-9]$/"},
+		"mixed":    append(benchmarkToolNames(20), "gitlab_*", "/^jira_(create|update)_/"),
+	}
+	tools := benchmarkToolNames(100)
+
+	for name, list := range lists {
+		b.Run(name, func(b *testing.B) {
+			matcher, err := newToolMatcher(list)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, tool := range tools {
+					matcher.Match(tool)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCreateToolFilter(b *testing.B) {
+	// 过滤函数会为每个被过滤的工具记录日志，基准测试中丢弃
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ps := newFilterTestServer(b, &interfaces.ToolFilterConfig{
+		Mode: interfaces.ToolFilterModeBlock,
+		List: []string{"gitlab_*", "/^jira_(create|update)_/", "github_tool_7"},
+	})
+	tools := benchmarkToolNames(100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		filter := ps.createToolFilter()
+		for _, tool := range tools {
+			filter(tool)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// tunnelDialTimeout 建立隧道时连接上游的超时时间
+const tunnelDialTimeout = 10 * time.Second
+
+// TunnelHandler 处理 CONNECT 请求，在调用方与上游之间建立原始 TCP 隧道
+//
+// 只允许连接到已配置上游的地址，避免代理被用作开放转发。
+type TunnelHandler struct {
+	allowed map[string]struct{}
+}
+
+// NewTunnelHandler 创建隧道处理器，allowedHosts 为允许连接的 host:port 列表
+func NewTunnelHandler(allowedHosts []string) *TunnelHandler {
+	allowed := make(map[string]struct{}, len(allowedHosts))
+	for _, host := range allowedHosts {
+		allowed[host] = struct{}{}
+	}
+	return &TunnelHandler{allowed: allowed}
+}
+
+// ServeHTTP 处理 CONNECT 请求
+func (h *TunnelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	target := r.Host
+	if _, ok := h.allowed[target]; !ok {
+		slog.Warn("Rejected CONNECT to a host that is not a configured upstream", "target", target)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	upstream, err := net.DialTimeout("tcp", target, tunnelDialTimeout)
+	if err != nil {
+		slog.Error("Failed to connect tunnel", "target", target, "error", err)
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "Tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	downstream, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		slog.Error("Failed to hijack connection for tunnel", "target", target, "error", err)
+		return
+	}
+
+	slog.Info("Established CONNECT tunnel", "target", target)
+
+	// 调用方可能在 CONNECT 请求之后立即发送数据，先转发已缓冲的部分
+	if n := buffered.Reader.Buffered(); n > 0 {
+		data, _ := buffered.Reader.Peek(n)
+		if _, err := upstream.Write(data); err != nil {
+			upstream.Close()
+			downstream.Close()
+			return
+		}
+	}
+
+	go func() {
+		defer upstream.Close()
+		defer downstream.Close()
+		_, _ = io.Copy(upstream, downstream)
+	}()
+	go func() {
+		defer upstream.Close()
+		defer downstream.Close()
+		_, _ = io.Copy(downstream, upstream)
+	}()
+}
@@ -0,0 +1,203 @@
+// Package testutil 提供编写代理集成测试所需的辅助工具
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ceyewan/mcp-proxy/internal/app"
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// startTimeout 等待代理就绪的最长时间
+	startTimeout = 10 * time.Second
+	// callTimeout 单次工具调用的超时时间
+	callTimeout = 10 * time.Second
+)
+
+// MockMCPServer 进程内的最小 MCP 服务器，默认提供 echo 工具、greet 提示词和 test://hello 资源
+//
+// 代理的客户端只支持 stdio 和 HTTP 传输，因此服务器通过 httptest 监听回环地址，
+// 不依赖任何外部进程或网络环境。
+type MockMCPServer struct {
+	*server.MCPServer
+	httpServer *httptest.Server
+}
+
+// NewMockMCPServer 创建并启动模拟 MCP 服务器，测试结束时自动关闭
+func NewMockMCPServer(t testing.TB) *MockMCPServer {
+	t.Helper()
+
+	mcpServer := server.NewMCPServer(
+		"mock-mcp-server",
+		"1.0.0",
+		server.WithToolCapabilities(true),
+		server.WithPromptCapabilities(true),
+		server.WithResourceCapabilities(false, true),
+	)
+
+	mcpServer.AddTool(
+		mcp.NewTool("echo",
+			mcp.WithDescription("Echo the message back"),
+			mcp.WithString("message", mcp.Required()),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(request.GetString("message", "")), nil
+		},
+	)
+
+	mcpServer.AddPrompt(
+		mcp.NewPrompt("greet",
+			mcp.WithPromptDescription("Greet someone"),
+			mcp.WithArgument("name"),
+		),
+		func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return mcp.NewGetPromptResult("greeting", []mcp.PromptMessage{
+				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Hello, "+request.Params.Arguments["name"])),
+			}), nil
+		},
+	)
+
+	mcpServer.AddResource(
+		mcp.NewResource("test://hello", "hello", mcp.WithMIMEType("text/plain")),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{URI: request.Params.URI, MIMEType: "text/plain", Text: "hello"},
+			}, nil
+		},
+	)
+
+	mock := &MockMCPServer{
+		MCPServer:  mcpServer,
+		httpServer: server.NewTestStreamableHTTPServer(mcpServer),
+	}
+	t.Cleanup(mock.httpServer.Close)
+
+	return mock
+}
+
+// URL 返回模拟服务器的 Streamable HTTP 端点
+func (m *MockMCPServer) URL() string {
+	return m.httpServer.URL + "/mcp"
+}
+
+// ServerConfig 返回指向模拟服务器的上游配置
+func (m *MockMCPServer) ServerConfig() interfaces.ServerConfig {
+	return interfaces.ServerConfig{
+		Transport: interfaces.ClientTypeStreamable,
+		URL:       m.URL(),
+	}
+}
+
+// StartProxy 使用给定配置启动代理，等待 /readyz 返回 200（所有上游就绪）后返回监听地址
+//
+// 监听地址和 BaseURL 会被替换为随机的本地端口，代理在测试结束时自动关闭。
+func StartProxy(t testing.TB, config *interfaces.Config) string {
+	t.Helper()
+
+	addr := freeAddr(t)
+	config.Proxy.Addr = addr
+	config.Proxy.BaseURL = "http://" + addr
+
+	// 写入临时配置文件
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, data, 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	application, err := app.New("test")
+	if err != nil {
+		t.Fatalf("failed to create application: %v", err)
+	}
+	if err := application.Start(configPath); err != nil {
+		t.Fatalf("failed to start proxy: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = application.Shutdown(ctx)
+	})
+
+	waitReady(t, application.Addr())
+	return application.Addr()
+}
+
+// MustCallTool 通过代理的 SSE 端点调用工具，失败时终止测试
+func MustCallTool(t testing.TB, addr, serverName, tool string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+
+	mcpClient, err := client.NewSSEMCPClient(fmt.Sprintf("http://%s/%s/sse", addr, serverName))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer mcpClient.Close()
+
+	if err := mcpClient.Start(ctx); err != nil {
+		t.Fatalf("failed to start client: %v", err)
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "testutil", Version: "1.0.0"}
+	if _, err := mcpClient.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("failed to initialize: %v", err)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = tool
+	request.Params.Arguments = args
+	result, err := mcpClient.CallTool(ctx, request)
+	if err != nil {
+		t.Fatalf("failed to call tool %s: %v", tool, err)
+	}
+	return result
+}
+
+// freeAddr 获取一个空闲的本地端口
+func freeAddr(t testing.TB) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// waitReady 轮询 /readyz 直到返回 200
+func waitReady(t testing.TB, addr string) {
+	t.Helper()
+
+	deadline := time.Now().Add(startTimeout)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get("http://" + addr + "/readyz")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("proxy at %s did not become ready within %s", addr, startTimeout)
+}
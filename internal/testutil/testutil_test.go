@@ -0,0 +1,34 @@
+package testutil
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ceyewan/mcp-proxy/internal/interfaces"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestStartProxyAndCallTool(t *testing.T) {
+	mock := NewMockMCPServer(t)
+	addr := StartProxy(t, &interfaces.Config{
+		Proxy:   interfaces.ProxyConfig{Name: "test-proxy", Version: "1.0.0", Type: interfaces.TransportTypeSSE},
+		Servers: map[string]interfaces.ServerConfig{"mock": mock.ServerConfig()},
+	})
+
+	resp, err := http.Get("http://" + addr + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /healthz = %d, want 200", resp.StatusCode)
+	}
+
+	result := MustCallTool(t, addr, "mock", "echo", map[string]any{"message": "hello"})
+	if result.IsError || len(result.Content) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if text, ok := result.Content[0].(mcp.TextContent); !ok || text.Text != "hello" {
+		t.Errorf("echo returned %+v, want hello", result.Content[0])
+	}
+}